@@ -0,0 +1,118 @@
+package backfill
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/likecoin/likecoin-chain-tx-indexer/cmd/source"
+	"github.com/likecoin/likecoin-chain-tx-indexer/db"
+	"github.com/likecoin/likecoin-chain-tx-indexer/db/schema"
+	"github.com/likecoin/likecoin-chain-tx-indexer/extractor"
+	"github.com/likecoin/likecoin-chain-tx-indexer/logger"
+	"github.com/likecoin/likecoin-chain-tx-indexer/poller"
+)
+
+const (
+	CmdFrom        = "from"
+	CmdTo          = "to"
+	CmdOnlyMissing = "only-missing"
+	CmdExtract     = "extract"
+	CmdReport      = "report"
+)
+
+var Command = &cobra.Command{
+	Use:   "backfill",
+	Short: "Re-fetch and overwrite the indexed txs in a height range",
+	Long: `Re-fetch the blocks in the height range, or the heights in the report of "indexer verify", from the chain,
+and overwrite the indexed txs and block headers.
+The latest indexed height is not changed, so it can run while the poller is running.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		from, err := cmd.Flags().GetInt64(CmdFrom)
+		if err != nil {
+			return err
+		}
+		to, err := cmd.Flags().GetInt64(CmdTo)
+		if err != nil {
+			return err
+		}
+		onlyMissing, err := cmd.Flags().GetBool(CmdOnlyMissing)
+		if err != nil {
+			return err
+		}
+		extract, err := cmd.Flags().GetBool(CmdExtract)
+		if err != nil {
+			return err
+		}
+		reportPath, err := cmd.Flags().GetString(CmdReport)
+		if err != nil {
+			return err
+		}
+		var report *poller.VerifyReport
+		if reportPath != "" {
+			report, err = readReport(reportPath)
+			if err != nil {
+				return err
+			}
+		} else if from <= 0 || to < from {
+			return fmt.Errorf("invalid height range, from = %d, to = %d", from, to)
+		}
+
+		pool, err := db.GetConnPoolFromCmdArgs(cmd)
+		if err != nil {
+			logger.L.Panicw("Cannot initialize database connection pool", "error", err)
+		}
+		conn, err := db.AcquireFromPool(pool)
+		if err != nil {
+			logger.L.Panicw("Cannot acquire connection from database connection pool", "error", err)
+		}
+		err = schema.InitDB(conn)
+		if err != nil {
+			logger.L.Panicw("Cannot initialize database", "error", err)
+		}
+		conn.Release()
+
+		chainSource, release, err := source.GetChainSourceFromCmdArgs(cmd)
+		if err != nil {
+			logger.L.Panicw("Cannot initialize chain source", "error", err)
+		}
+		defer release()
+
+		var extractors []db.NamedExtractor
+		if extract {
+			extractors = extractor.Extractors
+		}
+		if report != nil {
+			count, err := poller.BackfillHeights(pool, chainSource, report.Heights, extractors)
+			logger.L.Infow("Backfill finished", "report", reportPath, "backfilled", count)
+			return err
+		}
+		count, err := poller.Backfill(pool, chainSource, from, to, onlyMissing, extractors)
+		logger.L.Infow("Backfill finished", "from", from, "to", to, "backfilled", count)
+		return err
+	},
+}
+
+func readReport(path string) (*poller.VerifyReport, error) {
+	reportJSON, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read verify report: %w", err)
+	}
+	report := poller.VerifyReport{}
+	err = json.Unmarshal(reportJSON, &report)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse verify report: %w", err)
+	}
+	return &report, nil
+}
+
+func init() {
+	Command.PersistentFlags().Int64(CmdFrom, 0, "first height to backfill")
+	Command.PersistentFlags().Int64(CmdTo, 0, "last height to backfill (inclusive)")
+	Command.PersistentFlags().Bool(CmdOnlyMissing, false, "only backfill the heights with missing block header, missing txs or empty placeholder txs")
+	Command.PersistentFlags().Bool(CmdExtract, false, "extract again from the lowest backfilled height if it is already extracted")
+	Command.PersistentFlags().String(CmdReport, "", "backfill the heights in the JSON report of `indexer verify` instead of a height range")
+	source.ConfigCmd(Command)
+}
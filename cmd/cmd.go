@@ -3,10 +3,16 @@ package cmd
 import (
 	"github.com/spf13/cobra"
 
+	"github.com/likecoin/likecoin-chain-tx-indexer/cmd/backfill"
+	"github.com/likecoin/likecoin-chain-tx-indexer/cmd/extract"
 	"github.com/likecoin/likecoin-chain-tx-indexer/cmd/importdb"
 	"github.com/likecoin/likecoin-chain-tx-indexer/cmd/migrate"
+	"github.com/likecoin/likecoin-chain-tx-indexer/cmd/reindex"
 	"github.com/likecoin/likecoin-chain-tx-indexer/cmd/serve"
+	"github.com/likecoin/likecoin-chain-tx-indexer/cmd/snapshot"
+	"github.com/likecoin/likecoin-chain-tx-indexer/cmd/verify"
 	"github.com/likecoin/likecoin-chain-tx-indexer/db"
+	"github.com/likecoin/likecoin-chain-tx-indexer/extractor"
 	"github.com/likecoin/likecoin-chain-tx-indexer/logger"
 )
 
@@ -15,6 +21,7 @@ var rootCmd = &cobra.Command{
 	Short: "The indexing service for LikeCoin chain transactions",
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
 		logger.SetupLoggerFromCmdArgs(cmd)
+		extractor.SetupExtractorFromCmdArgs(cmd)
 	},
 }
 
@@ -27,9 +34,15 @@ func Execute() {
 func init() {
 	db.ConfigCmd(rootCmd)
 	logger.ConfigCmd(rootCmd)
+	extractor.ConfigExtractorCmd(rootCmd)
 	rootCmd.AddCommand(
 		importdb.Command,
+		backfill.Command,
+		verify.Command,
+		reindex.Command,
+		extract.Command,
 		serve.Command,
+		snapshot.Command,
 		migrate.MigrateCommand,
 	)
 }
@@ -0,0 +1,47 @@
+package extract
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/likecoin/likecoin-chain-tx-indexer/db"
+	"github.com/likecoin/likecoin-chain-tx-indexer/db/schema"
+	"github.com/likecoin/likecoin-chain-tx-indexer/extractor"
+	"github.com/likecoin/likecoin-chain-tx-indexer/logger"
+)
+
+var Command = &cobra.Command{
+	Use:   "extract",
+	Short: "Manage the extraction of the indexed txs",
+}
+
+var RetryCommand = &cobra.Command{
+	Use:   "retry",
+	Short: "Run the extractors again on the txs with unresolved extraction failures",
+	Long: `Run the extractors again on the txs recorded in the extraction_failures table, e.g. after fixing an extractor.
+The failures are resolved if the extractor succeeds this time.
+The extractors are rewound to the lowest height of the failures, together with the extractors sharing tables with them,
+and replayed forward up to their checkpoints, so the txs are extracted again in order without writing the records twice.
+Nothing is published to pubsub during the replay.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pool, err := db.GetConnPoolFromCmdArgs(cmd)
+		if err != nil {
+			logger.L.Panicw("Cannot initialize database connection pool", "error", err)
+		}
+		conn, err := db.AcquireFromPool(pool)
+		if err != nil {
+			logger.L.Panicw("Cannot acquire connection from database connection pool", "error", err)
+		}
+		defer conn.Release()
+		err = schema.InitDB(conn)
+		if err != nil {
+			logger.L.Panicw("Cannot initialize database", "error", err)
+		}
+		res, err := db.RetryExtractionFailures(conn, extractor.Extractors)
+		logger.L.Infow("Retry finished", "retried", res.Retried, "resolved", res.Resolved, "failed", res.Failed, "skipped", res.Skipped)
+		return err
+	},
+}
+
+func init() {
+	Command.AddCommand(RetryCommand)
+}
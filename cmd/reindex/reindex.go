@@ -0,0 +1,79 @@
+package reindex
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/likecoin/likecoin-chain-tx-indexer/db"
+	"github.com/likecoin/likecoin-chain-tx-indexer/db/schema"
+	"github.com/likecoin/likecoin-chain-tx-indexer/extractor"
+	"github.com/likecoin/likecoin-chain-tx-indexer/logger"
+)
+
+const (
+	CmdTables     = "tables"
+	CmdFromHeight = "from-height"
+)
+
+var Command = &cobra.Command{
+	Use:   "reindex",
+	Short: "Rebuild the extracted tables from the indexed txs",
+	Long: fmt.Sprintf(`Rebuild the extracted tables by replaying the extractors writing them over the indexed txs.
+The tables written by the same extractors are rebuilt together.
+Without --from-height, the tables are rebuilt from the beginning as shadow tables, and then replace the original tables
+in one transaction, so the HTTP server keeps serving the old data until the rebuild finishes.
+With --from-height, the records extracted from the txs after the height are deleted, and the extractors replay them
+from the height. It is only supported for the tables keyed by tx (%s).
+It can run while the poller is running.`, strings.Join(db.TxKeyedTables(), ", ")),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tables, err := cmd.Flags().GetStringSlice(CmdTables)
+		if err != nil {
+			return err
+		}
+		if len(tables) == 0 {
+			return fmt.Errorf("no tables to reindex")
+		}
+		fromHeight, err := cmd.Flags().GetInt64(CmdFromHeight)
+		if err != nil {
+			return err
+		}
+		if fromHeight < 0 {
+			return fmt.Errorf("invalid from height %d", fromHeight)
+		}
+		extractors, allTables, err := db.ExtractorsOfTables(extractor.Extractors, tables)
+		if err != nil {
+			return err
+		}
+
+		pool, err := db.GetConnPoolFromCmdArgs(cmd)
+		if err != nil {
+			logger.L.Panicw("Cannot initialize database connection pool", "error", err)
+		}
+		conn, err := db.AcquireFromPool(pool)
+		if err != nil {
+			logger.L.Panicw("Cannot acquire connection from database connection pool", "error", err)
+		}
+		defer conn.Release()
+		err = schema.InitDB(conn)
+		if err != nil {
+			logger.L.Panicw("Cannot initialize database", "error", err)
+		}
+
+		names := []string{}
+		for _, e := range extractors {
+			names = append(names, e.Name)
+		}
+		logger.L.Infow("Reindexing tables", "tables", allTables, "extractors", names, "from_height", fromHeight)
+		if fromHeight > 0 {
+			return db.Rewind(conn, extractors, tables, fromHeight)
+		}
+		return db.Reindex(conn, extractors, allTables)
+	},
+}
+
+func init() {
+	Command.PersistentFlags().StringSlice(CmdTables, nil, "tables to rebuild, e.g. nft_event,nft_income")
+	Command.PersistentFlags().Int64(CmdFromHeight, 0, "only rebuild the records extracted from the txs after the height")
+}
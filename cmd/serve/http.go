@@ -1,9 +1,14 @@
 package serve
 
 import (
+	"net/http"
+	"time"
+
 	"github.com/spf13/cobra"
 
 	"github.com/likecoin/likecoin-chain-tx-indexer/db"
+	"github.com/likecoin/likecoin-chain-tx-indexer/extractor"
+	"github.com/likecoin/likecoin-chain-tx-indexer/lcd"
 	"github.com/likecoin/likecoin-chain-tx-indexer/logger"
 	"github.com/likecoin/likecoin-chain-tx-indexer/rest"
 )
@@ -26,17 +31,23 @@ func ServeHTTP(cmd *cobra.Command) {
 	if err != nil {
 		logger.L.Panicw("Cannot get listen address from command line parameters", "error", err)
 	}
-	lcdEndpoint, err := cmd.Flags().GetString(rest.CmdLcdEndpoint)
+	lcdEndpoints, err := cmd.Flags().GetStringSlice(rest.CmdLcdEndpoint)
 	if err != nil {
 		logger.L.Panicw("Cannot get lcd endpoint address from command line parameters", "error", err)
 	}
-	defaultApiAddresses, err := cmd.Flags().GetStringSlice(rest.CmdApiAddresses)
+	defaultApiAddresses, err := cmd.Flags().GetStringSlice(extractor.CmdApiAddresses)
 	if err != nil {
 		logger.L.Panicw("Cannot get API sender addresses from command line parameters", "error", err)
 	}
+	adminToken, err := cmd.Flags().GetString(rest.CmdAdminToken)
+	if err != nil {
+		logger.L.Panicw("Cannot get admin token from command line parameters", "error", err)
+	}
 
-	if lcdEndpoint[len(lcdEndpoint)-1] == '/' {
-		lcdEndpoint = lcdEndpoint[:len(lcdEndpoint)-1]
+	lcdPool, err := lcd.NewPool(lcdEndpoints, &http.Client{Timeout: 10 * time.Second})
+	if err != nil {
+		logger.L.Panicw("Cannot initialize lcd endpoint pool", "lcd_endpoints", lcdEndpoints, "error", err)
 	}
-	rest.Run(pool, listenAddr, lcdEndpoint, defaultApiAddresses)
+	lcdPool.StartHealthCheck()
+	rest.Run(pool, listenAddr, lcdPool, defaultApiAddresses, adminToken)
 }
@@ -11,9 +11,12 @@ import (
 	"github.com/likecoin/likecoin-chain-tx-indexer/db"
 	"github.com/likecoin/likecoin-chain-tx-indexer/db/schema"
 	"github.com/likecoin/likecoin-chain-tx-indexer/extractor"
+	"github.com/likecoin/likecoin-chain-tx-indexer/lcd"
 	"github.com/likecoin/likecoin-chain-tx-indexer/logger"
+	"github.com/likecoin/likecoin-chain-tx-indexer/metadata"
 	"github.com/likecoin/likecoin-chain-tx-indexer/poller"
 	"github.com/likecoin/likecoin-chain-tx-indexer/pubsub"
+	"github.com/likecoin/likecoin-chain-tx-indexer/rest"
 )
 
 var PollerCommand = &cobra.Command{
@@ -41,7 +44,7 @@ func ServePoller(cmd *cobra.Command) {
 	}
 	conn.Release()
 
-	lcdEndpoint, err := cmd.Flags().GetString("lcd-endpoint")
+	lcdEndpoints, err := cmd.Flags().GetStringSlice(rest.CmdLcdEndpoint)
 	if err != nil {
 		logger.L.Panicw("Cannot get lcd endpoint address from command line parameters", "error", err)
 	}
@@ -51,15 +54,56 @@ func ServePoller(cmd *cobra.Command) {
 		logger.L.Errorw("Pubsub initialization filed", "error", err)
 	}
 
-	ctx := poller.CosmosCallContext{
-		Codec: app.MakeEncodingConfig().Amino.Amino,
-		Client: &http.Client{
-			Transport: &http.Transport{
-				MaxIdleConnsPerHost: 20,
-			},
-			Timeout: 10 * time.Second,
+	lcdPool, err := lcd.NewPool(lcdEndpoints, &http.Client{
+		Transport: &http.Transport{
+			MaxIdleConnsPerHost: 20,
 		},
-		LcdEndpoint: lcdEndpoint,
+		Timeout: 10 * time.Second,
+	})
+	if err != nil {
+		logger.L.Panicw("Cannot initialize lcd endpoint pool", "lcd_endpoints", lcdEndpoints, "error", err)
+	}
+	var source poller.ChainSource = &poller.CosmosCallContext{
+		Codec: app.MakeEncodingConfig().Amino.Amino,
+		Lcd:   lcdPool,
+	}
+	grpcEndpoint, err := cmd.Flags().GetString(poller.CmdGrpcEndpoint)
+	if err != nil {
+		logger.L.Panicw("Cannot get grpc endpoint address from command line parameters", "error", err)
+	}
+	if grpcEndpoint == "" {
+		lcdPool.StartHealthCheck()
+	} else {
+		grpcSource, err := poller.DialGrpcChainSource(grpcEndpoint)
+		if err != nil {
+			logger.L.Panicw("Cannot connect to grpc endpoint", "grpc_endpoint", grpcEndpoint, "error", err)
+		}
+		defer grpcSource.Conn.Close()
+		source = grpcSource
+	}
+
+	resolver, err := metadata.NewResolverFromCmd(cmd, pool)
+	if err != nil {
+		logger.L.Panicw("Cannot initialize metadata resolver from command line parameters", "error", err)
+	}
+	if resolver != nil {
+		go resolver.Run()
+	}
+
+	sourceType, err := cmd.Flags().GetString(poller.CmdSource)
+	if err != nil {
+		logger.L.Panicw("Cannot get poller source from command line parameters", "error", err)
+	}
+	switch sourceType {
+	case poller.SourcePolling:
+		poller.Run(pool, source, extractor.Run(pool))
+	case poller.SourceRpcWebsocket:
+		rpcEndpoint, err := cmd.Flags().GetString(poller.CmdRpcEndpoint)
+		if err != nil {
+			logger.L.Panicw("Cannot get rpc endpoint address from command line parameters", "error", err)
+		}
+		poller.RunWebsocket(pool, source, rpcEndpoint, extractor.Run(pool))
+	default:
+		logger.L.Panicw("Unknown poller source", "source", sourceType)
 	}
-	poller.Run(pool, &ctx, extractor.Run(pool))
 }
@@ -3,6 +3,8 @@ package serve
 import (
 	"github.com/spf13/cobra"
 
+	"github.com/likecoin/likecoin-chain-tx-indexer/metadata"
+	"github.com/likecoin/likecoin-chain-tx-indexer/poller"
 	"github.com/likecoin/likecoin-chain-tx-indexer/pubsub"
 	"github.com/likecoin/likecoin-chain-tx-indexer/rest"
 )
@@ -21,4 +23,6 @@ func init() {
 	Command.AddCommand(PollerCommand, HTTPCommand)
 	rest.ConfigCmd(Command)
 	pubsub.ConfigCmd(Command)
+	poller.ConfigCmd(Command)
+	metadata.ConfigCmd(Command)
 }
@@ -0,0 +1,125 @@
+package snapshot
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/likecoin/likecoin-chain-tx-indexer/db"
+	"github.com/likecoin/likecoin-chain-tx-indexer/logger"
+)
+
+const (
+	CmdClass         = "class"
+	CmdHeight        = "height"
+	CmdTime          = "time"
+	CmdIncludeBurned = "include-burned"
+	CmdOutput        = "output"
+)
+
+var Command = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Export snapshots of the indexed data at a past point in time",
+}
+
+var HoldersCommand = &cobra.Command{
+	Use:   "holders",
+	Short: "Export the holders of the NFTs of classes at a height or time as CSV",
+	Long: `Export the holder of each NFT of the classes at the height or time as CSV, with the columns class_id, nft_id,
+owner and acquired_at, by replaying the NFT events up to the height or time.
+The height or time must have been extracted.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		classIds, err := cmd.Flags().GetStringSlice(CmdClass)
+		if err != nil {
+			return err
+		}
+		if len(classIds) == 0 {
+			return fmt.Errorf("no class to export")
+		}
+		height, err := cmd.Flags().GetInt64(CmdHeight)
+		if err != nil {
+			return err
+		}
+		timeStr, err := cmd.Flags().GetString(CmdTime)
+		if err != nil {
+			return err
+		}
+		includeBurned, err := cmd.Flags().GetBool(CmdIncludeBurned)
+		if err != nil {
+			return err
+		}
+		output, err := cmd.Flags().GetString(CmdOutput)
+		if err != nil {
+			return err
+		}
+		var atTime *time.Time
+		if timeStr != "" {
+			t, err := time.Parse(time.RFC3339, timeStr)
+			if err != nil {
+				return fmt.Errorf("invalid time %s: %w", timeStr, err)
+			}
+			atTime = &t
+		}
+		if (height > 0) == (atTime != nil) {
+			return fmt.Errorf("exactly one of --%s and --%s is required", CmdHeight, CmdTime)
+		}
+
+		pool, err := db.GetConnPoolFromCmdArgs(cmd)
+		if err != nil {
+			logger.L.Panicw("Cannot initialize database connection pool", "error", err)
+		}
+		conn, err := db.AcquireFromPool(pool)
+		if err != nil {
+			logger.L.Panicw("Cannot acquire connection from database connection pool", "error", err)
+		}
+		defer conn.Release()
+
+		t, err := db.GetOwnershipTime(conn, height, atTime)
+		if err != nil {
+			return err
+		}
+		holders, err := db.GetNftHoldersAt(conn, classIds, t, includeBurned)
+		if err != nil {
+			return err
+		}
+		logger.L.Infow("Holders snapshot finished", "classes", classIds, "height", height, "time", t, "nfts", len(holders))
+
+		if output == "" {
+			return writeHoldersCSV(os.Stdout, holders)
+		}
+		f, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return writeHoldersCSV(f, holders)
+	},
+}
+
+func writeHoldersCSV(w io.Writer, holders []db.NftHolder) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"class_id", "nft_id", "owner", "acquired_at"}); err != nil {
+		return err
+	}
+	for _, h := range holders {
+		if err := writer.Write([]string{h.ClassId, h.NftId, h.Owner, h.AcquiredAt.Format(time.RFC3339)}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func init() {
+	HoldersCommand.PersistentFlags().StringSlice(CmdClass, []string{}, "class IDs to export, comma separated or repeated")
+	HoldersCommand.PersistentFlags().Int64(CmdHeight, 0, "height of the snapshot")
+	HoldersCommand.PersistentFlags().String(CmdTime, "", "time of the snapshot in RFC3339, instead of height")
+	HoldersCommand.PersistentFlags().Bool(CmdIncludeBurned, false, "include the NFTs burned before the snapshot, held by the last owner")
+	HoldersCommand.PersistentFlags().String(CmdOutput, "", "path of the CSV file, or stdout if empty")
+	_ = HoldersCommand.MarkPersistentFlagRequired(CmdClass)
+	Command.AddCommand(HoldersCommand)
+}
@@ -0,0 +1,56 @@
+package source
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/likecoin/likecoin-chain/v4/app"
+
+	"github.com/likecoin/likecoin-chain-tx-indexer/lcd"
+	"github.com/likecoin/likecoin-chain-tx-indexer/logger"
+	"github.com/likecoin/likecoin-chain-tx-indexer/poller"
+	"github.com/likecoin/likecoin-chain-tx-indexer/rest"
+)
+
+// ConfigCmd adds the chain source flags for the commands fetching blocks outside of `serve`
+func ConfigCmd(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringSlice(rest.CmdLcdEndpoint, []string{rest.DefaultLcdEndpoint}, "LikeCoin chain lite client RPC endpoints, requests fail over between them")
+	cmd.PersistentFlags().String(poller.CmdGrpcEndpoint, "", "LikeCoin chain gRPC endpoint (e.g. localhost:9090) for fetching blocks and txs instead of the lcd endpoint")
+}
+
+// GetChainSourceFromCmdArgs returns the gRPC chain source if the gRPC endpoint is set, or the LCD chain source
+// otherwise. The returned function releases the source.
+func GetChainSourceFromCmdArgs(cmd *cobra.Command) (poller.ChainSource, func(), error) {
+	lcdEndpoints, err := cmd.Flags().GetStringSlice(rest.CmdLcdEndpoint)
+	if err != nil {
+		return nil, nil, err
+	}
+	grpcEndpoint, err := cmd.Flags().GetString(poller.CmdGrpcEndpoint)
+	if err != nil {
+		return nil, nil, err
+	}
+	if grpcEndpoint != "" {
+		grpcSource, err := poller.DialGrpcChainSource(grpcEndpoint)
+		if err != nil {
+			logger.L.Errorw("Cannot connect to grpc endpoint", "grpc_endpoint", grpcEndpoint, "error", err)
+			return nil, nil, err
+		}
+		return grpcSource, func() { grpcSource.Conn.Close() }, nil
+	}
+	lcdPool, err := lcd.NewPool(lcdEndpoints, &http.Client{
+		Transport: &http.Transport{
+			MaxIdleConnsPerHost: 20,
+		},
+		Timeout: 10 * time.Second,
+	})
+	if err != nil {
+		logger.L.Errorw("Cannot initialize lcd endpoint pool", "lcd_endpoints", lcdEndpoints, "error", err)
+		return nil, nil, err
+	}
+	return &poller.CosmosCallContext{
+		Codec: app.MakeEncodingConfig().Amino.Amino,
+		Lcd:   lcdPool,
+	}, func() {}, nil
+}
@@ -0,0 +1,85 @@
+package verify
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/likecoin/likecoin-chain-tx-indexer/cmd/source"
+	"github.com/likecoin/likecoin-chain-tx-indexer/db"
+	"github.com/likecoin/likecoin-chain-tx-indexer/logger"
+	"github.com/likecoin/likecoin-chain-tx-indexer/poller"
+)
+
+const (
+	CmdFrom   = "from"
+	CmdTo     = "to"
+	CmdOutput = "output"
+)
+
+var Command = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify the indexed txs in a height range against the chain",
+	Long: `Compare the stored tx count and tx hashes of each height with the blocks on the chain, and detect placeholder
+txs and duplicate or out-of-order tx indexes.
+The JSON report can be passed to "indexer backfill --report" to fix the heights with discrepancies.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		from, err := cmd.Flags().GetInt64(CmdFrom)
+		if err != nil {
+			return err
+		}
+		to, err := cmd.Flags().GetInt64(CmdTo)
+		if err != nil {
+			return err
+		}
+		output, err := cmd.Flags().GetString(CmdOutput)
+		if err != nil {
+			return err
+		}
+		if from <= 0 || to < from {
+			return fmt.Errorf("invalid height range, from = %d, to = %d", from, to)
+		}
+
+		pool, err := db.GetConnPoolFromCmdArgs(cmd)
+		if err != nil {
+			logger.L.Panicw("Cannot initialize database connection pool", "error", err)
+		}
+		conn, err := db.AcquireFromPool(pool)
+		if err != nil {
+			logger.L.Panicw("Cannot acquire connection from database connection pool", "error", err)
+		}
+		defer conn.Release()
+
+		chainSource, release, err := source.GetChainSourceFromCmdArgs(cmd)
+		if err != nil {
+			logger.L.Panicw("Cannot initialize chain source", "error", err)
+		}
+		defer release()
+
+		report, err := poller.Verify(conn, chainSource, from, to)
+		if err != nil {
+			return err
+		}
+		logger.L.Infow("Verify finished", "from", from, "to", to, "discrepancies", len(report.Discrepancies), "heights", len(report.Heights))
+		reportJSON, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		if output == "" {
+			fmt.Println(string(reportJSON))
+			return nil
+		}
+		return os.WriteFile(output, reportJSON, 0644)
+	},
+}
+
+func init() {
+	Command.PersistentFlags().Int64(CmdFrom, 0, "first height to verify")
+	Command.PersistentFlags().Int64(CmdTo, 0, "last height to verify (inclusive)")
+	Command.PersistentFlags().String(CmdOutput, "", "path of the JSON report, or stdout if empty")
+	_ = Command.MarkPersistentFlagRequired(CmdFrom)
+	_ = Command.MarkPersistentFlagRequired(CmdTo)
+	source.ConfigCmd(Command)
+}
@@ -0,0 +1,131 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"github.com/likecoin/likecoin-chain-tx-indexer/logger"
+	"github.com/likecoin/likecoin-chain-tx-indexer/utils"
+)
+
+func normalizeAuthzAddresses(granter, grantee string) (string, string) {
+	convertedGranter, err := utils.ConvertAddressPrefix(granter, MainAddressPrefix)
+	if err == nil {
+		granter = convertedGranter
+	}
+	convertedGrantee, err := utils.ConvertAddressPrefix(grantee, MainAddressPrefix)
+	if err == nil {
+		grantee = convertedGrantee
+	}
+	return granter, grantee
+}
+
+// activeAuthzGrantSQL returns the condition that a grant of the AuthzGrantRef in the parameters from $n is active at
+// the time in the parameter after them, i.e. granted and neither revoked nor expired before then. A grant revoked at the
+// time is still active, since the authorization used up by a MsgExec is revoked in the same tx.
+func activeAuthzGrantSQL(n int) string {
+	return fmt.Sprintf(`EXISTS (
+		SELECT 1 FROM authz_grant
+		WHERE granter = $%[1]d AND grantee = $%[2]d AND msg_type_url = $%[3]d AND timestamp <= $%[4]d
+			AND (revoked_at IS NULL OR revoked_at >= $%[4]d)
+			AND (expiration IS NULL OR expiration > $%[4]d)
+	)`, n, n+1, n+2, n+3)
+}
+
+// activeAuthzGrantArgs returns the parameters of activeAuthzGrantSQL
+func activeAuthzGrantArgs(g AuthzGrantRef, t time.Time) []interface{} {
+	granter, grantee := normalizeAuthzAddresses(g.Granter, g.Grantee)
+	return []interface{}{granter, grantee, g.MsgTypeUrl, t.UTC()}
+}
+
+// InsertAuthzGrant inserts the grant, and revokes the existing grant of the same granter, grantee and message type,
+// which is replaced by the new grant on chain
+func (batch *Batch) InsertAuthzGrant(g AuthzGrant) {
+	g.Granter, g.Grantee = normalizeAuthzAddresses(g.Granter, g.Grantee)
+	var expiration interface{}
+	if g.Expiration != nil {
+		expiration = g.Expiration.UTC()
+	}
+	batch.Batch.Queue(`
+	UPDATE authz_grant SET revoked_height = $5, revoked_tx_hash = $4, revoked_at = $6
+	WHERE granter = $1 AND grantee = $2 AND msg_type_url = $3 AND revoked_at IS NULL AND tx_hash != $4
+	`, g.Granter, g.Grantee, g.MsgTypeUrl, g.TxHash, g.Height, g.Timestamp.UTC())
+	batch.Batch.Queue(`
+	INSERT INTO authz_grant (
+		granter, grantee, msg_type_url, authorization_type, authorization,
+		expiration, height, tx_hash, timestamp
+	)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	ON CONFLICT (granter, grantee, msg_type_url) WHERE revoked_at IS NULL DO NOTHING
+	`,
+		g.Granter, g.Grantee, g.MsgTypeUrl, g.AuthorizationType, g.Authorization,
+		expiration, g.Height, g.TxHash, g.Timestamp.UTC(),
+	)
+}
+
+func (batch *Batch) RevokeAuthzGrant(r AuthzRevocation) {
+	r.Granter, r.Grantee = normalizeAuthzAddresses(r.Granter, r.Grantee)
+	batch.Batch.Queue(`
+	UPDATE authz_grant SET revoked_height = $4, revoked_tx_hash = $5, revoked_at = $6
+	WHERE granter = $1 AND grantee = $2 AND msg_type_url = $3 AND revoked_at IS NULL
+	`, r.Granter, r.Grantee, r.MsgTypeUrl, r.Height, r.TxHash, r.Timestamp.UTC())
+}
+
+// GetAuthzGrants returns the current and historical grants of the granter and the grantee. Grants are active if they
+// are neither revoked nor expired at the current time.
+func GetAuthzGrants(conn *pgxpool.Conn, q QueryAuthzGrantsRequest, p PageRequest) (QueryAuthzGrantsResponse, error) {
+	granterVariations := utils.ConvertAddressPrefixes(q.Granter, AddressPrefixes)
+	granteeVariations := utils.ConvertAddressPrefixes(q.Grantee, AddressPrefixes)
+	sql := fmt.Sprintf(`
+	SELECT
+		id, granter, grantee, msg_type_url, authorization_type,
+		authorization, expiration, height, tx_hash, timestamp,
+		revoked_height, revoked_tx_hash, revoked_at, active
+	FROM (
+		SELECT *, (revoked_at IS NULL AND (expiration IS NULL OR expiration > NOW() AT TIME ZONE 'UTC')) AS active
+		FROM authz_grant
+	) g
+	WHERE ($1 = 0 OR id > $1)
+		AND ($2 = 0 OR id < $2)
+		AND ($4::text[] IS NULL OR cardinality($4::text[]) = 0 OR granter = ANY($4))
+		AND ($5::text[] IS NULL OR cardinality($5::text[]) = 0 OR grantee = ANY($5))
+		AND ($6 = '' OR msg_type_url = $6)
+		AND ($7 = false OR active)
+	ORDER BY id %s
+	LIMIT $3
+	`, p.Order())
+
+	ctx, cancel := GetTimeoutContext()
+	defer cancel()
+
+	rows, err := conn.Query(
+		ctx, sql,
+		p.After(), p.Before(), p.Limit, granterVariations, granteeVariations,
+		q.MsgTypeUrl, q.ActiveOnly,
+	)
+	if err != nil {
+		logger.L.Errorw("Failed to query authz grants", "error", err)
+		return QueryAuthzGrantsResponse{}, fmt.Errorf("query authz grants error: %w", err)
+	}
+	defer rows.Close()
+
+	res := QueryAuthzGrantsResponse{
+		Grants: []AuthzGrant{},
+	}
+	for rows.Next() {
+		var g AuthzGrant
+		if err = rows.Scan(
+			&res.Pagination.NextKey, &g.Granter, &g.Grantee, &g.MsgTypeUrl, &g.AuthorizationType,
+			&g.Authorization, &g.Expiration, &g.Height, &g.TxHash, &g.Timestamp,
+			&g.RevokedHeight, &g.RevokedTxHash, &g.RevokedAt, &g.Active,
+		); err != nil {
+			logger.L.Errorw("failed to scan authz grants", "error", err, "q", q)
+			return QueryAuthzGrantsResponse{}, fmt.Errorf("query authz grants data failed: %w", err)
+		}
+		res.Grants = append(res.Grants, g)
+	}
+	res.Pagination.Count = len(res.Grants)
+	return res, nil
+}
@@ -0,0 +1,190 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"github.com/likecoin/likecoin-chain-tx-indexer/logger"
+)
+
+// placeholderTxCondition matches the txs without tx body, e.g. the empty results written by import for txs missing
+// from tx_index
+const placeholderTxCondition = `jsonb_typeof(tx -> 'tx') IS DISTINCT FROM 'object'`
+
+func (batch *Batch) InsertBlock(b Block) {
+	sql := `
+	INSERT INTO blocks (height, hash, time, proposer, num_txs, app_hash)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	ON CONFLICT (height) DO UPDATE SET
+		hash = EXCLUDED.hash,
+		time = EXCLUDED.time,
+		proposer = EXCLUDED.proposer,
+		num_txs = EXCLUDED.num_txs,
+		app_hash = EXCLUDED.app_hash
+	`
+	batch.Batch.Queue(sql, b.Height, b.Hash, b.Time.UTC(), b.Proposer, b.NumTxs, b.AppHash)
+}
+
+func scanBlock(conn *pgxpool.Conn, sql string, args ...interface{}) (Block, error) {
+	ctx, cancel := GetTimeoutContext()
+	defer cancel()
+	var b Block
+	err := conn.QueryRow(ctx, sql, args...).Scan(&b.Height, &b.Hash, &b.Time, &b.Proposer, &b.NumTxs, &b.AppHash)
+	b.Time = b.Time.UTC()
+	return b, err
+}
+
+// GetBlockByHeight returns the block at the height, or pgx.ErrNoRows if the block is not indexed
+func GetBlockByHeight(conn *pgxpool.Conn, height int64) (Block, error) {
+	sql := `
+	SELECT height, hash, time, proposer, num_txs, app_hash
+	FROM blocks
+	WHERE height = $1
+	`
+	b, err := scanBlock(conn, sql, height)
+	if err != nil {
+		if err != pgx.ErrNoRows {
+			logger.L.Errorw("Failed to query block by height", "error", err, "height", height)
+		}
+		return Block{}, fmt.Errorf("failed to query block by height: %w", err)
+	}
+	return b, nil
+}
+
+// GetBlockByTime returns the latest block at or before the time, or pgx.ErrNoRows if there is no such block
+func GetBlockByTime(conn *pgxpool.Conn, t time.Time) (Block, error) {
+	sql := `
+	SELECT height, hash, time, proposer, num_txs, app_hash
+	FROM blocks
+	WHERE time <= $1
+	ORDER BY time DESC, height DESC
+	LIMIT 1
+	`
+	b, err := scanBlock(conn, sql, t.UTC())
+	if err != nil {
+		if err != pgx.ErrNoRows {
+			logger.L.Errorw("Failed to query block by time", "error", err, "time", t)
+		}
+		return Block{}, fmt.Errorf("failed to query block by time: %w", err)
+	}
+	return b, nil
+}
+
+func GetBlocks(conn *pgxpool.Conn, q QueryBlocksRequest, p PageRequest) (QueryBlocksResponse, error) {
+	sql := fmt.Sprintf(`
+	SELECT height, hash, time, proposer, num_txs, app_hash
+	FROM blocks
+	WHERE ($1::bigint = 0 OR height > $1)
+		AND ($2::bigint = 0 OR height < $2)
+		AND ($4::bigint = 0 OR height >= $4)
+		AND ($5::bigint = 0 OR height <= $5)
+		AND ($6::timestamp IS NULL OR time >= $6)
+		AND ($7::timestamp IS NULL OR time <= $7)
+	ORDER BY height %s
+	LIMIT $3
+	`, p.Order())
+	var fromTime, toTime *time.Time
+	if q.FromTime != nil {
+		t := q.FromTime.UTC()
+		fromTime = &t
+	}
+	if q.ToTime != nil {
+		t := q.ToTime.UTC()
+		toTime = &t
+	}
+	ctx, cancel := GetTimeoutContext()
+	defer cancel()
+	rows, err := conn.Query(ctx, sql,
+		p.After(), p.Before(), p.Limit,
+		q.FromHeight, q.ToHeight, fromTime, toTime,
+	)
+	if err != nil {
+		logger.L.Errorw("Failed to query blocks", "error", err, "q", q)
+		return QueryBlocksResponse{}, fmt.Errorf("failed to query blocks: %w", err)
+	}
+	defer rows.Close()
+	res := QueryBlocksResponse{
+		Blocks: []Block{},
+	}
+	for rows.Next() {
+		var b Block
+		if err = rows.Scan(&b.Height, &b.Hash, &b.Time, &b.Proposer, &b.NumTxs, &b.AppHash); err != nil {
+			logger.L.Errorw("Failed to scan block", "error", err, "q", q)
+			return QueryBlocksResponse{}, fmt.Errorf("failed to scan block: %w", err)
+		}
+		b.Time = b.Time.UTC()
+		res.Pagination.NextKey = uint64(b.Height)
+		res.Blocks = append(res.Blocks, b)
+	}
+	res.Pagination.Count = len(res.Blocks)
+	return res, nil
+}
+
+// GetMissingHeights returns the heights from `from` to `to` (inclusive) which are not completely indexed, i.e. the
+// block header is missing, the number of txs does not match the block, or any tx is an empty placeholder (e.g. written
+// by import for txs missing from tx_index)
+func GetMissingHeights(conn *pgxpool.Conn, from, to int64) ([]int64, error) {
+	sql := `
+	SELECT h
+	FROM generate_series($1::bigint, $2::bigint) AS h
+	LEFT JOIN blocks AS b
+		ON b.height = h
+	WHERE b.height IS NULL
+		OR b.num_txs <> (SELECT count(*) FROM txs WHERE height = h)
+		OR EXISTS (
+			SELECT 1 FROM txs
+			WHERE height = h
+				AND ` + placeholderTxCondition + `
+		)
+	ORDER BY h
+	`
+	ctx, cancel := GetTimeoutContext()
+	defer cancel()
+	rows, err := conn.Query(ctx, sql, from, to)
+	if err != nil {
+		logger.L.Errorw("Failed to query missing heights", "error", err, "from", from, "to", to)
+		return nil, fmt.Errorf("failed to query missing heights: %w", err)
+	}
+	defer rows.Close()
+	heights := []int64{}
+	for rows.Next() {
+		var height int64
+		if err = rows.Scan(&height); err != nil {
+			logger.L.Errorw("Failed to scan missing height", "error", err, "from", from, "to", to)
+			return nil, fmt.Errorf("failed to scan missing height: %w", err)
+		}
+		heights = append(heights, height)
+	}
+	return heights, nil
+}
+
+// GetIndexedTxs returns the txs stored from `from` to `to` (inclusive), ordered by height and tx index
+func GetIndexedTxs(conn *pgxpool.Conn, from, to int64) ([]IndexedTx, error) {
+	sql := `
+	SELECT height, tx_index, COALESCE(tx ->> 'txhash', ''), ` + placeholderTxCondition + `
+	FROM txs
+	WHERE height >= $1 AND height <= $2
+	ORDER BY height, tx_index, id
+	`
+	ctx, cancel := GetTimeoutContext()
+	defer cancel()
+	rows, err := conn.Query(ctx, sql, from, to)
+	if err != nil {
+		logger.L.Errorw("Failed to query indexed txs", "error", err, "from", from, "to", to)
+		return nil, fmt.Errorf("failed to query indexed txs: %w", err)
+	}
+	defer rows.Close()
+	txs := []IndexedTx{}
+	for rows.Next() {
+		var tx IndexedTx
+		if err = rows.Scan(&tx.Height, &tx.TxIndex, &tx.TxHash, &tx.Placeholder); err != nil {
+			logger.L.Errorw("Failed to scan indexed tx", "error", err, "from", from, "to", to)
+			return nil, fmt.Errorf("failed to scan indexed tx: %w", err)
+		}
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
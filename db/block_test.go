@@ -0,0 +1,69 @@
+package db_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/likecoin/likecoin-chain-tx-indexer/db"
+	. "github.com/likecoin/likecoin-chain-tx-indexer/test"
+)
+
+func TestBlocks(t *testing.T) {
+	defer CleanupTestData(Conn)
+	baseTime := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	blocks := []Block{}
+	for height := int64(1); height <= 10; height++ {
+		blocks = append(blocks, Block{
+			Height:   height,
+			Hash:     "HASH",
+			Time:     baseTime.Add(time.Duration(height) * 6 * time.Second),
+			Proposer: "PROPOSER",
+			NumTxs:   int(height % 3),
+			AppHash:  "APPHASH",
+		})
+	}
+	InsertTestData(DBTestData{Blocks: blocks})
+
+	block, err := GetBlockByHeight(Conn, 5)
+	require.NoError(t, err)
+	require.Equal(t, blocks[4], block)
+
+	_, err = GetBlockByHeight(Conn, 11)
+	require.True(t, errors.Is(err, pgx.ErrNoRows))
+
+	block, err = GetBlockByTime(Conn, blocks[4].Time)
+	require.NoError(t, err)
+	require.Equal(t, int64(5), block.Height)
+
+	block, err = GetBlockByTime(Conn, blocks[4].Time.Add(5*time.Second))
+	require.NoError(t, err)
+	require.Equal(t, int64(5), block.Height)
+
+	block, err = GetBlockByTime(Conn, baseTime.Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, int64(10), block.Height)
+
+	_, err = GetBlockByTime(Conn, baseTime)
+	require.True(t, errors.Is(err, pgx.ErrNoRows))
+
+	res, err := GetBlocks(Conn, QueryBlocksRequest{FromHeight: 3, ToHeight: 7}, PageRequest{Limit: 3})
+	require.NoError(t, err)
+	require.Len(t, res.Blocks, 3)
+	require.Equal(t, int64(3), res.Blocks[0].Height)
+	require.Equal(t, uint64(5), res.Pagination.NextKey)
+
+	res, err = GetBlocks(Conn, QueryBlocksRequest{FromHeight: 3, ToHeight: 7}, PageRequest{Key: 5, Limit: 3})
+	require.NoError(t, err)
+	require.Len(t, res.Blocks, 2)
+	require.Equal(t, int64(6), res.Blocks[0].Height)
+
+	fromTime := blocks[7].Time
+	res, err = GetBlocks(Conn, QueryBlocksRequest{FromTime: &fromTime}, PageRequest{Limit: 100, Reverse: true})
+	require.NoError(t, err)
+	require.Len(t, res.Blocks, 3)
+	require.Equal(t, int64(10), res.Blocks[0].Height)
+}
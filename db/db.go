@@ -54,8 +54,10 @@ const (
 var encodingConfig = app.MakeEncodingConfig()
 
 var (
-	MainAddressPrefix = "like"
-	AddressPrefixes   = []string{MainAddressPrefix, "cosmos"}
+	MainAddressPrefix          = "like"
+	AddressPrefixes            = []string{MainAddressPrefix, "cosmos"}
+	MainValidatorAddressPrefix = MainAddressPrefix + "valoper"
+	ValidatorAddressPrefixes   = []string{MainValidatorAddressPrefix, "cosmosvaloper"}
 )
 
 func serializeTx(txRes *types.TxResponse) ([]byte, error) {
@@ -226,6 +228,8 @@ type Batch struct {
 	Batch      pgx.Batch
 	limit      int
 	prevHeight int64
+	// silent is set when replaying the txs extracted before, whose changes were already published to pubsub
+	silent bool
 }
 
 func NewBatch(conn *pgxpool.Conn, limit int) Batch {
@@ -237,11 +241,45 @@ func NewBatch(conn *pgxpool.Conn, limit int) Batch {
 	}
 }
 
+// publish publishes the change to pubsub, unless the batch is silent
+func (batch *Batch) publish(action string, payload interface{}) {
+	if batch.silent {
+		return
+	}
+	_ = pubsub.Publish(action, payload)
+}
+
 func (batch *Batch) InsertTx(txRes types.TxResponse, height int64, txIndex int) error {
+	txResJSON, err := batch.queueTx(
+		"INSERT INTO txs (height, tx_index, tx, events) VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING",
+		txRes, height, txIndex,
+	)
+	if err != nil {
+		return err
+	}
+	_ = pubsub.Publish("NewTx", json.RawMessage(txResJSON))
+	return nil
+}
+
+// UpsertTx is the same as InsertTx, but overwrites the existing tx at the same height and index,
+// e.g. the empty placeholder written by import for txs missing from tx_index
+func (batch *Batch) UpsertTx(txRes types.TxResponse, height int64, txIndex int) error {
+	_, err := batch.queueTx(`
+		INSERT INTO txs (height, tx_index, tx, events) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (height, tx_index) DO UPDATE SET
+			tx = EXCLUDED.tx,
+			events = EXCLUDED.events
+		`,
+		txRes, height, txIndex,
+	)
+	return err
+}
+
+func (batch *Batch) queueTx(sql string, txRes types.TxResponse, height int64, txIndex int) ([]byte, error) {
 	if batch.Batch.Len() >= batch.limit && batch.prevHeight > 0 && height != batch.prevHeight {
 		err := batch.Flush()
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 	eventStrings := []string{}
@@ -250,14 +288,13 @@ func (batch *Batch) InsertTx(txRes types.TxResponse, height int64, txIndex int)
 	}
 	txResJSON, err := serializeTx(&txRes)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	_ = pubsub.Publish("NewTx", json.RawMessage(txResJSON))
 	logger.L.Infow("Processing transaction", "txhash", txRes.TxHash, "height", height, "index", txIndex)
-	batch.Batch.Queue("INSERT INTO txs (height, tx_index, tx, events) VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING", height, txIndex, txResJSON, eventStrings)
+	batch.Batch.Queue(sql, height, txIndex, txResJSON, eventStrings)
 	batch.prevHeight = height
 	logger.L.Debugw("Processed height", "height", height, "batch_size", batch.Batch.Len())
-	return nil
+	return txResJSON, nil
 }
 
 func (batch *Batch) UpdateLatestBlockHeight(height int64) {
@@ -280,11 +317,16 @@ func (batch *Batch) Flush() error {
 		defer cancel()
 		result := batch.Conn.SendBatch(ctx, &batch.Batch)
 		_, err := result.Exec()
+		// the errors of the statements after the first one are only returned on closing, and the whole batch is
+		// rolled back on any of them
+		closeErr := result.Close()
+		if err == nil {
+			err = closeErr
+		}
 		if err != nil {
 			logger.L.Debugw("Error when flushing Postgres batch", "err", err, "batch_size", batch.Batch.Len())
 			return err
 		}
-		result.Close()
 		batch.Batch = pgx.Batch{}
 	}
 	return nil
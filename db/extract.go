@@ -11,7 +11,6 @@ import (
 	"github.com/jackc/pgx/v4/pgxpool"
 
 	"github.com/likecoin/likecoin-chain-tx-indexer/logger"
-	"github.com/likecoin/likecoin-chain-tx-indexer/pubsub"
 	"github.com/likecoin/likecoin-chain-tx-indexer/utils"
 )
 
@@ -26,12 +25,17 @@ type EventContext struct {
 	Messages   []json.RawMessage
 	EventsList EventsList
 	Timestamp  time.Time
+	Height     int64
 	TxHash     string
 	Memo       string
+	// AuthInfo is the raw `auth_info` of the tx, with the fee and the signers
+	AuthInfo json.RawMessage
 
 	// If the event is from authz, we process it by making a psuedo EventContext
 	// for each authz message, and then set this field to the original EventContext
 	AuthzParent *EventContext
+	// AuthzMsgIndex is the index of the MsgExec in AuthzParent
+	AuthzMsgIndex int
 }
 
 type Extractor func(ctx EventContext) error
@@ -56,68 +60,210 @@ func Extract(conn *pgxpool.Conn, extractor Extractor) (finished bool, err error)
 		finished = true
 	}
 
+	batch := NewBatch(conn, int(LIMIT))
+	err = extractTxs(conn, &batch, []NamedExtractor{{Extractor: extractor}}, "height > $1 AND height <= $2", prevSyncedHeight, latestSyncingHeight)
+	if err != nil {
+		return false, err
+	}
+	batch.UpdateMetaHeight(META_EXTRACTOR, latestSyncingHeight)
+	err = batch.Flush()
+	if err != nil {
+		return false, fmt.Errorf("send batch failed: %w", err)
+	}
+	logger.L.Infof("Extractor synced height: %d", latestSyncingHeight)
+	return finished, nil
+}
+
+// NamedExtractor is an extractor with its own checkpoint in the `meta` table, so an extractor added later can catch
+// up from its own height without reprocessing the other extractors.
+// Tables are the tables written by the extractor, for rebuilding them by Reindex. The tables written by other extractors
+// and read by the extractor are also listed, so they are rebuilt together.
+// AfterRound is optional, and runs after each round of extraction with the height reached by the extractor, e.g. to
+// expire the marketplace items by the block time. Nothing is published to pubsub if silent.
+type NamedExtractor struct {
+	Name       string
+	Extractor  Extractor
+	Tables     []string
+	AfterRound func(conn *pgxpool.Conn, height int64, silent bool) error
+}
+
+func ExtractorMetaKey(name string) string {
+	return "extractor_" + name
+}
+
+// CombineExtractors runs all the extractors on each tx in order. An error of an extractor does not stop the others.
+func CombineExtractors(extractors []NamedExtractor) Extractor {
+	return func(ctx EventContext) error {
+		var firstErr error
+		for _, e := range extractors {
+			err := e.Extractor(ctx)
+			if err != nil && firstErr == nil {
+				firstErr = withExtractorName(err, e.Name)
+			}
+		}
+		return firstErr
+	}
+}
+
+// getExtractorHeights returns the checkpoints of the extractors. Checkpoints of new extractors are created at 0.
+func getExtractorHeights(conn *pgxpool.Conn, extractors []NamedExtractor) ([]int64, error) {
 	ctx, cancel := GetTimeoutContext()
 	defer cancel()
+	heights := make([]int64, len(extractors))
+	for i, e := range extractors {
+		_, err := conn.Exec(ctx, `INSERT INTO meta (id, height) VALUES ($1, 0) ON CONFLICT DO NOTHING`, ExtractorMetaKey(e.Name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create extractor %s synchonized height: %w", e.Name, err)
+		}
+		heights[i], err = GetMetaHeight(conn, ExtractorMetaKey(e.Name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get extractor %s synchonized height: %w", e.Name, err)
+		}
+	}
+	return heights, nil
+}
 
-	sql := `
-	SELECT tx #> '{"tx", "body", "messages"}' AS messages, tx -> 'logs' AS logs, tx -> 'timestamp', tx -> 'txhash', tx -> 'tx' -> 'body' -> 'memo'
+// ExtractNamed runs one round of extraction for the extractors with the lowest checkpoint, up to LIMIT heights and not
+// beyond the next checkpoint of the others, so the lagging extractors catch up alone and then run together with the
+// others in the same pass.
+// META_EXTRACTOR is kept as the height reached by all extractors.
+func ExtractNamed(conn *pgxpool.Conn, extractors []NamedExtractor) (finished bool, err error) {
+	err = lockExtractors(conn)
+	if err != nil {
+		return false, err
+	}
+	defer unlockExtractors(conn)
+	heights, err := getExtractorHeights(conn, extractors)
+	if err != nil {
+		return false, err
+	}
+	latestHeight, err := GetLatestHeight(conn)
+	if err != nil {
+		return false, fmt.Errorf("failed to get latest height: %w", err)
+	}
+
+	prevSyncedHeight := latestHeight
+	for _, height := range heights {
+		if height < prevSyncedHeight {
+			prevSyncedHeight = height
+		}
+	}
+	if prevSyncedHeight == latestHeight {
+		return true, nil
+	}
+	latestSyncingHeight := latestHeight
+	if latestSyncingHeight > prevSyncedHeight+LIMIT {
+		latestSyncingHeight = prevSyncedHeight + LIMIT
+	}
+	lagging := []NamedExtractor{}
+	names := []string{}
+	for i, height := range heights {
+		if height == prevSyncedHeight {
+			lagging = append(lagging, extractors[i])
+			names = append(names, extractors[i].Name)
+		} else if height < latestSyncingHeight {
+			latestSyncingHeight = height
+		}
+	}
+
+	batch := NewBatch(conn, int(LIMIT))
+	err = extractTxs(conn, &batch, lagging, "height > $1 AND height <= $2", prevSyncedHeight, latestSyncingHeight)
+	if err != nil {
+		return false, err
+	}
+	for _, e := range lagging {
+		batch.UpdateMetaHeight(ExtractorMetaKey(e.Name), latestSyncingHeight)
+	}
+	batch.UpdateMetaHeight(META_EXTRACTOR, latestSyncingHeight)
+	err = batch.Flush()
+	if err != nil {
+		return false, fmt.Errorf("send batch failed: %w", err)
+	}
+	logger.L.Infow("Extractor synced height", "height", latestSyncingHeight, "extractors", names)
+	runAfterRound(conn, lagging, latestSyncingHeight, false)
+
+	// the other extractors are not behind latestSyncingHeight, so all extractors reach the latest height together
+	return latestSyncingHeight == latestHeight, nil
+}
+
+// runAfterRound runs AfterRound of the extractors which reached the height. The errors are only logged, since the round
+// is already committed.
+func runAfterRound(conn *pgxpool.Conn, extractors []NamedExtractor, height int64, silent bool) {
+	for _, e := range extractors {
+		if e.AfterRound == nil {
+			continue
+		}
+		err := e.AfterRound(conn, height, silent)
+		if err != nil {
+			logger.L.Errorw("Failed to run after extraction round", "error", err, "extractor", e.Name, "height", height)
+		}
+	}
+}
+
+// extractTxs runs the extractors on the txs matching the condition. A failure of an extractor on a tx does not stop
+// the others, and is recorded in `extraction_failures` in the same batch.
+func extractTxs(conn *pgxpool.Conn, batch *Batch, extractors []NamedExtractor, condition string, args ...interface{}) error {
+	ctx, cancel := GetTimeoutContext()
+	defer cancel()
+
+	sql := fmt.Sprintf(`
+	SELECT height, tx #> '{"tx", "body", "messages"}' AS messages, tx -> 'logs' AS logs, tx -> 'timestamp', tx -> 'txhash', tx -> 'tx' -> 'body' -> 'memo',
+		COALESCE(tx -> 'tx' -> 'auth_info', 'null'::jsonb)
 	FROM txs
-	WHERE height > $1
-		AND height <= $2
-	ORDER BY height ASC;
-	`
+	WHERE %s
+	ORDER BY height ASC, tx_index ASC;
+	`, condition)
 
-	rows, err := conn.Query(ctx, sql, prevSyncedHeight, latestSyncingHeight)
+	rows, err := conn.Query(ctx, sql, args...)
 	if err != nil {
-		return false, fmt.Errorf("failed to query unprocessed txs: %w", err)
+		return fmt.Errorf("failed to query unprocessed txs: %w", err)
 
 	}
 	defer rows.Close()
 
-	batch := NewBatch(conn, int(LIMIT))
-
 	for rows.Next() {
+		var height int64
 		var messageData pgtype.JSONB
 		var eventData pgtype.JSONB
 		var timestamp time.Time
 		var txHash string
 		var memo string
-		err := rows.Scan(&messageData, &eventData, &timestamp, &txHash, &memo)
+		var authInfo pgtype.JSONB
+		err := rows.Scan(&height, &messageData, &eventData, &timestamp, &txHash, &memo, &authInfo)
 		if err != nil {
-			return false, fmt.Errorf("failed to scan tx row on tx %s: %w", txHash, err)
+			return fmt.Errorf("failed to scan tx row on tx %s: %w", txHash, err)
 		}
 
 		var messages []json.RawMessage
 		err = messageData.AssignTo(&messages)
 		if err != nil {
-			return false, fmt.Errorf("failed to unmarshal tx message on tx %s: %w", txHash, err)
+			return fmt.Errorf("failed to unmarshal tx message on tx %s: %w", txHash, err)
 		}
 		var eventsList EventsList
 		err = eventData.AssignTo(&eventsList)
 		if err != nil {
-			return false, fmt.Errorf("failed to unmarshal tx event on tx %s: %w", txHash, err)
+			return fmt.Errorf("failed to unmarshal tx event on tx %s: %w", txHash, err)
 		}
 
 		ctx := EventContext{
-			Batch:      &batch,
+			Batch:      batch,
 			Messages:   messages,
 			EventsList: eventsList,
 			Timestamp:  timestamp,
+			Height:     height,
 			TxHash:     strings.Trim(txHash, "\""),
 			Memo:       strings.Trim(memo, "\""),
+			AuthInfo:   authInfo.Bytes,
 		}
-		err = extractor(ctx)
-		if err != nil {
-			logger.L.Errorw("Handle message failed", "error", err, "context", ctx)
+		for _, e := range extractors {
+			err = e.Extractor(ctx)
+			if err != nil {
+				logger.L.Errorw("Handle message failed", "error", err, "extractor", e.Name, "context", ctx)
+				batch.InsertExtractionFailure(ctx.TxHash, height, withExtractorName(err, e.Name))
+			}
 		}
 	}
-	batch.UpdateMetaHeight(META_EXTRACTOR, latestSyncingHeight)
-	err = batch.Flush()
-	if err != nil {
-		return false, fmt.Errorf("send batch failed: %w", err)
-	}
-	logger.L.Infof("Extractor synced height: %d", latestSyncingHeight)
-	return finished, nil
+	return nil
 }
 
 func GetMetaHeight(conn *pgxpool.Conn, key string) (int64, error) {
@@ -173,7 +319,7 @@ func (batch *Batch) InsertIscn(insert IscnInsert) {
 		;
 	`
 	batch.Batch.Queue(sql, insert.IscnPrefix, insert.Version)
-	_ = pubsub.Publish("NewISCN", insert)
+	batch.publish("NewISCN", insert)
 }
 
 func (batch *Batch) UpdateMetaHeight(key string, height int64) {
@@ -197,7 +343,8 @@ func (batch *Batch) InsertNftClass(c NftClass) {
 		c.Symbol, c.Description, c.URI, c.URIHash, c.Metadata,
 		c.Config, c.CreatedAt, c.LatestPrice, c.PriceUpdatedAt,
 	)
-	_ = pubsub.Publish("NewNFTClass", c)
+	batch.EnqueueResolveMetadata(c.URI, c.URIHash)
+	batch.publish("NewNFTClass", c)
 }
 
 func (batch *Batch) UpdateNftClass(c NftClass) {
@@ -216,7 +363,8 @@ func (batch *Batch) UpdateNftClass(c NftClass) {
 		c.Name, c.Symbol, c.Description, c.URI, c.URIHash,
 		c.Metadata, c.Config, c.Id,
 	)
-	_ = pubsub.Publish("UpdateNFTClass", c)
+	batch.EnqueueResolveMetadata(c.URI, c.URIHash)
+	batch.publish("UpdateNFTClass", c)
 }
 
 func (batch *Batch) InsertNft(n Nft) {
@@ -229,12 +377,47 @@ func (batch *Batch) InsertNft(n Nft) {
 	(nft_id, class_id, owner, uri, uri_hash, metadata)
 	VALUES
 	($1, $2, $3, $4, $5, $6)
-	ON CONFLICT DO NOTHING`
+	-- the ID of a burned NFT can be minted again
+	ON CONFLICT (class_id, nft_id) DO UPDATE
+		SET owner = EXCLUDED.owner, uri = EXCLUDED.uri, uri_hash = EXCLUDED.uri_hash,
+			metadata = EXCLUDED.metadata, burned_at = NULL
+		WHERE nft.burned_at IS NOT NULL`
 	batch.Batch.Queue(sql, n.NftId, n.ClassId, n.Owner, n.Uri, n.UriHash, n.Metadata)
-	_ = pubsub.Publish("NewNFT", n)
+	batch.EnqueueResolveMetadata(n.Uri, n.UriHash)
+	batch.publish("NewNFT", n)
+}
+
+// BurnNft marks the NFT as burned, keeping the last owner
+func (batch *Batch) BurnNft(classId, nftId string, timestamp time.Time) {
+	batch.Batch.Queue(`UPDATE nft SET burned_at = $3 WHERE class_id = $1 AND nft_id = $2`, classId, nftId, timestamp.UTC())
 }
 
 func (batch *Batch) InsertNftEvent(e NftEvent) {
+	batch.insertNftEvent(e, nil)
+}
+
+// InsertNftSale inserts the NFT send of a sale with its price and incomes, where the token send of the buyer is executed
+// by an API address through authz. The price and incomes are only recorded if the grant of the buyer to the API address
+// is active at the time of the sale.
+func (batch *Batch) InsertNftSale(e NftEvent, incomes []NftIncome, grant AuthzGrantRef) {
+	batch.insertNftEvent(e, &grant)
+	for _, income := range incomes {
+		batch.Batch.Queue(fmt.Sprintf(`
+		INSERT INTO nft_income (class_id, nft_id, tx_hash, address, amount, is_royalty)
+		SELECT $1, $2, $3, $4, $5::bigint, $6::boolean
+		WHERE %s
+		`, activeAuthzGrantSQL(7)),
+			append([]interface{}{
+				income.ClassId, income.NftId, income.TxHash, income.Address, income.Amount, income.IsRoyalty,
+			}, activeAuthzGrantArgs(grant, e.Timestamp)...)...,
+		)
+		batch.publish("NewNFTIncome", income)
+	}
+}
+
+// insertNftEvent inserts the event, with the price only recorded if the grant is active at the time of the event
+// unless grant is nil
+func (batch *Batch) insertNftEvent(e NftEvent, grant *AuthzGrantRef) {
 	convertedSender, err := utils.ConvertAddressPrefix(e.Sender, MainAddressPrefix)
 	if err == nil {
 		e.Sender = convertedSender
@@ -243,14 +426,30 @@ func (batch *Batch) InsertNftEvent(e NftEvent) {
 	if err == nil {
 		e.Receiver = convertedReceiver
 	}
-	sql := `
+	convertedExecutor, err := utils.ConvertAddressPrefix(e.Executor, MainAddressPrefix)
+	if err == nil {
+		e.Executor = convertedExecutor
+	}
+	convertedGranter, err := utils.ConvertAddressPrefix(e.Granter, MainAddressPrefix)
+	if err == nil {
+		e.Granter = convertedGranter
+	}
+	priceSQL := "$9"
+	grantCondition := func(n int) string { return "TRUE" }
+	grantArgs := []interface{}{}
+	if grant != nil {
+		priceSQL = fmt.Sprintf("CASE WHEN %s THEN $9::bigint ELSE 0 END", activeAuthzGrantSQL(13))
+		grantCondition = activeAuthzGrantSQL
+		grantArgs = activeAuthzGrantArgs(*grant, e.Timestamp)
+	}
+	sql := fmt.Sprintf(`
 	INSERT INTO nft_event (
 		action, class_id, nft_id, sender, receiver,
 		events, tx_hash, timestamp, price, memo,
-		iscn_owner_at_the_time
+		executor, granter, iscn_owner_at_the_time
 	)
 	VALUES (
-		$1, $2, $3, $4, $5, $6, $7, $8, $9, $10,
+		$1, $2, $3, $4, $5, $6, $7, $8, %s, $10, $11, $12,
 		COALESCE(
 			(SELECT i.owner
 			FROM nft_class AS c
@@ -263,44 +462,48 @@ func (batch *Batch) InsertNftEvent(e NftEvent) {
 			LIMIT 1)
 		, '')
 	)
-	ON CONFLICT DO NOTHING`
-	batch.Batch.Queue(sql,
+	ON CONFLICT DO NOTHING`, priceSQL)
+	batch.Batch.Queue(sql, append([]interface{}{
 		e.Action, e.ClassId, e.NftId, e.Sender, e.Receiver,
 		utils.GetEventStrings(e.Events), e.TxHash, e.Timestamp, e.Price, e.Memo,
-	)
+		e.Executor, e.Granter,
+	}, grantArgs...)...)
 
 	if e.Price > 0 {
-		nftSql := `
+		nftSql := fmt.Sprintf(`
 			UPDATE nft
 			SET latest_price = $1,
 				price_updated_at = $2
 			WHERE
 				class_id = $3
 				AND nft_id = $4
-		`
-		batch.Batch.Queue(nftSql, e.Price, e.Timestamp, e.ClassId, e.NftId)
-		nftClassSql := `
+				AND %s
+		`, grantCondition(5))
+		batch.Batch.Queue(nftSql, append([]interface{}{e.Price, e.Timestamp, e.ClassId, e.NftId}, grantArgs...)...)
+		nftClassSql := fmt.Sprintf(`
 			UPDATE nft_class
 			SET latest_price = $1,
 				price_updated_at = $2
 			WHERE
 				class_id = $3
-		`
-		batch.Batch.Queue(nftClassSql, e.Price, e.Timestamp, e.ClassId)
+				AND %s
+		`, grantCondition(4))
+		batch.Batch.Queue(nftClassSql, append([]interface{}{e.Price, e.Timestamp, e.ClassId}, grantArgs...)...)
 	}
-	_ = pubsub.Publish("NewNFTEvent", e)
+	batch.publish("NewNFTEvent", e)
 }
 
 func (batch *Batch) InsertNFTMarketplaceItem(item NftMarketplaceItem) {
 	sql := `
-	INSERT INTO nft_marketplace (type, class_id, nft_id, creator, price, expiration)
-	VALUES ($1, $2, $3, $4, $5, $6)
+	INSERT INTO nft_marketplace (type, class_id, nft_id, creator, price, expiration, status)
+	VALUES ($1, $2, $3, $4, $5, $6, 'active')
 	ON CONFLICT (type, class_id, nft_id, creator) DO UPDATE SET
 		price = EXCLUDED.price,
-		expiration = EXCLUDED.expiration
+		expiration = EXCLUDED.expiration,
+		status = EXCLUDED.status
 	`
 	batch.Batch.Queue(sql, item.Type, item.ClassId, item.NftId, item.Creator, item.Price, item.Expiration)
-	_ = pubsub.Publish("NewNFTMarketplaceItem", item)
+	batch.publish("NewNFTMarketplaceItem", item)
 }
 
 func (batch *Batch) InsertNftIncome(income NftIncome) {
@@ -309,21 +512,59 @@ func (batch *Batch) InsertNftIncome(income NftIncome) {
 	VALUES ($1, $2, $3, $4, $5, $6)
 	`
 	batch.Batch.Queue(sql, income.ClassId, income.NftId, income.TxHash, income.Address, income.Amount, income.IsRoyalty)
-	_ = pubsub.Publish("NewNFTIncome", income)
+	batch.publish("NewNFTIncome", income)
 }
 
-func (batch *Batch) DeleteNFTMarketplaceItemSilently(item NftMarketplaceItem) {
+// CancelStaleNFTMarketplaceItems cancels the active items of the NFT from the creators other than the item, e.g. the
+// stale listings of the previous owners, and records the cancellations as deleted in the history at the tx
+func (batch *Batch) CancelStaleNFTMarketplaceItems(item NftMarketplaceItem, height int64, txHash string, timestamp time.Time) {
 	sql := `
-	DELETE FROM nft_marketplace
+	WITH cancelled AS (
+		UPDATE nft_marketplace
+		SET status = 'cancelled'
+		WHERE
+			type = $1 AND
+			class_id = $2 AND
+			nft_id = $3 AND
+			creator != $4 AND
+			status = 'active'
+		RETURNING type, class_id, nft_id, creator, price, expiration
+	)
+	INSERT INTO nft_marketplace_history (action, type, class_id, nft_id, creator, price, expiration, height, tx_hash, timestamp)
+	SELECT $5, type, class_id, nft_id, creator, price, expiration, $6, $7, $8
+	FROM cancelled
+	ON CONFLICT DO NOTHING
+	`
+	batch.Batch.Queue(sql, item.Type, item.ClassId, item.NftId, item.Creator, MARKETPLACE_ACTION_DELETE, height, txHash, timestamp.UTC())
+}
+
+// DeleteNFTMarketplaceItem marks the item cancelled, unless it is already filled
+func (batch *Batch) DeleteNFTMarketplaceItem(item NftMarketplaceItem) {
+	sql := `
+	UPDATE nft_marketplace
+	SET status = 'cancelled'
 	WHERE
 		type = $1 AND
 		class_id = $2 AND
-		nft_id = $3
+		nft_id = $3 AND
+		creator = $4 AND
+		status IN ('active', 'expired')
 	`
-	batch.Batch.Queue(sql, item.Type, item.ClassId, item.NftId)
+	batch.Batch.Queue(sql, item.Type, item.ClassId, item.NftId, item.Creator)
+	batch.publish("DeleteNFTMarketplaceItem", item)
 }
 
-func (batch *Batch) DeleteNFTMarketplaceItem(item NftMarketplaceItem) {
-	batch.DeleteNFTMarketplaceItemSilently(item)
-	_ = pubsub.Publish("DeleteNFTMarketplaceItem", item)
+// FillNFTMarketplaceItem marks the item filled by a deal, where the creator is the seller of a listing or the buyer of
+// an offer
+func (batch *Batch) FillNFTMarketplaceItem(item NftMarketplaceItem) {
+	sql := `
+	UPDATE nft_marketplace
+	SET status = 'filled'
+	WHERE
+		type = $1 AND
+		class_id = $2 AND
+		nft_id = $3 AND
+		creator = $4
+	`
+	batch.Batch.Queue(sql, item.Type, item.ClassId, item.NftId, item.Creator)
 }
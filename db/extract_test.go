@@ -0,0 +1,136 @@
+package db_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	. "github.com/likecoin/likecoin-chain-tx-indexer/db"
+	. "github.com/likecoin/likecoin-chain-tx-indexer/test"
+)
+
+func TestExtractNamed(t *testing.T) {
+	defer CleanupTestData(Conn)
+	limit := LIMIT
+	LIMIT = 2
+	defer func() { LIMIT = limit }()
+
+	txs := []string{}
+	for height := 1; height <= 6; height++ {
+		txs = append(txs, fmt.Sprintf(
+			`{"height":"%[1]d","txhash":"TX%[1]d","tx":{"body":{"messages":[],"memo":"memo-%[1]d"}},"logs":[],"timestamp":"2022-01-01T00:00:00Z"}`,
+			height,
+		))
+	}
+	InsertTestData(DBTestData{Txs: txs})
+
+	memos := map[string][]string{}
+	order := []string{}
+	newExtractor := func(name string) NamedExtractor {
+		return NamedExtractor{
+			Name: name,
+			Extractor: func(ctx EventContext) error {
+				memos[name] = append(memos[name], ctx.Memo)
+				order = append(order, name+":"+ctx.Memo)
+				return nil
+			},
+		}
+	}
+	extractors := []NamedExtractor{newExtractor("test_a"), newExtractor("test_b")}
+	// test_a has extracted up to height 4, while test_b is newly added
+	_, err := Conn.Exec(context.Background(), `INSERT INTO meta (id, height) VALUES ($1, 4)`, ExtractorMetaKey("test_a"))
+	require.NoError(t, err)
+
+	// test_b catches up alone
+	for i := 0; i < 2; i++ {
+		finished, err := ExtractNamed(Conn, extractors)
+		require.NoError(t, err)
+		require.False(t, finished)
+	}
+	require.Empty(t, memos["test_a"])
+	require.Equal(t, []string{"memo-1", "memo-2", "memo-3", "memo-4"}, memos["test_b"])
+	height, err := GetMetaHeight(Conn, ExtractorMetaKey("test_b"))
+	require.NoError(t, err)
+	require.Equal(t, int64(4), height)
+
+	// then both run together, in the order of the extractors on each tx
+	order = []string{}
+	finished, err := ExtractNamed(Conn, extractors)
+	require.NoError(t, err)
+	require.True(t, finished)
+	require.Equal(t, []string{"test_a:memo-5", "test_b:memo-5", "test_a:memo-6", "test_b:memo-6"}, order)
+	require.Equal(t, []string{"memo-5", "memo-6"}, memos["test_a"])
+	require.Equal(t, []string{"memo-1", "memo-2", "memo-3", "memo-4", "memo-5", "memo-6"}, memos["test_b"])
+	for _, key := range []string{ExtractorMetaKey("test_a"), ExtractorMetaKey("test_b"), META_EXTRACTOR} {
+		height, err = GetMetaHeight(Conn, key)
+		require.NoError(t, err)
+		require.Equal(t, int64(6), height)
+	}
+
+	finished, err = ExtractNamed(Conn, extractors)
+	require.NoError(t, err)
+	require.True(t, finished)
+	require.Len(t, memos["test_b"], 6)
+}
+
+func TestReextract(t *testing.T) {
+	defer CleanupTestData(Conn)
+
+	txs := []string{}
+	for height := 1; height <= 4; height++ {
+		txs = append(txs, fmt.Sprintf(
+			`{"height":"%[1]d","txhash":"TX%[1]d","tx":{"body":{"messages":[],"memo":"memo-%[1]d"}},"logs":[],"timestamp":"2022-01-01T00:00:00Z"}`,
+			height,
+		))
+	}
+	InsertTestData(DBTestData{
+		Txs:              txs,
+		ExtractorHeights: map[string]int64{"test_reextract": 3, "test_reextract_behind": 1},
+	})
+
+	replayed := []string{}
+	extractors := []NamedExtractor{{
+		Name: "test_reextract",
+		Extractor: func(ctx EventContext) error {
+			replayed = append(replayed, ctx.TxHash)
+			ctx.Batch.Batch.Queue(
+				`INSERT INTO nft_income (class_id, nft_id, tx_hash, address, amount) VALUES ('class', 'nft', $1, 'address', 1)`,
+				ctx.TxHash,
+			)
+			return nil
+		},
+		Tables: []string{"nft_income"},
+	}, {
+		Name: "test_reextract_behind",
+		Extractor: func(ctx EventContext) error {
+			replayed = append(replayed, "behind-"+ctx.TxHash)
+			return nil
+		},
+	}}
+	_, err := Conn.Exec(context.Background(), `
+		INSERT INTO nft_income (class_id, nft_id, tx_hash, address, amount)
+		VALUES ('class', 'nft', 'TX1', 'address', 1), ('class', 'nft', 'TX2', 'address', 1), ('class', 'nft', 'TX3', 'address', 1)
+	`)
+	require.NoError(t, err)
+
+	// the heights after 1 are replayed in order up to the checkpoint without duplicating the rows, and the extractor
+	// which has not passed the height is left to the running extractor
+	err = Reextract(Conn, extractors, 1)
+	require.NoError(t, err)
+	require.Equal(t, []string{"TX2", "TX3"}, replayed)
+	var count int
+	err = Conn.QueryRow(context.Background(), `SELECT count(*) FROM nft_income`).Scan(&count)
+	require.NoError(t, err)
+	require.Equal(t, 3, count)
+	height, err := GetMetaHeight(Conn, ExtractorMetaKey("test_reextract"))
+	require.NoError(t, err)
+	require.Equal(t, int64(3), height)
+	height, err = GetMetaHeight(Conn, ExtractorMetaKey("test_reextract_behind"))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), height)
+	height, err = GetMetaHeight(Conn, META_EXTRACTOR)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), height)
+}
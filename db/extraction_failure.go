@@ -0,0 +1,236 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"github.com/likecoin/likecoin-chain-tx-indexer/logger"
+)
+
+// ExtractionError is an error of an extractor on a tx, with the location of the failure recorded in
+// `extraction_failures`
+type ExtractionError struct {
+	Extractor string
+	// MessageIndex is -1 if the failure is not on a message
+	MessageIndex int
+	EventType    string
+	Processor    string
+	Err          error
+}
+
+func (e *ExtractionError) Error() string {
+	return fmt.Sprintf(
+		"extractor %s failed on message %d, event %s, processor %s: %s",
+		e.Extractor, e.MessageIndex, e.EventType, e.Processor, e.Err,
+	)
+}
+
+func (e *ExtractionError) Unwrap() error {
+	return e.Err
+}
+
+// withExtractorName converts err to ExtractionError of the extractor, if it is not yet attributed to an extractor
+func withExtractorName(err error, name string) *ExtractionError {
+	var extractionErr *ExtractionError
+	if errors.As(err, &extractionErr) {
+		if extractionErr.Extractor == "" {
+			e := *extractionErr
+			e.Extractor = name
+			return &e
+		}
+		return extractionErr
+	}
+	return &ExtractionError{
+		Extractor:    name,
+		MessageIndex: -1,
+		Err:          err,
+	}
+}
+
+// InsertExtractionFailure records the failure of an extractor on a tx. If the tx has failed on the same extractor
+// before, the failure is updated and counted as another attempt.
+func (batch *Batch) InsertExtractionFailure(txHash string, height int64, e *ExtractionError) {
+	var messageIndex *int
+	if e.MessageIndex >= 0 {
+		messageIndex = &e.MessageIndex
+	}
+	batch.Batch.Queue(`
+	INSERT INTO extraction_failures AS f (tx_hash, height, extractor, message_index, event_type, processor, error)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	ON CONFLICT (tx_hash, extractor) DO UPDATE SET
+		height = EXCLUDED.height,
+		message_index = EXCLUDED.message_index,
+		event_type = EXCLUDED.event_type,
+		processor = EXCLUDED.processor,
+		error = EXCLUDED.error,
+		attempts = f.attempts + 1,
+		updated_at = NOW(),
+		resolved_at = NULL
+	`, txHash, height, e.Extractor, messageIndex, e.EventType, e.Processor, e.Err.Error())
+}
+
+// CountExtractionFailures returns the number of unresolved extraction failures
+func CountExtractionFailures(conn *pgxpool.Conn) (int64, error) {
+	ctx, cancel := GetTimeoutContext()
+	defer cancel()
+	var count int64
+	err := conn.QueryRow(ctx, `SELECT count(*) FROM extraction_failures WHERE resolved_at IS NULL`).Scan(&count)
+	if err != nil {
+		logger.L.Errorw("Failed to count extraction failures", "error", err)
+		return 0, fmt.Errorf("failed to count extraction failures: %w", err)
+	}
+	return count, nil
+}
+
+// GetExtractionFailures returns the unresolved extraction failures in the order of height
+func GetExtractionFailures(conn *pgxpool.Conn, limit int) ([]ExtractionFailure, error) {
+	return getExtractionFailuresAfter(conn, 0, 0, limit)
+}
+
+// getExtractionFailuresAfter returns the unresolved extraction failures after the (height, id) cursor
+func getExtractionFailuresAfter(conn *pgxpool.Conn, height int64, id int64, limit int) ([]ExtractionFailure, error) {
+	ctx, cancel := GetTimeoutContext()
+	defer cancel()
+	rows, err := conn.Query(ctx, `
+	SELECT id, tx_hash, height, extractor, message_index, event_type, processor, error, attempts, created_at, updated_at, resolved_at
+	FROM extraction_failures
+	WHERE resolved_at IS NULL AND (height, id) > ($1, $2)
+	ORDER BY height ASC, id ASC
+	LIMIT $3
+	`, height, id, limit)
+	if err != nil {
+		logger.L.Errorw("Failed to query extraction failures", "error", err)
+		return nil, fmt.Errorf("failed to query extraction failures: %w", err)
+	}
+	defer rows.Close()
+	failures := []ExtractionFailure{}
+	for rows.Next() {
+		var f ExtractionFailure
+		err = rows.Scan(
+			&f.Id, &f.TxHash, &f.Height, &f.Extractor, &f.MessageIndex, &f.EventType, &f.Processor, &f.Error,
+			&f.Attempts, &f.CreatedAt, &f.UpdatedAt, &f.ResolvedAt,
+		)
+		if err != nil {
+			logger.L.Errorw("Failed to scan extraction failure", "error", err)
+			return nil, fmt.Errorf("failed to scan extraction failure: %w", err)
+		}
+		failures = append(failures, f)
+	}
+	return failures, rows.Err()
+}
+
+// resolveExtractionFailuresBefore resolves the failures of the extractors last updated before the time, i.e. the
+// failures not reproduced by replaying the extractors since then
+func resolveExtractionFailuresBefore(conn *pgxpool.Conn, extractors []NamedExtractor, t time.Time) error {
+	names := []string{}
+	for _, e := range extractors {
+		names = append(names, e.Name)
+	}
+	_, err := conn.Exec(context.Background(), `
+		UPDATE extraction_failures SET resolved_at = NOW()
+		WHERE extractor = ANY($1) AND updated_at < $2 AND resolved_at IS NULL
+	`, names, t)
+	if err != nil {
+		return fmt.Errorf("failed to resolve extraction failures: %w", err)
+	}
+	return nil
+}
+
+// RetryExtractionFailures runs the extractors again on the txs of the unresolved failures, e.g. after the extractor is
+// fixed. A failure is resolved if the extractor succeeds, or counted as another attempt otherwise.
+// The extractors are rewound to the lowest height of the failures like Rewind, with the extractors sharing tables with
+// them, and replayed forward up to their checkpoints, so the txs are extracted again in order on top of the state left
+// by the txs before, without publishing to pubsub.
+// Failures of unknown extractors, or at heights after the checkpoint of the extractor, are skipped.
+func RetryExtractionFailures(conn *pgxpool.Conn, extractors []NamedExtractor) (RetryExtractionFailuresResponse, error) {
+	res := RetryExtractionFailuresResponse{}
+	err := lockExtractors(conn)
+	if err != nil {
+		return res, err
+	}
+	defer unlockExtractors(conn)
+
+	heights, err := getExtractorHeights(conn, extractors)
+	if err != nil {
+		return res, err
+	}
+	extractorIndexes := map[string]int{}
+	for i, e := range extractors {
+		extractorIndexes[e.Name] = i
+	}
+	retriedIds := []int64{}
+	failing := map[int]bool{}
+	fromHeight := int64(-1)
+	var cursorHeight, cursorId int64
+	for {
+		failures, err := getExtractionFailuresAfter(conn, cursorHeight, cursorId, int(LIMIT))
+		if err != nil {
+			return res, err
+		}
+		if len(failures) == 0 {
+			break
+		}
+		cursorHeight = failures[len(failures)-1].Height
+		cursorId = failures[len(failures)-1].Id
+		for _, f := range failures {
+			i, ok := extractorIndexes[f.Extractor]
+			if !ok || f.Height > heights[i] {
+				res.Skipped++
+				continue
+			}
+			failing[i] = true
+			if fromHeight < 0 || f.Height-1 < fromHeight {
+				fromHeight = f.Height - 1
+			}
+			retriedIds = append(retriedIds, f.Id)
+		}
+	}
+	if len(retriedIds) == 0 {
+		return res, nil
+	}
+
+	tables := []string{}
+	for i := range failing {
+		tables = append(tables, extractors[i].Tables...)
+	}
+	sharing, _, err := ExtractorsOfTables(extractors, tables)
+	if err != nil {
+		return res, err
+	}
+	rewound := []NamedExtractor{}
+	for i, e := range extractors {
+		if failing[i] || containsExtractor(sharing, e.Name) {
+			rewound = append(rewound, e)
+		}
+	}
+	// the failures are resolved by the rewinding, and marked unresolved again if the extractor fails again
+	err = rewindAndReplay(conn, extractors, rewound, fromHeight)
+	if err != nil {
+		return res, err
+	}
+	var failed int
+	err = conn.QueryRow(context.Background(), `
+		SELECT count(*) FROM extraction_failures WHERE id = ANY($1) AND resolved_at IS NULL
+	`, retriedIds).Scan(&failed)
+	if err != nil {
+		return res, fmt.Errorf("failed to count retried extraction failures: %w", err)
+	}
+	res.Retried = len(retriedIds)
+	res.Failed = failed
+	res.Resolved = len(retriedIds) - failed
+	logger.L.Infow("Retried extraction failures", "retried", len(retriedIds), "failed", failed, "from_height", fromHeight)
+	return res, nil
+}
+
+func containsExtractor(extractors []NamedExtractor, name string) bool {
+	for _, e := range extractors {
+		if e.Name == name {
+			return true
+		}
+	}
+	return false
+}
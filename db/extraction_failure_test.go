@@ -0,0 +1,96 @@
+package db_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	. "github.com/likecoin/likecoin-chain-tx-indexer/db"
+	. "github.com/likecoin/likecoin-chain-tx-indexer/test"
+)
+
+func TestExtractionFailures(t *testing.T) {
+	defer CleanupTestData(Conn)
+	txs := []string{}
+	for height := 1; height <= 3; height++ {
+		txs = append(txs, fmt.Sprintf(
+			`{"height":"%[1]d","txhash":"TX%[1]d","tx":{"body":{"messages":[],"memo":"memo-%[1]d"}},"logs":[],"timestamp":"2022-01-01T00:00:00Z"}`,
+			height,
+		))
+	}
+	InsertTestData(DBTestData{Txs: txs, LatestBlockHeight: 3})
+
+	broken := true
+	memos := []string{}
+	extractors := []NamedExtractor{{
+		Name: "test_failure",
+		Extractor: func(ctx EventContext) error {
+			// written before the failure
+			ctx.Batch.Batch.Queue(
+				`INSERT INTO nft_income (class_id, nft_id, tx_hash, address, amount) VALUES ('class', 'nft', $1, 'address', 1)`,
+				ctx.TxHash,
+			)
+			if broken && ctx.Memo == "memo-2" {
+				return fmt.Errorf("broken")
+			}
+			memos = append(memos, ctx.Memo)
+			return nil
+		},
+		Tables: []string{"nft_income"},
+	}}
+
+	// the failure does not stop the extractor
+	finished, err := ExtractNamed(Conn, extractors)
+	require.NoError(t, err)
+	require.True(t, finished)
+	require.Equal(t, []string{"memo-1", "memo-3"}, memos)
+	count, err := CountExtractionFailures(Conn)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), count)
+	failures, err := GetExtractionFailures(Conn, 10)
+	require.NoError(t, err)
+	require.Len(t, failures, 1)
+	require.Equal(t, "TX2", failures[0].TxHash)
+	require.Equal(t, int64(2), failures[0].Height)
+	require.Equal(t, "test_failure", failures[0].Extractor)
+	require.Nil(t, failures[0].MessageIndex)
+	require.Contains(t, failures[0].Error, "broken")
+	require.Equal(t, 1, failures[0].Attempts)
+
+	res, err := RetryExtractionFailures(Conn, extractors)
+	require.NoError(t, err)
+	require.Equal(t, RetryExtractionFailuresResponse{Retried: 1, Failed: 1}, res)
+	// the txs after the failure are replayed in order
+	require.Equal(t, []string{"memo-1", "memo-3", "memo-3"}, memos)
+	failures, err = GetExtractionFailures(Conn, 10)
+	require.NoError(t, err)
+	require.Len(t, failures, 1)
+	require.Equal(t, 2, failures[0].Attempts)
+
+	// failures of unknown extractors are left untouched
+	res, err = RetryExtractionFailures(Conn, []NamedExtractor{{Name: "other", Extractor: extractors[0].Extractor}})
+	require.NoError(t, err)
+	require.Equal(t, RetryExtractionFailuresResponse{Skipped: 1}, res)
+
+	broken = false
+	res, err = RetryExtractionFailures(Conn, extractors)
+	require.NoError(t, err)
+	require.Equal(t, RetryExtractionFailuresResponse{Retried: 1, Resolved: 1}, res)
+	require.Equal(t, []string{"memo-1", "memo-3", "memo-3", "memo-2", "memo-3"}, memos)
+	count, err = CountExtractionFailures(Conn)
+	require.NoError(t, err)
+	require.Zero(t, count)
+	// the rows written by the failed attempts are not duplicated
+	var incomeCount int
+	err = Conn.QueryRow(context.Background(), `SELECT count(*) FROM nft_income WHERE tx_hash = 'TX2'`).Scan(&incomeCount)
+	require.NoError(t, err)
+	require.Equal(t, 1, incomeCount)
+	err = Conn.QueryRow(context.Background(), `SELECT count(*) FROM nft_income WHERE tx_hash = 'TX3'`).Scan(&incomeCount)
+	require.NoError(t, err)
+	require.Equal(t, 1, incomeCount)
+	height, err := GetMetaHeight(Conn, ExtractorMetaKey("test_failure"))
+	require.NoError(t, err)
+	require.Equal(t, int64(3), height)
+}
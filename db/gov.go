@@ -0,0 +1,280 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"github.com/likecoin/likecoin-chain-tx-indexer/logger"
+	"github.com/likecoin/likecoin-chain-tx-indexer/utils"
+)
+
+func (batch *Batch) InsertGovProposal(p GovProposal) {
+	convertedProposer, err := utils.ConvertAddressPrefix(p.Proposer, MainAddressPrefix)
+	if err == nil {
+		p.Proposer = convertedProposer
+	}
+	var content interface{}
+	if len(p.Content) > 0 {
+		content = p.Content
+	}
+	batch.Batch.Queue(`
+	INSERT INTO gov_proposal (
+		proposal_id, height, tx_hash, proposer, proposal_type,
+		title, description, content, timestamp
+	)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	ON CONFLICT (proposal_id) DO NOTHING
+	`,
+		p.ProposalId, p.Height, p.TxHash, p.Proposer, p.ProposalType,
+		p.Title, p.Description, content, p.Timestamp.UTC(),
+	)
+}
+
+func (batch *Batch) InsertGovDeposit(d GovDeposit) {
+	convertedDepositor, err := utils.ConvertAddressPrefix(d.Depositor, MainAddressPrefix)
+	if err == nil {
+		d.Depositor = convertedDepositor
+	}
+	batch.Batch.Queue(`
+	INSERT INTO gov_deposit (proposal_id, height, tx_hash, message_index, depositor, denom, amount, timestamp)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, d.ProposalId, d.Height, d.TxHash, d.MessageIndex, d.Depositor, d.Denom, d.Amount, d.Timestamp.UTC())
+}
+
+func (batch *Batch) InsertGovVote(v GovVote) {
+	convertedVoter, err := utils.ConvertAddressPrefix(v.Voter, MainAddressPrefix)
+	if err == nil {
+		v.Voter = convertedVoter
+	}
+	batch.Batch.Queue(`
+	INSERT INTO gov_vote (proposal_id, height, tx_hash, message_index, voter, option, weight, timestamp)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, v.ProposalId, v.Height, v.TxHash, v.MessageIndex, v.Voter, v.Option, v.Weight, v.Timestamp.UTC())
+}
+
+func GetGovProposals(conn *pgxpool.Conn, q QueryGovProposalsRequest, p PageRequest) (QueryGovProposalsResponse, error) {
+	proposerVariations := utils.ConvertAddressPrefixes(q.Proposer, AddressPrefixes)
+	sql := fmt.Sprintf(`
+	SELECT
+		id, proposal_id, height, tx_hash, proposer, proposal_type,
+		title, description, content, timestamp
+	FROM gov_proposal
+	WHERE ($1 = 0 OR id > $1)
+		AND ($2 = 0 OR id < $2)
+		AND ($4::text[] IS NULL OR cardinality($4::text[]) = 0 OR proposer = ANY($4))
+	ORDER BY id %s
+	LIMIT $3
+	`, p.Order())
+
+	ctx, cancel := GetTimeoutContext()
+	defer cancel()
+
+	rows, err := conn.Query(ctx, sql, p.After(), p.Before(), p.Limit, proposerVariations)
+	if err != nil {
+		logger.L.Errorw("Failed to query gov proposals", "error", err)
+		return QueryGovProposalsResponse{}, fmt.Errorf("query gov proposals error: %w", err)
+	}
+	defer rows.Close()
+
+	res := QueryGovProposalsResponse{
+		Proposals: []GovProposal{},
+	}
+	for rows.Next() {
+		var proposal GovProposal
+		if err = rows.Scan(
+			&res.Pagination.NextKey, &proposal.ProposalId, &proposal.Height, &proposal.TxHash, &proposal.Proposer,
+			&proposal.ProposalType, &proposal.Title, &proposal.Description, &proposal.Content, &proposal.Timestamp,
+		); err != nil {
+			logger.L.Errorw("failed to scan gov proposals", "error", err, "q", q)
+			return QueryGovProposalsResponse{}, fmt.Errorf("query gov proposals data failed: %w", err)
+		}
+		res.Proposals = append(res.Proposals, proposal)
+	}
+	res.Pagination.Count = len(res.Proposals)
+	return res, nil
+}
+
+func GetGovDeposits(conn *pgxpool.Conn, proposalId uint64, p PageRequest) (QueryGovDepositsResponse, error) {
+	sql := fmt.Sprintf(`
+	SELECT id, proposal_id, height, tx_hash, message_index, depositor, denom, amount::text, timestamp
+	FROM gov_deposit
+	WHERE ($1 = 0 OR id > $1)
+		AND ($2 = 0 OR id < $2)
+		AND proposal_id = $4
+	ORDER BY id %s
+	LIMIT $3
+	`, p.Order())
+
+	ctx, cancel := GetTimeoutContext()
+	defer cancel()
+
+	rows, err := conn.Query(ctx, sql, p.After(), p.Before(), p.Limit, proposalId)
+	if err != nil {
+		logger.L.Errorw("Failed to query gov deposits", "error", err)
+		return QueryGovDepositsResponse{}, fmt.Errorf("query gov deposits error: %w", err)
+	}
+	defer rows.Close()
+
+	res := QueryGovDepositsResponse{
+		Deposits: []GovDeposit{},
+	}
+	for rows.Next() {
+		var d GovDeposit
+		if err = rows.Scan(
+			&res.Pagination.NextKey, &d.ProposalId, &d.Height, &d.TxHash, &d.MessageIndex,
+			&d.Depositor, &d.Denom, &d.Amount, &d.Timestamp,
+		); err != nil {
+			logger.L.Errorw("failed to scan gov deposits", "error", err, "proposal_id", proposalId)
+			return QueryGovDepositsResponse{}, fmt.Errorf("query gov deposits data failed: %w", err)
+		}
+		res.Deposits = append(res.Deposits, d)
+	}
+	res.Pagination.Count = len(res.Deposits)
+	return res, nil
+}
+
+// GetGovVotes returns the votes in the order of voting, including the votes changed later by the voter
+func GetGovVotes(conn *pgxpool.Conn, q QueryGovVotesRequest, p PageRequest) (QueryGovVotesResponse, error) {
+	voterVariations := utils.ConvertAddressPrefixes(q.Voter, AddressPrefixes)
+	sql := fmt.Sprintf(`
+	SELECT id, proposal_id, height, tx_hash, message_index, voter, option, weight::text, timestamp
+	FROM gov_vote
+	WHERE ($1 = 0 OR id > $1)
+		AND ($2 = 0 OR id < $2)
+		AND ($4 = 0 OR proposal_id = $4)
+		AND ($5::text[] IS NULL OR cardinality($5::text[]) = 0 OR voter = ANY($5))
+		AND ($6::text[] IS NULL OR cardinality($6::text[]) = 0 OR option = ANY($6))
+	ORDER BY id %s
+	LIMIT $3
+	`, p.Order())
+
+	ctx, cancel := GetTimeoutContext()
+	defer cancel()
+
+	rows, err := conn.Query(ctx, sql, p.After(), p.Before(), p.Limit, q.ProposalId, voterVariations, q.Option)
+	if err != nil {
+		logger.L.Errorw("Failed to query gov votes", "error", err)
+		return QueryGovVotesResponse{}, fmt.Errorf("query gov votes error: %w", err)
+	}
+	defer rows.Close()
+
+	res := QueryGovVotesResponse{
+		Votes: []GovVote{},
+	}
+	for rows.Next() {
+		var v GovVote
+		if err = rows.Scan(
+			&res.Pagination.NextKey, &v.ProposalId, &v.Height, &v.TxHash, &v.MessageIndex,
+			&v.Voter, &v.Option, &v.Weight, &v.Timestamp,
+		); err != nil {
+			logger.L.Errorw("failed to scan gov votes", "error", err, "q", q)
+			return QueryGovVotesResponse{}, fmt.Errorf("query gov votes data failed: %w", err)
+		}
+		res.Votes = append(res.Votes, v)
+	}
+	res.Pagination.Count = len(res.Votes)
+	return res, nil
+}
+
+// getValidatorAccounts returns the account addresses of the validators found in the staking events
+func getValidatorAccounts(conn *pgxpool.Conn) ([]string, error) {
+	ctx, cancel := GetTimeoutContext()
+	defer cancel()
+
+	rows, err := conn.Query(ctx, `
+	SELECT DISTINCT validator FROM staking_event
+	UNION
+	SELECT DISTINCT destination_validator FROM staking_event WHERE destination_validator != ''
+	`)
+	if err != nil {
+		logger.L.Errorw("Failed to query validators", "error", err)
+		return nil, fmt.Errorf("query validators error: %w", err)
+	}
+	defer rows.Close()
+
+	accounts := []string{}
+	for rows.Next() {
+		var validator string
+		if err = rows.Scan(&validator); err != nil {
+			logger.L.Errorw("failed to scan validators", "error", err)
+			return nil, fmt.Errorf("query validators data failed: %w", err)
+		}
+		account, err := utils.ConvertAddressPrefix(validator, MainAddressPrefix)
+		if err != nil {
+			continue
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
+// GetGovTurnout returns the number of voters and the total weights of each option on the proposal by voter type, where
+// only the latest vote of each voter is counted. Voters are validators if their accounts operate the validators found
+// in the staking events, or delegators otherwise.
+func GetGovTurnout(conn *pgxpool.Conn, proposalId uint64) (QueryGovTurnoutResponse, error) {
+	validatorAccounts, err := getValidatorAccounts(conn)
+	if err != nil {
+		return QueryGovTurnoutResponse{}, err
+	}
+	sql := `
+	WITH latest AS (
+		SELECT DISTINCT ON (voter) voter, tx_hash, message_index
+		FROM gov_vote
+		WHERE proposal_id = $1
+		ORDER BY voter, id DESC
+	), votes AS (
+		SELECT
+			v.voter, v.option, v.weight,
+			CASE WHEN v.voter = ANY($2) THEN $3::text ELSE $4::text END AS voter_type
+		FROM gov_vote v
+		JOIN latest l ON v.voter = l.voter AND v.tx_hash = l.tx_hash AND v.message_index = l.message_index
+		WHERE v.proposal_id = $1
+	)
+	SELECT voter_type, option, COUNT(DISTINCT voter), SUM(weight)::text
+	FROM votes
+	GROUP BY GROUPING SETS ((voter_type), (voter_type, option))
+	ORDER BY voter_type, option NULLS FIRST
+	`
+
+	ctx, cancel := GetTimeoutContext()
+	defer cancel()
+
+	rows, err := conn.Query(ctx, sql, proposalId, validatorAccounts, GOV_VOTER_TYPE_VALIDATOR, GOV_VOTER_TYPE_DELEGATOR)
+	if err != nil {
+		logger.L.Errorw("Failed to query gov turnout", "error", err)
+		return QueryGovTurnoutResponse{}, fmt.Errorf("query gov turnout error: %w", err)
+	}
+	defer rows.Close()
+
+	res := QueryGovTurnoutResponse{
+		ProposalId: proposalId,
+		Turnout:    []GovTurnout{},
+	}
+	for rows.Next() {
+		var voterType string
+		var option *string
+		var voters int64
+		var weight string
+		if err = rows.Scan(&voterType, &option, &voters, &weight); err != nil {
+			logger.L.Errorw("failed to scan gov turnout", "error", err, "proposal_id", proposalId)
+			return QueryGovTurnoutResponse{}, fmt.Errorf("query gov turnout data failed: %w", err)
+		}
+		// the row of the voter type comes before the rows of its options
+		if option == nil {
+			res.Turnout = append(res.Turnout, GovTurnout{
+				VoterType: voterType,
+				Voters:    voters,
+				Options:   []GovTurnoutOption{},
+			})
+			continue
+		}
+		t := &res.Turnout[len(res.Turnout)-1]
+		t.Options = append(t.Options, GovTurnoutOption{
+			Option: *option,
+			Voters: voters,
+			Weight: weight,
+		})
+	}
+	return res, nil
+}
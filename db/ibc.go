@@ -0,0 +1,115 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"github.com/likecoin/likecoin-chain-tx-indexer/logger"
+	"github.com/likecoin/likecoin-chain-tx-indexer/utils"
+)
+
+// InsertIbcTransfer inserts the transfer of a sent or received packet. The address on this chain is normalized, while
+// the address on the counterparty chain is kept as is.
+func (batch *Batch) InsertIbcTransfer(t IbcTransfer) {
+	if t.Direction == IBC_TRANSFER_DIRECTION_SEND {
+		convertedSender, err := utils.ConvertAddressPrefix(t.Sender, MainAddressPrefix)
+		if err == nil {
+			t.Sender = convertedSender
+		}
+	} else {
+		convertedReceiver, err := utils.ConvertAddressPrefix(t.Receiver, MainAddressPrefix)
+		if err == nil {
+			t.Receiver = convertedReceiver
+		}
+	}
+	batch.Batch.Queue(`
+	INSERT INTO ibc_transfer (
+		direction, src_port, src_channel, dst_port, dst_channel,
+		sequence, sender, receiver, denom, amount,
+		status, error, timeout_height, timeout_timestamp, height,
+		tx_hash, timestamp
+	)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+	ON CONFLICT (direction, src_port, src_channel, sequence) DO NOTHING
+	`,
+		t.Direction, t.SrcPort, t.SrcChannel, t.DstPort, t.DstChannel,
+		t.Sequence, t.Sender, t.Receiver, t.Denom, t.Amount,
+		t.Status, t.Error, t.TimeoutHeight, t.TimeoutTimestamp, t.Height,
+		t.TxHash, t.Timestamp.UTC(),
+	)
+}
+
+// CompleteIbcTransfer updates the status of the pending transfer of the sent packet
+func (batch *Batch) CompleteIbcTransfer(c IbcPacketCompletion) {
+	batch.Batch.Queue(`
+	UPDATE ibc_transfer
+	SET status = $4, error = $5, completed_height = $6, completed_tx_hash = $7, completed_at = $8
+	WHERE direction = $9 AND src_port = $1 AND src_channel = $2 AND sequence = $3 AND status = $10
+	`,
+		c.SrcPort, c.SrcChannel, c.Sequence, c.Status, c.Error, c.Height, c.TxHash, c.Timestamp.UTC(),
+		IBC_TRANSFER_DIRECTION_SEND, IBC_TRANSFER_STATUS_PENDING,
+	)
+}
+
+// GetIbcTransfers returns the transfers of the address as either sender or receiver. The channel is the channel on this
+// chain, i.e. the source channel of sent packets and the destination channel of received packets.
+func GetIbcTransfers(conn *pgxpool.Conn, q QueryIbcTransfersRequest, p PageRequest) (QueryIbcTransfersResponse, error) {
+	addressVariations := utils.ConvertAddressPrefixes(q.Address, AddressPrefixes)
+	if q.Address != "" {
+		// addresses on the counterparty chains are stored as is
+		addressVariations = append(addressVariations, q.Address)
+	}
+	sql := fmt.Sprintf(`
+	SELECT
+		id, direction, src_port, src_channel, dst_port,
+		dst_channel, sequence, sender, receiver, denom,
+		amount::text, status, error, timeout_height, timeout_timestamp,
+		height, tx_hash, timestamp, completed_height, completed_tx_hash,
+		completed_at
+	FROM ibc_transfer
+	WHERE ($1 = 0 OR id > $1)
+		AND ($2 = 0 OR id < $2)
+		AND ($4::text[] IS NULL OR cardinality($4::text[]) = 0 OR sender = ANY($4) OR receiver = ANY($4))
+		AND ($5 = '' OR direction = $5)
+		AND ($6 = '' OR (direction = 'send' AND src_channel = $6) OR (direction = 'receive' AND dst_channel = $6))
+		AND ($7 = '' OR denom = $7)
+		AND ($8::text[] IS NULL OR cardinality($8::text[]) = 0 OR status = ANY($8))
+	ORDER BY id %s
+	LIMIT $3
+	`, p.Order())
+
+	ctx, cancel := GetTimeoutContext()
+	defer cancel()
+
+	rows, err := conn.Query(
+		ctx, sql,
+		p.After(), p.Before(), p.Limit, addressVariations, q.Direction,
+		q.Channel, q.Denom, q.Status,
+	)
+	if err != nil {
+		logger.L.Errorw("Failed to query IBC transfers", "error", err)
+		return QueryIbcTransfersResponse{}, fmt.Errorf("query IBC transfers error: %w", err)
+	}
+	defer rows.Close()
+
+	res := QueryIbcTransfersResponse{
+		Transfers: []IbcTransfer{},
+	}
+	for rows.Next() {
+		var t IbcTransfer
+		if err = rows.Scan(
+			&res.Pagination.NextKey, &t.Direction, &t.SrcPort, &t.SrcChannel, &t.DstPort,
+			&t.DstChannel, &t.Sequence, &t.Sender, &t.Receiver, &t.Denom,
+			&t.Amount, &t.Status, &t.Error, &t.TimeoutHeight, &t.TimeoutTimestamp,
+			&t.Height, &t.TxHash, &t.Timestamp, &t.CompletedHeight, &t.CompletedTxHash,
+			&t.CompletedAt,
+		); err != nil {
+			logger.L.Errorw("failed to scan IBC transfers", "error", err, "q", q)
+			return QueryIbcTransfersResponse{}, fmt.Errorf("query IBC transfers data failed: %w", err)
+		}
+		res.Transfers = append(res.Transfers, t)
+	}
+	res.Pagination.Count = len(res.Transfers)
+	return res, nil
+}
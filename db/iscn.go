@@ -168,3 +168,74 @@ func parseIscn(rows pgx.Rows, limit int) (IscnResponse, error) {
 	res.Pagination.Count = len(res.Records)
 	return res, nil
 }
+
+func (batch *Batch) InsertIscnEvent(e IscnEvent) {
+	for _, address := range []*string{&e.Sender, &e.Receiver, &e.Executor, &e.Granter} {
+		converted, err := utils.ConvertAddressPrefix(*address, MainAddressPrefix)
+		if err == nil {
+			*address = converted
+		}
+	}
+	batch.Batch.Queue(`
+	INSERT INTO iscn_event (
+		action, iscn_id, iscn_id_prefix, version, sender,
+		receiver, executor, granter, height, tx_hash,
+		timestamp
+	)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	ON CONFLICT (action, iscn_id, tx_hash) DO NOTHING
+	`,
+		e.Action, e.IscnId, e.IscnIdPrefix, e.Version, e.Sender,
+		e.Receiver, e.Executor, e.Granter, e.Height, e.TxHash,
+		e.Timestamp.UTC(),
+	)
+}
+
+// GetIscnHistory returns the creation, updates and ownership transfers of the ISCN, where the owner at a time is the
+// receiver of the latest event before it
+func GetIscnHistory(conn *pgxpool.Conn, q QueryIscnHistoryRequest, p PageRequest) (QueryIscnHistoryResponse, error) {
+	sql := fmt.Sprintf(`
+	SELECT
+		id, action, iscn_id, iscn_id_prefix, version,
+		sender, receiver, executor, granter, height,
+		tx_hash, timestamp
+	FROM iscn_event
+	WHERE iscn_id_prefix = $4
+		AND ($1 = 0 OR id > $1)
+		AND ($2 = 0 OR id < $2)
+		AND ($5::text[] IS NULL OR cardinality($5::text[]) = 0 OR action = ANY($5))
+	ORDER BY id %s
+	LIMIT $3
+	`, p.Order())
+
+	ctx, cancel := GetTimeoutContext()
+	defer cancel()
+
+	rows, err := conn.Query(
+		ctx, sql,
+		p.After(), p.Before(), p.Limit, q.IscnIdPrefix, q.Action,
+	)
+	if err != nil {
+		logger.L.Errorw("Failed to query ISCN history", "error", err)
+		return QueryIscnHistoryResponse{}, fmt.Errorf("query ISCN history error: %w", err)
+	}
+	defer rows.Close()
+
+	res := QueryIscnHistoryResponse{
+		Events: []IscnEvent{},
+	}
+	for rows.Next() {
+		var e IscnEvent
+		if err = rows.Scan(
+			&res.Pagination.NextKey, &e.Action, &e.IscnId, &e.IscnIdPrefix, &e.Version,
+			&e.Sender, &e.Receiver, &e.Executor, &e.Granter, &e.Height,
+			&e.TxHash, &e.Timestamp,
+		); err != nil {
+			logger.L.Errorw("failed to scan ISCN history", "error", err, "q", q)
+			return QueryIscnHistoryResponse{}, fmt.Errorf("query ISCN history data failed: %w", err)
+		}
+		res.Events = append(res.Events, e)
+	}
+	res.Pagination.Count = len(res.Events)
+	return res, nil
+}
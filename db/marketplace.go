@@ -6,6 +6,8 @@ import (
 
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/likecoin/likecoin-chain-tx-indexer/logger"
+	"github.com/likecoin/likecoin-chain-tx-indexer/pubsub"
+	"github.com/likecoin/likecoin-chain-tx-indexer/utils"
 )
 
 func GetNftMarketplaceItems(conn *pgxpool.Conn, q QueryNftMarketplaceItemsRequest, p PageRequest) (QueryNftMarketplaceItemsResponse, error) {
@@ -19,9 +21,11 @@ func GetNftMarketplaceItems(conn *pgxpool.Conn, q QueryNftMarketplaceItemsReques
 	afterTime := time.Unix(int64(after/1e9), int64(after%1e9)).UTC()
 	before := p.Before()
 	beforeTime := time.Unix(int64(before/1e9), int64(before%1e9)).UTC()
+	activeOnly := q.ActiveOnly == nil || *q.ActiveOnly
 	sql := fmt.Sprintf(`
 		SELECT
 			m.type, m.class_id, m.nft_id, m.creator, m.price, m.expiration,
+			CASE WHEN m.status = 'active' AND m.expiration <= $1 THEN 'expired' ELSE m.status END,
 			c.metadata AS class_metadata,
 			n.metadata AS nft_metadata
 		FROM nft_marketplace m
@@ -29,7 +33,7 @@ func GetNftMarketplaceItems(conn *pgxpool.Conn, q QueryNftMarketplaceItemsReques
 			ON ($11 = true AND m.class_id = c.class_id)
 		LEFT JOIN nft n
 			ON ($11 = true AND m.nft_id = n.nft_id)
-		WHERE (m.expiration > $1)
+		WHERE ($12 = false OR (m.status = 'active' AND m.expiration > $1))
 			AND ($2::bigint = 0 OR m.expiration > $3)
 			AND ($4::bigint = 0 OR m.expiration < $5)
 			AND (m.type = $7)
@@ -45,8 +49,8 @@ func GetNftMarketplaceItems(conn *pgxpool.Conn, q QueryNftMarketplaceItemsReques
 		ctx, sql,
 		// $1 ~ $7
 		blockTime, after, afterTime, before, beforeTime, p.Limit, q.Type,
-		// $8 ~ $11
-		q.ClassId, q.NftId, q.Creator, q.Expand,
+		// $8 ~ $12
+		q.ClassId, q.NftId, q.Creator, q.Expand, activeOnly,
 	)
 	if err != nil {
 		logger.L.Errorw("Failed to query database query for GetMarketplaceItems", "error", err, "q", q)
@@ -58,7 +62,7 @@ func GetNftMarketplaceItems(conn *pgxpool.Conn, q QueryNftMarketplaceItemsReques
 		var item NftMarketplaceItemResponse
 		if err = rows.Scan(
 			&item.Type, &item.ClassId, &item.NftId, &item.Creator, &item.Price, &item.Expiration,
-			&item.ClassMetadata, &item.NftMetadata,
+			&item.Status, &item.ClassMetadata, &item.NftMetadata,
 		); err != nil {
 			logger.L.Errorw("Failed to scan row into NftMarketplaceItemResponse", "error", err)
 			return QueryNftMarketplaceItemsResponse{}, fmt.Errorf("failed to scan row into NftMarketplaceItemResponse: %w", err)
@@ -70,3 +74,429 @@ func GetNftMarketplaceItems(conn *pgxpool.Conn, q QueryNftMarketplaceItemsReques
 	res.Pagination.Count = len(res.Items)
 	return res, nil
 }
+
+// ExpireNftMarketplaceItems marks the active items expired at the block time, and publishes them to pubsub unless
+// silent
+func ExpireNftMarketplaceItems(conn *pgxpool.Conn, blockTime time.Time, silent bool) ([]NftMarketplaceItem, error) {
+	sql := `
+	UPDATE nft_marketplace
+	SET status = 'expired'
+	WHERE status = 'active' AND expiration <= $1
+	RETURNING type, class_id, nft_id, creator, price, expiration, status
+	`
+	ctx, cancel := GetTimeoutContext()
+	defer cancel()
+	rows, err := conn.Query(ctx, sql, blockTime.UTC())
+	if err != nil {
+		logger.L.Errorw("Failed to expire nft marketplace items", "error", err, "block_time", blockTime)
+		return nil, fmt.Errorf("failed to expire nft marketplace items: %w", err)
+	}
+	defer rows.Close()
+
+	items := []NftMarketplaceItem{}
+	for rows.Next() {
+		var item NftMarketplaceItem
+		if err = rows.Scan(
+			&item.Type, &item.ClassId, &item.NftId, &item.Creator, &item.Price, &item.Expiration, &item.Status,
+		); err != nil {
+			logger.L.Errorw("Failed to scan expired nft marketplace item", "error", err)
+			return nil, fmt.Errorf("failed to scan expired nft marketplace item: %w", err)
+		}
+		item.Expiration = item.Expiration.UTC()
+		items = append(items, item)
+	}
+	if err = rows.Err(); err != nil {
+		logger.L.Errorw("Failed to expire nft marketplace items", "error", err, "block_time", blockTime)
+		return nil, fmt.Errorf("failed to expire nft marketplace items: %w", err)
+	}
+	if !silent {
+		for _, item := range items {
+			_ = pubsub.Publish("ExpireNFTMarketplaceItem", item)
+		}
+	}
+	return items, nil
+}
+
+// InsertNftMarketplaceHistory records a change of a listing or offer.
+// The price and expiration are taken from the item when unknown, e.g. on delete, and a fill is linked to the buy_nft
+// or sell_nft event inserted before in the batch.
+func (batch *Batch) InsertNftMarketplaceHistory(h NftMarketplaceHistory) {
+	creator, err := utils.ConvertAddressPrefix(h.Creator, MainAddressPrefix)
+	if err != nil {
+		creator = h.Creator
+	}
+	counterparty, err := utils.ConvertAddressPrefix(h.Counterparty, MainAddressPrefix)
+	if err != nil {
+		counterparty = h.Counterparty
+	}
+	var expiration interface{}
+	if h.Expiration != nil {
+		expiration = h.Expiration.UTC()
+	}
+	dealAction := ""
+	if h.Action == MARKETPLACE_ACTION_FILL {
+		dealAction = string(ACTION_BUY)
+		if h.Type == "offer" {
+			dealAction = string(ACTION_SELL)
+		}
+	}
+	sql := `
+	INSERT INTO nft_marketplace_history (
+		action, type, class_id, nft_id, creator,
+		price, expiration, counterparty, nft_event_id, height,
+		tx_hash, timestamp
+	)
+	SELECT
+		$1, $2, $3, $4, $5,
+		COALESCE(NULLIF($6::bigint, 0), m.price), COALESCE($7::timestamp, m.expiration), $8,
+		(SELECT e.id FROM nft_event AS e WHERE e.action = $9 AND e.class_id = $3 AND e.nft_id = $4 AND e.tx_hash = $11),
+		$10, $11, $12
+	FROM (SELECT 1) AS t
+	LEFT JOIN nft_marketplace AS m
+		ON m.type = $2 AND m.class_id = $3 AND m.nft_id = $4 AND m.creator = $13
+	ON CONFLICT DO NOTHING
+	`
+	batch.Batch.Queue(sql,
+		h.Action, h.Type, h.ClassId, h.NftId, creator,
+		h.Price, expiration, counterparty, dealAction, h.Height,
+		h.TxHash, h.Timestamp.UTC(), h.Creator,
+	)
+}
+
+func GetNftMarketplaceHistory(conn *pgxpool.Conn, q QueryNftMarketplaceHistoryRequest, p PageRequest) (QueryNftMarketplaceHistoryResponse, error) {
+	creatorVariations := utils.ConvertAddressPrefixes(q.Creator, AddressPrefixes)
+	sql := fmt.Sprintf(`
+	SELECT
+		h.id, h.action, h.type, h.class_id, h.nft_id,
+		h.creator, COALESCE(h.price, 0), h.expiration, h.counterparty, h.height,
+		h.tx_hash, h.timestamp,
+		e.action, e.sender, e.receiver, e.price, e.memo
+	FROM nft_marketplace_history AS h
+	LEFT JOIN nft_event AS e ON e.id = h.nft_event_id
+	WHERE h.class_id = $4
+		AND ($5 = '' OR h.nft_id = $5)
+		AND ($6 = '' OR h.type = $6)
+		AND ($7::text[] IS NULL OR cardinality($7::text[]) = 0 OR h.creator = ANY($7))
+		AND ($8::text[] IS NULL OR cardinality($8::text[]) = 0 OR h.action = ANY($8))
+		AND ($1 = 0 OR h.id > $1)
+		AND ($2 = 0 OR h.id < $2)
+	ORDER BY h.id %s
+	LIMIT $3
+	`, p.Order())
+
+	ctx, cancel := GetTimeoutContext()
+	defer cancel()
+
+	rows, err := conn.Query(ctx, sql,
+		p.After(), p.Before(), p.Limit, q.ClassId, q.NftId,
+		q.Type, creatorVariations, q.Action,
+	)
+	if err != nil {
+		logger.L.Errorw("Failed to query nft marketplace history", "error", err, "q", q)
+		return QueryNftMarketplaceHistoryResponse{}, fmt.Errorf("query nft marketplace history error: %w", err)
+	}
+	defer rows.Close()
+
+	res := QueryNftMarketplaceHistoryResponse{
+		History: []NftMarketplaceHistory{},
+	}
+	for rows.Next() {
+		var h NftMarketplaceHistory
+		var eventAction, eventSender, eventReceiver, eventMemo *string
+		var eventPrice *uint64
+		if err = rows.Scan(
+			&res.Pagination.NextKey, &h.Action, &h.Type, &h.ClassId, &h.NftId,
+			&h.Creator, &h.Price, &h.Expiration, &h.Counterparty, &h.Height,
+			&h.TxHash, &h.Timestamp,
+			&eventAction, &eventSender, &eventReceiver, &eventPrice, &eventMemo,
+		); err != nil {
+			logger.L.Errorw("failed to scan nft marketplace history", "error", err, "q", q)
+			return QueryNftMarketplaceHistoryResponse{}, fmt.Errorf("query nft marketplace history data failed: %w", err)
+		}
+		if eventAction != nil {
+			h.NftEvent = &NftEvent{
+				Action:    NftEventAction(*eventAction),
+				ClassId:   h.ClassId,
+				NftId:     h.NftId,
+				Sender:    *eventSender,
+				Receiver:  *eventReceiver,
+				TxHash:    h.TxHash,
+				Timestamp: h.Timestamp,
+			}
+			if eventPrice != nil {
+				h.NftEvent.Price = *eventPrice
+			}
+			if eventMemo != nil {
+				h.NftEvent.Memo = *eventMemo
+			}
+		}
+		res.History = append(res.History, h)
+	}
+	res.Pagination.Count = len(res.History)
+	return res, nil
+}
+
+// GetNftMarketplaceSellerStats returns the fill statistics of the listings by seller, ordered by the filled volume
+func GetNftMarketplaceSellerStats(conn *pgxpool.Conn, q QueryNftMarketplaceSellerStatsRequest, p PageRequest) (QueryNftMarketplaceSellerStatsResponse, error) {
+	sellerVariations := utils.ConvertAddressArrayPrefixes(q.Seller, AddressPrefixes)
+	sql := `
+	SELECT
+		h.creator,
+		COUNT(*) FILTER (WHERE h.action = 'create') AS listing_count,
+		COUNT(*) FILTER (WHERE h.action = 'fill') AS filled_count,
+		COUNT(*) FILTER (WHERE h.action = 'delete') AS cancelled_count,
+		COALESCE(SUM(h.price) FILTER (WHERE h.action = 'fill'), 0)::bigint AS filled_volume,
+		COALESCE(AVG(EXTRACT(EPOCH FROM h.timestamp - c.timestamp)) FILTER (WHERE h.action = 'fill'), 0)::float8,
+		COUNT(*) OVER() AS row_count
+	FROM nft_marketplace_history AS h
+	-- the latest creation of the listing before the fill
+	LEFT JOIN LATERAL (
+		SELECT c.timestamp
+		FROM nft_marketplace_history AS c
+		WHERE h.action = 'fill'
+			AND c.action = 'create'
+			AND c.type = h.type
+			AND c.class_id = h.class_id
+			AND c.nft_id = h.nft_id
+			AND c.creator = h.creator
+			AND c.id < h.id
+		ORDER BY c.id DESC
+		LIMIT 1
+	) AS c ON true
+	WHERE h.type = 'listing'
+		AND ($1::text[] IS NULL OR cardinality($1::text[]) = 0 OR h.creator = ANY($1))
+		AND ($2 = '' OR h.class_id = $2)
+	GROUP BY h.creator
+	ORDER BY filled_volume DESC, h.creator
+	OFFSET $3
+	LIMIT $4
+	`
+
+	ctx, cancel := GetTimeoutContext()
+	defer cancel()
+
+	rows, err := conn.Query(ctx, sql, sellerVariations, q.ClassId, p.Offset, p.Limit)
+	if err != nil {
+		logger.L.Errorw("Failed to query nft marketplace seller stats", "error", err, "q", q)
+		return QueryNftMarketplaceSellerStatsResponse{}, fmt.Errorf("query nft marketplace seller stats error: %w", err)
+	}
+	defer rows.Close()
+
+	res := QueryNftMarketplaceSellerStatsResponse{
+		Sellers: []NftMarketplaceSellerStat{},
+	}
+	for rows.Next() {
+		var s NftMarketplaceSellerStat
+		if err = rows.Scan(
+			&s.Seller, &s.ListingCount, &s.FilledCount, &s.CancelledCount, &s.FilledVolume,
+			&s.AvgFillSeconds, &res.Pagination.Total,
+		); err != nil {
+			logger.L.Errorw("failed to scan nft marketplace seller stats", "error", err, "q", q)
+			return QueryNftMarketplaceSellerStatsResponse{}, fmt.Errorf("query nft marketplace seller stats data failed: %w", err)
+		}
+		if s.ListingCount > 0 {
+			s.FillRate = float64(s.FilledCount) / float64(s.ListingCount)
+		}
+		res.Sellers = append(res.Sellers, s)
+	}
+	res.Pagination.Count = len(res.Sellers)
+	return res, nil
+}
+
+// GetNftMarketplaceClassStats returns the floor price, best offer, spread and price depth of the active listings and
+// offers of the classes, in the order of the requested class IDs. The depth is only returned with a bucket size, as the
+// levels nearest to the spread, i.e. from the floor price upwards and from the best offer downwards.
+func GetNftMarketplaceClassStats(conn *pgxpool.Conn, q QueryNftMarketplaceClassStatsRequest) (QueryNftMarketplaceClassStatsResponse, error) {
+	blockTime, err := GetLatestBlockTime(conn)
+	if err != nil {
+		logger.L.Errorw("Failed to get latest block time", "error", err)
+		// same as GetNftMarketplaceItems, the expired items are included without the block time
+		blockTime = time.Unix(0, 0)
+	}
+	res := QueryNftMarketplaceClassStatsResponse{
+		Classes: []NftMarketplaceClassStat{},
+	}
+	seen := map[string]bool{}
+	for _, classId := range q.ClassIds {
+		if !seen[classId] {
+			seen[classId] = true
+			res.Classes = append(res.Classes, NftMarketplaceClassStat{ClassId: classId})
+		}
+	}
+	statsByClassId := map[string]*NftMarketplaceClassStat{}
+	for i := range res.Classes {
+		statsByClassId[res.Classes[i].ClassId] = &res.Classes[i]
+	}
+
+	sql := `
+	SELECT class_id, type, MIN(price), MAX(price), COUNT(*)
+	FROM nft_marketplace
+	WHERE class_id = ANY($1)
+		AND status = 'active'
+		AND expiration > $2
+	GROUP BY class_id, type
+	`
+	ctx, cancel := GetTimeoutContext()
+	defer cancel()
+	rows, err := conn.Query(ctx, sql, q.ClassIds, blockTime)
+	if err != nil {
+		logger.L.Errorw("Failed to query nft marketplace class stats", "error", err, "q", q)
+		return QueryNftMarketplaceClassStatsResponse{}, fmt.Errorf("query nft marketplace class stats error: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var classId, itemType string
+		var minPrice, maxPrice uint64
+		var count int
+		if err = rows.Scan(&classId, &itemType, &minPrice, &maxPrice, &count); err != nil {
+			logger.L.Errorw("failed to scan nft marketplace class stats", "error", err, "q", q)
+			return QueryNftMarketplaceClassStatsResponse{}, fmt.Errorf("query nft marketplace class stats data failed: %w", err)
+		}
+		stat := statsByClassId[classId]
+		switch itemType {
+		case "listing":
+			stat.FloorPrice = &minPrice
+			stat.ListingCount = count
+		case "offer":
+			stat.BestOffer = &maxPrice
+			stat.OfferCount = count
+		}
+	}
+	rows.Close()
+	for i := range res.Classes {
+		stat := &res.Classes[i]
+		if stat.FloorPrice != nil && stat.BestOffer != nil {
+			spread := int64(*stat.FloorPrice) - int64(*stat.BestOffer)
+			stat.Spread = &spread
+		}
+	}
+
+	if q.BucketSize == 0 || q.DepthLevels == 0 {
+		return res, nil
+	}
+	sql = `
+	SELECT class_id, type, bucket, count
+	FROM (
+		SELECT
+			class_id, type, price / $3 * $3 AS bucket, COUNT(*) AS count,
+			ROW_NUMBER() OVER (
+				PARTITION BY class_id, type
+				ORDER BY CASE WHEN type = 'listing' THEN price / $3 ELSE -(price / $3) END
+			) AS level
+		FROM nft_marketplace
+		WHERE class_id = ANY($1)
+			AND status = 'active'
+			AND expiration > $2
+		GROUP BY class_id, type, price / $3
+	) AS t
+	WHERE level <= $4
+	ORDER BY class_id, type, level
+	`
+	rows, err = conn.Query(ctx, sql, q.ClassIds, blockTime, int64(q.BucketSize), q.DepthLevels)
+	if err != nil {
+		logger.L.Errorw("Failed to query nft marketplace class depth", "error", err, "q", q)
+		return QueryNftMarketplaceClassStatsResponse{}, fmt.Errorf("query nft marketplace class depth error: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var classId, itemType string
+		var level NftMarketplacePriceLevel
+		if err = rows.Scan(&classId, &itemType, &level.Price, &level.Count); err != nil {
+			logger.L.Errorw("failed to scan nft marketplace class depth", "error", err, "q", q)
+			return QueryNftMarketplaceClassStatsResponse{}, fmt.Errorf("query nft marketplace class depth data failed: %w", err)
+		}
+		stat := statsByClassId[classId]
+		switch itemType {
+		case "listing":
+			stat.ListingDepth = append(stat.ListingDepth, level)
+		case "offer":
+			stat.OfferDepth = append(stat.OfferDepth, level)
+		}
+	}
+	return res, nil
+}
+
+// SnapshotNftMarketplaceFloorPrice records the floor price and count of the active listings of the class at the block,
+// if they changed since the last snapshot before the block. It is queued after each change of the listings of the
+// class, and replaces the snapshot recorded before in the same block, so the snapshot is the state at the end of the
+// block, and replaying the block records the same snapshot again.
+func (batch *Batch) SnapshotNftMarketplaceFloorPrice(classId string, height int64, blockTime time.Time) {
+	batch.Batch.Queue(`DELETE FROM nft_marketplace_floor_price WHERE class_id = $1 AND height = $2`, classId, height)
+	sql := `
+	WITH current AS (
+		SELECT MIN(price) AS floor_price, COUNT(*)::int AS listing_count
+		FROM nft_marketplace
+		WHERE class_id = $1
+			AND type = 'listing'
+			AND status = 'active'
+			AND expiration > $3
+	), latest AS (
+		SELECT floor_price, listing_count
+		FROM nft_marketplace_floor_price
+		WHERE class_id = $1 AND height < $2
+		ORDER BY height DESC
+		LIMIT 1
+	)
+	INSERT INTO nft_marketplace_floor_price (class_id, floor_price, listing_count, height, timestamp)
+	SELECT $1, c.floor_price, c.listing_count, $2, $3
+	FROM current AS c
+	LEFT JOIN latest AS l ON true
+	WHERE c.floor_price IS DISTINCT FROM l.floor_price
+		OR c.listing_count IS DISTINCT FROM COALESCE(l.listing_count, 0)
+	`
+	batch.Batch.Queue(sql, classId, height, blockTime.UTC())
+}
+
+// SnapshotNftMarketplaceFloorPrices records the floor prices of the classes at the block like
+// SnapshotNftMarketplaceFloorPrice, e.g. after their listings expired
+func SnapshotNftMarketplaceFloorPrices(conn *pgxpool.Conn, classIds []string, height int64, blockTime time.Time) error {
+	batch := NewBatch(conn, len(classIds)*2)
+	for _, classId := range classIds {
+		batch.SnapshotNftMarketplaceFloorPrice(classId, height, blockTime)
+	}
+	err := batch.Flush()
+	if err != nil {
+		logger.L.Errorw("Failed to snapshot nft marketplace floor prices", "error", err, "height", height)
+		return fmt.Errorf("failed to snapshot nft marketplace floor prices: %w", err)
+	}
+	return nil
+}
+
+func GetNftMarketplaceFloorPriceHistory(conn *pgxpool.Conn, q QueryNftMarketplaceFloorPriceHistoryRequest, p PageRequest) (QueryNftMarketplaceFloorPriceHistoryResponse, error) {
+	sql := fmt.Sprintf(`
+	SELECT id, class_id, floor_price, listing_count, height, timestamp
+	FROM nft_marketplace_floor_price
+	WHERE class_id = $4
+		AND ($5 = 0 OR timestamp > to_timestamp($5))
+		AND ($6 = 0 OR timestamp < to_timestamp($6))
+		AND ($1 = 0 OR id > $1)
+		AND ($2 = 0 OR id < $2)
+	ORDER BY id %s
+	LIMIT $3
+	`, p.Order())
+
+	ctx, cancel := GetTimeoutContext()
+	defer cancel()
+
+	rows, err := conn.Query(ctx, sql, p.After(), p.Before(), p.Limit, q.ClassId, q.After, q.Before)
+	if err != nil {
+		logger.L.Errorw("Failed to query nft marketplace floor price history", "error", err, "q", q)
+		return QueryNftMarketplaceFloorPriceHistoryResponse{}, fmt.Errorf("query nft marketplace floor price history error: %w", err)
+	}
+	defer rows.Close()
+
+	res := QueryNftMarketplaceFloorPriceHistoryResponse{
+		Snapshots: []NftMarketplaceFloorPriceSnapshot{},
+	}
+	for rows.Next() {
+		var s NftMarketplaceFloorPriceSnapshot
+		if err = rows.Scan(&res.Pagination.NextKey, &s.ClassId, &s.FloorPrice, &s.ListingCount, &s.Height, &s.Timestamp); err != nil {
+			logger.L.Errorw("failed to scan nft marketplace floor price history", "error", err, "q", q)
+			return QueryNftMarketplaceFloorPriceHistoryResponse{}, fmt.Errorf("query nft marketplace floor price history data failed: %w", err)
+		}
+		s.Timestamp = s.Timestamp.UTC()
+		res.Snapshots = append(res.Snapshots, s)
+	}
+	res.Pagination.Count = len(res.Snapshots)
+	return res, nil
+}
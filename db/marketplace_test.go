@@ -3,6 +3,7 @@ package db_test
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"testing"
 	"time"
 
@@ -132,6 +133,7 @@ func TestMarketplace(t *testing.T) {
 		LatestBlockTime:     &blockTime,
 	})
 
+	activeOnly := false
 	table := []struct {
 		name          string
 		query         QueryNftMarketplaceItemsRequest
@@ -141,6 +143,7 @@ func TestMarketplace(t *testing.T) {
 		pagination    PageResponse
 		classMetadata []json.RawMessage
 		nftMetadata   []json.RawMessage
+		statuses      []NftMarketplaceItemStatus
 	}{
 		{
 			name:   "query all listings",
@@ -148,6 +151,13 @@ func TestMarketplace(t *testing.T) {
 			length: 3,
 			items:  []NftMarketplaceItem{marketplaceItems[0], marketplaceItems[1], marketplaceItems[2]},
 		},
+		{
+			name:     "include inactive listings",
+			query:    QueryNftMarketplaceItemsRequest{Type: "listing", ActiveOnly: &activeOnly},
+			length:   4,
+			items:    []NftMarketplaceItem{marketplaceItems[0], marketplaceItems[1], marketplaceItems[2], marketplaceItems[3]},
+			statuses: []NftMarketplaceItemStatus{MARKETPLACE_STATUS_ACTIVE, MARKETPLACE_STATUS_ACTIVE, MARKETPLACE_STATUS_ACTIVE, MARKETPLACE_STATUS_EXPIRED},
+		},
 		{
 			name:   "query all offers",
 			query:  QueryNftMarketplaceItemsRequest{Type: "offer"},
@@ -238,6 +248,9 @@ func TestMarketplace(t *testing.T) {
 				require.Equal(t, item.Price, res.Items[i].Price)
 				require.Equal(t, item.Expiration, res.Items[i].Expiration)
 			}
+			for i, status := range v.statuses {
+				require.Equal(t, status, res.Items[i].Status)
+			}
 			for i, classMetadata := range v.classMetadata {
 				if classMetadata != nil {
 					require.Equal(t, 0, bytes.Compare(classMetadata, res.Items[i].ClassMetadata), "%s <-> %s", classMetadata, res.Items[i].ClassMetadata)
@@ -254,3 +267,195 @@ func TestMarketplace(t *testing.T) {
 		})
 	}
 }
+
+func TestExpireNftMarketplaceItems(t *testing.T) {
+	defer CleanupTestData(Conn)
+	expiration := time.Unix(1700000000, 0).UTC()
+	items := []NftMarketplaceItem{
+		{
+			Type:       "listing",
+			ClassId:    "nftlike1aaaaa1",
+			NftId:      "testing-nft-91301",
+			Creator:    ADDR_01_LIKE,
+			Price:      100,
+			Expiration: expiration,
+		},
+		{
+			Type:       "listing",
+			ClassId:    "nftlike1aaaaa1",
+			NftId:      "testing-nft-91302",
+			Creator:    ADDR_02_LIKE,
+			Price:      200,
+			Expiration: expiration.Add(1 * time.Second),
+		},
+	}
+	InsertTestData(DBTestData{NftMarketplaceItems: items})
+
+	expired, err := ExpireNftMarketplaceItems(Conn, expiration.Add(-1*time.Second), false)
+	require.NoError(t, err)
+	require.Empty(t, expired)
+
+	expired, err = ExpireNftMarketplaceItems(Conn, expiration, false)
+	require.NoError(t, err)
+	require.Len(t, expired, 1)
+	require.Equal(t, items[0].NftId, expired[0].NftId)
+	require.Equal(t, items[0].Expiration, expired[0].Expiration)
+	require.Equal(t, MARKETPLACE_STATUS_EXPIRED, expired[0].Status)
+
+	// expired items are not expired again
+	expired, err = ExpireNftMarketplaceItems(Conn, expiration.Add(1*time.Second), false)
+	require.NoError(t, err)
+	require.Len(t, expired, 1)
+	require.Equal(t, items[1].NftId, expired[0].NftId)
+}
+
+func TestGetNftMarketplaceClassStats(t *testing.T) {
+	defer CleanupTestData(Conn)
+	classA := "nftlike1aaaaa1"
+	classB := "nftlike1bbbbb1"
+	classC := "nftlike1ccccc1"
+	expiration := time.Unix(1700000000, 0).UTC()
+	items := []NftMarketplaceItem{}
+	for i, price := range []uint64{120, 100, 150, 210, 90} {
+		items = append(items, NftMarketplaceItem{
+			Type:       "listing",
+			ClassId:    classA,
+			NftId:      fmt.Sprintf("testing-nft-%d", i),
+			Creator:    ADDR_01_LIKE,
+			Price:      price,
+			Expiration: expiration,
+		})
+	}
+	// expired at the block time
+	items[4].Expiration = expiration.Add(-200 * time.Second)
+	for i, price := range []uint64{80, 95, 40} {
+		items = append(items, NftMarketplaceItem{
+			Type:       "offer",
+			ClassId:    classA,
+			NftId:      "testing-nft-0",
+			Creator:    fmt.Sprintf("like1offer%d", i),
+			Price:      price,
+			Expiration: expiration,
+		})
+	}
+	items = append(items, NftMarketplaceItem{
+		Type:       "offer",
+		ClassId:    classB,
+		NftId:      "testing-nft-b",
+		Creator:    ADDR_02_LIKE,
+		Price:      300,
+		Expiration: expiration,
+	})
+	blockTime := expiration.Add(-100 * time.Second)
+	InsertTestData(DBTestData{
+		NftMarketplaceItems: items,
+		LatestBlockTime:     &blockTime,
+	})
+
+	uint64Ptr := func(v uint64) *uint64 { return &v }
+	int64Ptr := func(v int64) *int64 { return &v }
+
+	res, err := GetNftMarketplaceClassStats(Conn, QueryNftMarketplaceClassStatsRequest{
+		ClassIds: []string{classC, classA, classB, classA},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []NftMarketplaceClassStat{
+		{ClassId: classC},
+		{
+			ClassId:      classA,
+			FloorPrice:   uint64Ptr(100),
+			BestOffer:    uint64Ptr(95),
+			Spread:       int64Ptr(5),
+			ListingCount: 4,
+			OfferCount:   3,
+		},
+		{ClassId: classB, BestOffer: uint64Ptr(300), OfferCount: 1},
+	}, res.Classes)
+
+	res, err = GetNftMarketplaceClassStats(Conn, QueryNftMarketplaceClassStatsRequest{
+		ClassIds:    []string{classA},
+		BucketSize:  50,
+		DepthLevels: 2,
+	})
+	require.NoError(t, err)
+	require.Len(t, res.Classes, 1)
+	require.Equal(t, []NftMarketplacePriceLevel{{Price: 100, Count: 2}, {Price: 150, Count: 1}}, res.Classes[0].ListingDepth)
+	require.Equal(t, []NftMarketplacePriceLevel{{Price: 50, Count: 2}, {Price: 0, Count: 1}}, res.Classes[0].OfferDepth)
+}
+
+func TestSnapshotNftMarketplaceFloorPrices(t *testing.T) {
+	defer CleanupTestData(Conn)
+	classId := "nftlike1aaaaa1"
+	expiration := time.Unix(1700000000, 0).UTC()
+	items := []NftMarketplaceItem{
+		{
+			Type:       "listing",
+			ClassId:    classId,
+			NftId:      "testing-nft-91301",
+			Creator:    ADDR_01_LIKE,
+			Price:      100,
+			Expiration: expiration,
+		},
+		{
+			Type:       "listing",
+			ClassId:    classId,
+			NftId:      "testing-nft-91302",
+			Creator:    ADDR_02_LIKE,
+			Price:      200,
+			Expiration: expiration.Add(1 * time.Second),
+		},
+	}
+	InsertTestData(DBTestData{NftMarketplaceItems: items})
+
+	blockTime := expiration.Add(-10 * time.Second)
+	batch := NewBatch(Conn, 10)
+	batch.SnapshotNftMarketplaceFloorPrice(classId, 1000, blockTime)
+	require.NoError(t, batch.Flush())
+	// replaying the block records the same snapshot
+	batch.SnapshotNftMarketplaceFloorPrice(classId, 1000, blockTime)
+	require.NoError(t, batch.Flush())
+
+	// unchanged at the end of the block, after a cheaper listing is created and cancelled in the block
+	cheaper := NftMarketplaceItem{
+		Type:       "listing",
+		ClassId:    classId,
+		NftId:      "testing-nft-91303",
+		Creator:    ADDR_03_LIKE,
+		Price:      50,
+		Expiration: expiration,
+	}
+	batch.InsertNFTMarketplaceItem(cheaper)
+	batch.SnapshotNftMarketplaceFloorPrice(classId, 1001, blockTime.Add(1*time.Second))
+	batch.DeleteNFTMarketplaceItem(cheaper)
+	batch.SnapshotNftMarketplaceFloorPrice(classId, 1001, blockTime.Add(1*time.Second))
+	require.NoError(t, batch.Flush())
+
+	for i, height := range []int64{1002, 1003, 1004} {
+		err := SnapshotNftMarketplaceFloorPrices(Conn, []string{classId}, height, expiration.Add(time.Duration(i)*time.Second))
+		require.NoError(t, err)
+	}
+
+	res, err := GetNftMarketplaceFloorPriceHistory(Conn, QueryNftMarketplaceFloorPriceHistoryRequest{
+		ClassId: classId,
+	}, PageRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, res.Snapshots, 3)
+	floorPrice1, floorPrice2 := uint64(100), uint64(200)
+	require.Equal(t, NftMarketplaceFloorPriceSnapshot{
+		ClassId: classId, FloorPrice: &floorPrice1, ListingCount: 2, Height: 1000, Timestamp: blockTime,
+	}, res.Snapshots[0])
+	require.Equal(t, NftMarketplaceFloorPriceSnapshot{
+		ClassId: classId, FloorPrice: &floorPrice2, ListingCount: 1, Height: 1002, Timestamp: expiration,
+	}, res.Snapshots[1])
+	require.Equal(t, NftMarketplaceFloorPriceSnapshot{
+		ClassId: classId, ListingCount: 0, Height: 1003, Timestamp: expiration.Add(1 * time.Second),
+	}, res.Snapshots[2])
+
+	res, err = GetNftMarketplaceFloorPriceHistory(Conn, QueryNftMarketplaceFloorPriceHistoryRequest{
+		ClassId: classId,
+		After:   expiration.Unix() - 1,
+	}, PageRequest{Limit: 10, Reverse: true})
+	require.NoError(t, err)
+	require.Len(t, res.Snapshots, 2)
+	require.Equal(t, int64(1003), res.Snapshots[0].Height)
+}
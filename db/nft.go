@@ -2,6 +2,7 @@ package db
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/jackc/pgtype"
 	"github.com/jackc/pgx/v4"
@@ -14,11 +15,22 @@ func GetClasses(conn *pgxpool.Conn, q QueryClassRequest, p PageRequest) (QueryCl
 	accountVariations := utils.ConvertAddressPrefixes(q.Account, AddressPrefixes)
 	iscnOwnerVariations := utils.ConvertAddressArrayPrefixes(q.IscnOwner, AddressPrefixes)
 	ownerVariations := utils.ConvertAddressPrefixes(q.Owner, AddressPrefixes)
+	// the mint periods are checked at the latest block time, the same as the expiration of the marketplace items
+	blockTime := time.Unix(0, 0).UTC()
+	if q.MintableNow {
+		var err error
+		blockTime, err = GetLatestBlockTime(conn)
+		if err != nil {
+			logger.L.Errorw("Failed to get latest block time", "error", err)
+			return QueryClassResponse{}, fmt.Errorf("failed to get latest block time: %w", err)
+		}
+	}
 	sql := fmt.Sprintf(`
 	WITH owner_nfts AS (
 		SELECT n.class_id, COUNT(*) AS nft_owned_count
 		FROM nft AS n
 		WHERE ($8::text[] IS NOT NULL AND cardinality($8::text[]) > 0 AND n.owner = ANY($8))
+			AND ($9 = true OR n.burned_at IS NULL)
 		GROUP BY n.class_id
 	),
 	last_owned_events AS (
@@ -36,7 +48,7 @@ func GetClasses(conn *pgxpool.Conn, q QueryClassRequest, p PageRequest) (QueryCl
 		c.id, c.class_id, c.name, c.description, c.symbol,
 		c.uri, c.uri_hash, c.config, c.metadata, c.latest_price,
 		c.parent_type, c.parent_iscn_id_prefix, c.parent_account, c.created_at, c.price_updated_at,
-		i.owner, owner_nfts.nft_owned_count, last_owned_events.nft_id, last_owned_events.timestamp
+		i.owner, owner_nfts.nft_owned_count, last_owned_events.nft_id, last_owned_events.timestamp, rm.content
 	FROM nft_class as c
 	LEFT JOIN iscn AS i ON i.iscn_id_prefix = c.parent_iscn_id_prefix
 	LEFT JOIN iscn_latest_version
@@ -45,15 +57,31 @@ func GetClasses(conn *pgxpool.Conn, q QueryClassRequest, p PageRequest) (QueryCl
 		-- this is for optimizing out a left join when nft data is not needed
 		ON ($8::text[] IS NOT NULL AND cardinality($8::text[]) > 0)
 			AND n.class_id = c.class_id
+			AND ($9 = true OR n.burned_at IS NULL)
 	LEFT JOIN owner_nfts 
 		ON c.class_id = owner_nfts.class_id
 	LEFT JOIN last_owned_events 
 		ON c.class_id = last_owned_events.class_id
+	LEFT JOIN resolved_metadata AS rm
+		ON $13 = true AND rm.status = 'resolved'
+			AND rm.uri = c.uri AND rm.uri_hash = COALESCE(c.uri_hash, '')
 	WHERE ($4 = '' OR c.parent_iscn_id_prefix = $4)
 		AND ($5::text[] IS NULL OR cardinality($5::text[]) = 0 OR c.parent_account = ANY($5))
 		AND ($6::text[] IS NULL OR cardinality($6::text[]) = 0 OR i.owner = ANY($6))
 		AND ($8::text[] IS NULL OR cardinality($8::text[]) = 0 OR n.owner = ANY($8))
 		AND ($7 = true OR i.version = iscn_latest_version.latest_version)
+		AND ($10::boolean IS NULL OR (c.blind_box_reveal_time IS NOT NULL) = $10)
+		AND ($11 = false OR (
+			EXISTS (
+				SELECT 1 FROM nft_class_mint_period AS mp
+				WHERE mp.class_id = c.class_id AND mp.start_time <= $14
+			)
+			AND (c.max_supply = 0 OR c.max_supply > (
+				SELECT COUNT(*) FROM nft AS sn
+				WHERE sn.class_id = c.class_id AND sn.burned_at IS NULL
+			))
+		))
+		AND ($12::numeric = 0 OR (c.max_supply > 0 AND c.max_supply <= $12))
 		AND ($1 = 0 OR c.id > $1)
 		AND ($2 = 0 OR c.id < $2)
 	ORDER BY c.id %s
@@ -64,7 +92,8 @@ func GetClasses(conn *pgxpool.Conn, q QueryClassRequest, p PageRequest) (QueryCl
 	rows, err := conn.Query(
 		ctx, sql,
 		p.After(), p.Before(), p.Limit, q.IscnIdPrefix, accountVariations,
-		iscnOwnerVariations, q.AllIscnVersions, ownerVariations)
+		iscnOwnerVariations, q.AllIscnVersions, ownerVariations, q.IncludeBurned, q.HasBlindBox,
+		q.MintableNow, q.MaxSupplyLte, q.ExpandUriMetadata, blockTime)
 	if err != nil {
 		logger.L.Errorw("Failed to query nft class by iscn id prefix", "error", err, "q", q)
 		return QueryClassResponse{}, fmt.Errorf("query nft class by iscn id prefix error: %w", err)
@@ -79,7 +108,7 @@ func GetClasses(conn *pgxpool.Conn, q QueryClassRequest, p PageRequest) (QueryCl
 			&res.Pagination.NextKey, &c.Id, &c.Name, &c.Description, &c.Symbol,
 			&c.URI, &c.URIHash, &c.Config, &c.Metadata, &c.LatestPrice,
 			&c.Parent.Type, &c.Parent.IscnIdPrefix, &c.Parent.Account, &c.CreatedAt, &c.PriceUpdatedAt,
-			&c.Owner, &c.NftOwnedCount, &c.LastOwnedNftId, &c.NftLastOwnedAt,
+			&c.Owner, &c.NftOwnedCount, &c.LastOwnedNftId, &c.NftLastOwnedAt, &c.UriMetadata,
 		); err != nil {
 			logger.L.Errorw("failed to scan nft class", "error", err)
 			return QueryClassResponse{}, fmt.Errorf("query nft class data failed: %w", err)
@@ -124,6 +153,7 @@ func GetClassesRanking(conn *pgxpool.Conn, q QueryRankingRequest, p PageRequest)
 				AND ($11 = 0 OR (e.timestamp IS NOT NULL AND e.timestamp > to_timestamp($11)))
 				AND ($12 = 0 OR (e.timestamp IS NOT NULL AND e.timestamp < to_timestamp($12)))
 				AND e.sender = ANY($13::text[])
+				AND ($14 = true OR n.burned_at IS NULL)
 		)
 		SELECT DISTINCT ON (ne.id)
 			ne.nft_id,
@@ -170,8 +200,8 @@ func GetClassesRanking(conn *pgxpool.Conn, q QueryRankingRequest, p PageRequest)
 		p.Limit, q.IncludeOwner, ignoreListVariations, creatorVariations, q.Type,
 		// $6 ~ $10
 		stakeholderIdVariataions, q.StakeholderName, collectorVariations, q.CreatedAfter, q.CreatedBefore,
-		// $11 ~ $13
-		q.After, q.Before, ApiAddressesVariations,
+		// $11 ~ $14
+		q.After, q.Before, ApiAddressesVariations, q.IncludeBurned,
 	)
 	if err != nil {
 		logger.L.Errorw("Failed to query nft class ranking", "error", err, "q", q)
@@ -197,6 +227,9 @@ func GetClassesRanking(conn *pgxpool.Conn, q QueryRankingRequest, p PageRequest)
 }
 
 func GetNfts(conn *pgxpool.Conn, q QueryNftRequest, p PageRequest) (QueryNftResponse, error) {
+	if q.AtHeight != 0 || q.AtTime != nil {
+		return getNftsAt(conn, q, p)
+	}
 	ownerVariations := utils.ConvertAddressPrefixes(q.Owner, AddressPrefixes)
 	sql := fmt.Sprintf(`
 	SELECT
@@ -204,10 +237,16 @@ func GetNfts(conn *pgxpool.Conn, q QueryNftRequest, p PageRequest) (QueryNftResp
 		n.uri_hash, n.metadata, e.timestamp, c.name, c.description,
 		c.symbol, c.uri, c.uri_hash, c.config, c.metadata,
 		c.latest_price, c.parent_type, c.parent_iscn_id_prefix, c.parent_account, c.created_at,
-		c.price_updated_at
+		c.price_updated_at, n.burned_at, nrm.content, crm.content
 	FROM nft as n
 	JOIN nft_class as c
 	ON n.class_id = c.class_id
+	LEFT JOIN resolved_metadata AS nrm
+		ON $6 = true AND nrm.status = 'resolved'
+			AND nrm.uri = n.uri AND nrm.uri_hash = COALESCE(n.uri_hash, '')
+	LEFT JOIN resolved_metadata AS crm
+		ON $6 = true AND $7 = true AND crm.status = 'resolved'
+			AND crm.uri = c.uri AND crm.uri_hash = COALESCE(c.uri_hash, '')
 	JOIN (
 		SELECT DISTINCT ON (nft_id) nft_id, timestamp
 		FROM nft_event
@@ -216,6 +255,7 @@ func GetNfts(conn *pgxpool.Conn, q QueryNftRequest, p PageRequest) (QueryNftResp
 	) e
 	ON n.nft_id = e.nft_id
 	WHERE owner = ANY($4)
+		AND ($5 = true OR n.burned_at IS NULL)
 		AND ($1 = 0 OR n.id > $1)
 		AND ($2 = 0 OR n.id < $2)
 	ORDER BY n.id %s
@@ -223,7 +263,11 @@ func GetNfts(conn *pgxpool.Conn, q QueryNftRequest, p PageRequest) (QueryNftResp
 	`, p.Order())
 	ctx, cancel := GetTimeoutContext()
 	defer cancel()
-	rows, err := conn.Query(ctx, sql, p.After(), p.Before(), p.Limit, ownerVariations)
+	rows, err := conn.Query(
+		ctx, sql,
+		p.After(), p.Before(), p.Limit, ownerVariations, q.IncludeBurned,
+		q.ExpandUriMetadata, q.ExpandClasses,
+	)
 	if err != nil {
 		logger.L.Errorw("Failed to query nft by owner", "error", err, "q", q)
 		return QueryNftResponse{}, fmt.Errorf("query nft class error: %w", err)
@@ -239,7 +283,7 @@ func GetNfts(conn *pgxpool.Conn, q QueryNftRequest, p PageRequest) (QueryNftResp
 			&n.UriHash, &n.Metadata, &n.Timestamp, &c.Name, &c.Description,
 			&c.Symbol, &c.URI, &c.URIHash, &c.Config, &c.Metadata,
 			&c.LatestPrice, &n.ClassParent.Type, &n.ClassParent.IscnIdPrefix, &n.ClassParent.Account, &c.CreatedAt,
-			&c.PriceUpdatedAt,
+			&c.PriceUpdatedAt, &n.BurnedAt, &n.UriMetadata, &c.UriMetadata,
 		); err != nil {
 			logger.L.Errorw("failed to scan nft", "error", err, "q", q)
 			return QueryNftResponse{}, fmt.Errorf("query nft failed: %w", err)
@@ -256,6 +300,9 @@ func GetNfts(conn *pgxpool.Conn, q QueryNftRequest, p PageRequest) (QueryNftResp
 }
 
 func GetOwners(conn *pgxpool.Conn, q QueryOwnerRequest) (QueryOwnerResponse, error) {
+	if q.AtHeight != 0 || q.AtTime != nil {
+		return getOwnersAt(conn, q)
+	}
 	ignoreListVariations := utils.ConvertAddressArrayPrefixes(q.IgnoreList, AddressPrefixes)
 
 	sql := `
@@ -271,12 +318,13 @@ func GetOwners(conn *pgxpool.Conn, q QueryOwnerRequest) (QueryOwnerResponse, err
 	WHERE n.class_id = $1
 		AND ($2 = false OR n.owner != i.owner)
 		AND ($3::text[] IS NULL OR cardinality($3::text[]) = 0 OR n.owner != ALL($3))
+		AND ($4 = true OR n.burned_at IS NULL)
 	GROUP BY n.owner
 	`
 	ctx, cancel := GetTimeoutContext()
 	defer cancel()
 
-	rows, err := conn.Query(ctx, sql, q.ClassId, q.ExcludeIscnOwner, ignoreListVariations)
+	rows, err := conn.Query(ctx, sql, q.ClassId, q.ExcludeIscnOwner, ignoreListVariations, q.IncludeBurned)
 	if err != nil {
 		logger.L.Errorw("Failed to query owner", "error", err)
 		return QueryOwnerResponse{}, fmt.Errorf("query owner error: %w", err)
@@ -305,13 +353,14 @@ func GetNftEvents(conn *pgxpool.Conn, q QueryEventsRequest, p PageRequest) (Quer
 	receiverVariations := utils.ConvertAddressArrayPrefixes(q.Receiver, AddressPrefixes)
 	creatorVariations := utils.ConvertAddressArrayPrefixes(q.Creator, AddressPrefixes)
 	involverVariations := utils.ConvertAddressArrayPrefixes(q.Involver, AddressPrefixes)
+	executorVariations := utils.ConvertAddressArrayPrefixes(q.Executor, AddressPrefixes)
 	sql := fmt.Sprintf(`
 		SELECT * FROM (
 			(
 				SELECT
 					e.id, e.action, e.class_id, e.nft_id, e.sender,
 					e.receiver, e.timestamp, e.tx_hash, e.events, e.price,
-					e.memo
+					e.memo, e.executor, e.granter
 				FROM nft_event as e
 				JOIN nft_class as c
 				ON e.class_id = c.class_id
@@ -335,13 +384,15 @@ func GetNftEvents(conn *pgxpool.Conn, q QueryEventsRequest, p PageRequest) (Quer
 					AND ($7::text[] IS NULL OR cardinality($7::text[]) = 0 OR e.action = ANY($7))
 					AND ($8::text[] IS NULL OR cardinality($8::text[]) = 0 OR e.sender != ALL($8))
 					AND ($9::text[] IS NULL OR cardinality($9::text[]) = 0 OR e.receiver != ALL($9))
+					AND ($14::text[] IS NULL OR cardinality($14::text[]) = 0 OR e.executor = ANY($14))
+					AND ($15::boolean IS NULL OR (e.executor != '') = $15)
 				ORDER BY e.id %[1]s
 				LIMIT $3
 			) UNION (
 				SELECT
 					e.id, e.action, e.class_id, e.nft_id, e.sender,
 					e.receiver, e.timestamp, e.tx_hash, e.events, e.price,
-					e.memo
+					e.memo, e.executor, e.granter
 				FROM nft_event as e
 				JOIN nft_class as c
 				ON e.class_id = c.class_id
@@ -362,6 +413,8 @@ func GetNftEvents(conn *pgxpool.Conn, q QueryEventsRequest, p PageRequest) (Quer
 					AND ($7::text[] IS NULL OR cardinality($7::text[]) = 0 OR e.action = ANY($7))
 					AND ($8::text[] IS NULL OR cardinality($8::text[]) = 0 OR e.sender != ALL($8))
 					AND ($9::text[] IS NULL OR cardinality($9::text[]) = 0 OR e.receiver != ALL($9))
+					AND ($14::text[] IS NULL OR cardinality($14::text[]) = 0 OR e.executor = ANY($14))
+					AND ($15::boolean IS NULL OR (e.executor != '') = $15)
 				ORDER BY e.id %[1]s
 				LIMIT $3
 			)
@@ -377,7 +430,7 @@ func GetNftEvents(conn *pgxpool.Conn, q QueryEventsRequest, p PageRequest) (Quer
 		ctx, sql,
 		p.After(), p.Before(), p.Limit, q.ClassId, q.NftId,
 		q.IscnIdPrefix, q.ActionType, ignoreFromListVariations, ignoreToListVariations, senderVariations,
-		receiverVariations, creatorVariations, involverVariations,
+		receiverVariations, creatorVariations, involverVariations, executorVariations, q.ViaAuthz,
 	)
 	if err != nil {
 		logger.L.Errorw("Failed to query nft events", "error", err)
@@ -394,7 +447,7 @@ func GetNftEvents(conn *pgxpool.Conn, q QueryEventsRequest, p PageRequest) (Quer
 		if err = rows.Scan(
 			&res.Pagination.NextKey, &e.Action, &e.ClassId, &e.NftId, &e.Sender,
 			&e.Receiver, &e.Timestamp, &e.TxHash, &eventRaw, &price,
-			&e.Memo,
+			&e.Memo, &e.Executor, &e.Granter,
 		); err != nil {
 			logger.L.Errorw("failed to scan nft events", "error", err, "q", q)
 			return QueryEventsResponse{}, fmt.Errorf("query nft events data failed: %w", err)
@@ -577,6 +630,7 @@ func GetCollector(conn *pgxpool.Conn, q QueryCollectorRequest, p PageRequest) (r
 		JOIN nft_class AS c ON i.iscn_id_prefix = c.parent_iscn_id_prefix
 		JOIN nft AS n ON c.class_id = n.class_id
 			AND ($4::text[] IS NULL OR cardinality($4::text[]) = 0 OR n.owner != ALL($4))
+			AND ($7 = true OR n.burned_at IS NULL)
 		JOIN LATERAL (
 			SELECT nft_id, receiver, MAX(id) AS max_id
 			FROM nft_event
@@ -606,7 +660,7 @@ func GetCollector(conn *pgxpool.Conn, q QueryCollectorRequest, p PageRequest) (r
 
 	rows, err := conn.Query(ctx, sql,
 		creatorVariations, p.Offset, p.Limit, ignoreListVariations, q.AllIscnVersions,
-		q.IncludeOwner)
+		q.IncludeOwner, q.IncludeBurned)
 	if err != nil {
 		logger.L.Errorw("failed to query collectors", "error", err, "q", q)
 		err = fmt.Errorf("query supporters error: %w", err)
@@ -645,6 +699,7 @@ func GetCreators(conn *pgxpool.Conn, q QueryCreatorRequest, p PageRequest) (res
 		JOIN nft_class AS c ON i.iscn_id_prefix = c.parent_iscn_id_prefix
 		JOIN nft AS n ON c.class_id = n.class_id
 			AND ($4::text[] IS NULL OR cardinality($4::text[]) = 0 OR n.owner != ALL($4))
+			AND ($7 = true OR n.burned_at IS NULL)
 		JOIN LATERAL (
 			SELECT nft_id, receiver, MAX(id) AS max_id
 			FROM nft_event
@@ -674,7 +729,7 @@ func GetCreators(conn *pgxpool.Conn, q QueryCreatorRequest, p PageRequest) (res
 
 	rows, err := conn.Query(ctx, sql,
 		collectorVariations, p.Offset, p.Limit, ignoreListVariations, q.AllIscnVersions,
-		q.IncludeOwner)
+		q.IncludeOwner, q.IncludeBurned)
 	if err != nil {
 		logger.L.Errorw("failed to query creators", "error", err, "q", q)
 		err = fmt.Errorf("query creators error: %w", err)
@@ -718,9 +773,10 @@ func GetUserStat(conn *pgxpool.Conn, q QueryUserStatRequest) (res QueryUserStatR
 	FROM nft_class as c
 	JOIN nft AS n ON c.class_id = n.class_id
 	WHERE n.owner = $1
+		AND ($2 = true OR n.burned_at IS NULL)
 	GROUP BY c.class_id
 	`
-	rows, err := conn.Query(ctx, sql, q.User)
+	rows, err := conn.Query(ctx, sql, q.User, q.IncludeBurned)
 	if err != nil {
 		logger.L.Errorw("failed to query collected classes", "error", err, "q", q)
 		err = fmt.Errorf("query collected classes error: %w", err)
@@ -763,10 +819,11 @@ func GetUserStat(conn *pgxpool.Conn, q QueryUserStatRequest) (res QueryUserStatR
 	JOIN nft_class AS c ON i.iscn_id_prefix = c.parent_iscn_id_prefix
 	JOIN nft AS n ON c.class_id = n.class_id
 		AND ($2::text[] IS NULL OR n.owner != ALL($2))
+		AND ($4 = true OR n.burned_at IS NULL)
 	WHERE i.owner = $1
 	`
 
-	row = conn.QueryRow(ctx, sql, q.User, q.IgnoreList, q.AllIscnVersions)
+	row = conn.QueryRow(ctx, sql, q.User, q.IgnoreList, q.AllIscnVersions, q.IncludeBurned)
 
 	err = row.Scan(&res.CollectorCount)
 	if err != nil {
@@ -823,6 +880,7 @@ func GetCollectorTopRankedCreators(conn *pgxpool.Conn, q QueryCollectorTopRanked
 		JOIN nft_class as c ON i.iscn_id_prefix = c.parent_iscn_id_prefix
 		JOIN nft AS n ON c.class_id = n.class_id
 			AND ($3::text[] IS NULL OR cardinality($3::text[]) = 0 OR n.owner != ALL($3))
+			AND ($6 = true OR n.burned_at IS NULL)
 		WHERE 
 			($5 = true OR n.owner != i.owner)
 		GROUP BY creator, collector
@@ -838,6 +896,7 @@ func GetCollectorTopRankedCreators(conn *pgxpool.Conn, q QueryCollectorTopRanked
 
 	rows, err := conn.Query(ctx, sql,
 		collectorVariations, q.Top, ignoreListVariations, q.AllIscnVersions, q.IncludeOwner,
+		q.IncludeBurned,
 	)
 	if err != nil {
 		logger.L.Errorw("failed to query collector top ranked creators list", "error", err, "q", q)
@@ -856,18 +915,22 @@ func GetCollectorTopRankedCreators(conn *pgxpool.Conn, q QueryCollectorTopRanked
 }
 
 func GetClassesOwners(conn *pgxpool.Conn, q QueryClassesOwnersRequest) (QueryClassesOwnersResponse, error) {
+	if q.AtHeight != 0 || q.AtTime != nil {
+		return getClassesOwnersAt(conn, q)
+	}
 	ownersVariations := utils.ConvertAddressArrayPrefixes(q.Owners, AddressPrefixes)
 	sql := `
 		SELECT DISTINCT owner, class_id
 		FROM nft
 		WHERE class_id = ANY($1)
 			AND ($2::text[] IS NULL OR cardinality($2::text[]) = 0 OR owner = ANY($2))
+			AND ($3 = true OR burned_at IS NULL)
 		ORDER BY owner, class_id
 ;
 	`
 	ctx, cancel := GetTimeoutContext()
 	defer cancel()
-	rows, err := conn.Query(ctx, sql, q.ClassIds, ownersVariations)
+	rows, err := conn.Query(ctx, sql, q.ClassIds, ownersVariations, q.IncludeBurned)
 	if err != nil {
 		logger.L.Errorw("Failed to query nft classes owners", "error", err, "q", q)
 		return QueryClassesOwnersResponse{}, fmt.Errorf("error on query nft classes owners: %w", err)
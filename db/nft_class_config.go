@@ -0,0 +1,117 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"github.com/likecoin/likecoin-chain-tx-indexer/logger"
+	"github.com/likecoin/likecoin-chain-tx-indexer/utils"
+)
+
+// UpdateNftClassConfig sets the structured columns of the class config, and replaces the mint periods of the blind box
+func (batch *Batch) UpdateNftClassConfig(classId string, config NftClassConfig) {
+	var revealTime interface{}
+	if config.BlindBoxConfig != nil {
+		revealTime = config.BlindBoxConfig.RevealTime.UTC()
+	}
+	batch.Batch.Queue(`
+	UPDATE nft_class SET burnable = $2, max_supply = $3, blind_box_reveal_time = $4
+	WHERE class_id = $1
+	`, classId, config.Burnable, config.MaxSupply, revealTime)
+	batch.Batch.Queue(`DELETE FROM nft_class_mint_period WHERE class_id = $1`, classId)
+	if config.BlindBoxConfig == nil {
+		return
+	}
+	for _, p := range config.BlindBoxConfig.MintPeriods {
+		allowedAddresses := []string{}
+		for _, address := range p.AllowedAddresses {
+			converted, err := utils.ConvertAddressPrefix(address, MainAddressPrefix)
+			if err == nil {
+				address = converted
+			}
+			allowedAddresses = append(allowedAddresses, address)
+		}
+		batch.Batch.Queue(`
+		INSERT INTO nft_class_mint_period (class_id, start_time, allowed_addresses, mint_price)
+		VALUES ($1, $2, $3, $4)
+		`, classId, p.StartTime.UTC(), allowedAddresses, p.MintPrice)
+	}
+}
+
+// UpdateNftRoyaltyConfig replaces the royalty config of the class, or deletes it if the config is nil
+func (batch *Batch) UpdateNftRoyaltyConfig(classId string, config *NftRoyaltyConfig) {
+	var rateBasisPoints interface{}
+	if config != nil {
+		rateBasisPoints = config.RateBasisPoints
+	}
+	batch.Batch.Queue(`UPDATE nft_class SET royalty_rate_basis_points = $2 WHERE class_id = $1`, classId, rateBasisPoints)
+	batch.Batch.Queue(`DELETE FROM nft_class_royalty_stakeholder WHERE class_id = $1`, classId)
+	if config == nil {
+		return
+	}
+	for _, s := range config.Stakeholders {
+		account := s.Account
+		converted, err := utils.ConvertAddressPrefix(account, MainAddressPrefix)
+		if err == nil {
+			account = converted
+		}
+		batch.Batch.Queue(`
+		INSERT INTO nft_class_royalty_stakeholder (class_id, account, weight)
+		VALUES ($1, $2, $3)
+		`, classId, account, s.Weight)
+	}
+}
+
+func (batch *Batch) InsertNftClassConfigHistory(h NftClassConfigHistory) {
+	var config interface{}
+	if len(h.Config) > 0 {
+		config = h.Config
+	}
+	batch.Batch.Queue(`
+	INSERT INTO nft_class_config_history (class_id, config_type, config, height, tx_hash, timestamp)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	ON CONFLICT (class_id, config_type, tx_hash) DO UPDATE SET config = EXCLUDED.config
+	`, h.ClassId, h.ConfigType, config, h.Height, h.TxHash, h.Timestamp.UTC())
+}
+
+// GetNftClassConfigHistory returns the class configs and royalty configs set on the class over time
+func GetNftClassConfigHistory(conn *pgxpool.Conn, q QueryNftClassConfigHistoryRequest, p PageRequest) (QueryNftClassConfigHistoryResponse, error) {
+	sql := fmt.Sprintf(`
+	SELECT id, class_id, config_type, config, height, tx_hash, timestamp
+	FROM nft_class_config_history
+	WHERE class_id = $4
+		AND ($1 = 0 OR id > $1)
+		AND ($2 = 0 OR id < $2)
+		AND ($5::text[] IS NULL OR cardinality($5::text[]) = 0 OR config_type = ANY($5))
+	ORDER BY id %s
+	LIMIT $3
+	`, p.Order())
+
+	ctx, cancel := GetTimeoutContext()
+	defer cancel()
+
+	rows, err := conn.Query(ctx, sql, p.After(), p.Before(), p.Limit, q.ClassId, q.ConfigType)
+	if err != nil {
+		logger.L.Errorw("Failed to query nft class config history", "error", err)
+		return QueryNftClassConfigHistoryResponse{}, fmt.Errorf("query nft class config history error: %w", err)
+	}
+	defer rows.Close()
+
+	res := QueryNftClassConfigHistoryResponse{
+		History: []NftClassConfigHistory{},
+	}
+	for rows.Next() {
+		var h NftClassConfigHistory
+		if err = rows.Scan(
+			&res.Pagination.NextKey, &h.ClassId, &h.ConfigType, &h.Config, &h.Height,
+			&h.TxHash, &h.Timestamp,
+		); err != nil {
+			logger.L.Errorw("failed to scan nft class config history", "error", err, "q", q)
+			return QueryNftClassConfigHistoryResponse{}, fmt.Errorf("query nft class config history data failed: %w", err)
+		}
+		res.History = append(res.History, h)
+	}
+	res.Pagination.Count = len(res.History)
+	return res, nil
+}
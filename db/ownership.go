@@ -0,0 +1,311 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"github.com/likecoin/likecoin-chain-tx-indexer/logger"
+	"github.com/likecoin/likecoin-chain-tx-indexer/utils"
+)
+
+var ErrOwnershipNotExtracted = errors.New("the ownership at the height or time is not extracted yet")
+
+// nftOwnershipAtSQL returns the sub-query of the owner of each NFT by replaying the events changing the ownership up to
+// the time in the parameter timeParam, for the events matching the condition. A mint event records the owner as the
+// sender, and the owner of a burned NFT is the last owner before the burn.
+func nftOwnershipAtSQL(timeParam int, condition string) string {
+	return fmt.Sprintf(`
+		SELECT DISTINCT ON (e.class_id, e.nft_id)
+			e.class_id, e.nft_id,
+			CASE WHEN e.action IN ('%[1]s', '%[2]s') THEN e.sender ELSE e.receiver END AS owner,
+			e.action = '%[1]s' AS burned,
+			e.timestamp
+		FROM nft_event AS e
+		WHERE e.action IN ('%[1]s', '%[2]s', '%[3]s', '%[4]s', '%[5]s')
+			AND e.timestamp <= $%[6]d
+			AND (%[7]s)
+		ORDER BY e.class_id, e.nft_id, e.timestamp DESC, e.id DESC
+	`, ACTION_BURN, ACTION_MINT, ACTION_SEND, ACTION_BUY, ACTION_SELL, timeParam, condition)
+}
+
+// OwnershipExtractors are the extractors writing the nft_event rows changing the ownership
+var OwnershipExtractors = []string{"nft", "marketplace"}
+
+// getOwnershipExtractedHeight returns the lowest checkpoint of OwnershipExtractors, or 0 if any of them has not run yet
+func getOwnershipExtractedHeight(conn *pgxpool.Conn) (int64, error) {
+	var extractedHeight int64
+	for i, name := range OwnershipExtractors {
+		height, err := GetMetaHeight(conn, ExtractorMetaKey(name))
+		if err == pgx.ErrNoRows {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		if i == 0 || height < extractedHeight {
+			extractedHeight = height
+		}
+	}
+	return extractedHeight, nil
+}
+
+func getTxTime(conn *pgxpool.Conn, sql string, height int64) (time.Time, error) {
+	ctx, cancel := GetTimeoutContext()
+	defer cancel()
+	var t time.Time
+	err := conn.QueryRow(ctx, sql, height).Scan(&t)
+	return t.UTC(), err
+}
+
+// GetOwnershipTime returns the time to replay the ownership changes up to for the height or time.
+// Since the nft_event rows have no height but share the block time as the timestamp, a height is resolved as the time
+// of the latest tx at or before the height.
+func GetOwnershipTime(conn *pgxpool.Conn, atHeight int64, atTime *time.Time) (time.Time, error) {
+	extractedHeight, err := getOwnershipExtractedHeight(conn)
+	if err != nil {
+		logger.L.Errorw("Failed to get extractor height", "error", err)
+		return time.Time{}, fmt.Errorf("failed to get extractor height: %w", err)
+	}
+	if atHeight > 0 {
+		if atHeight > extractedHeight {
+			return time.Time{}, fmt.Errorf("%w: height %d, extracted height %d", ErrOwnershipNotExtracted, atHeight, extractedHeight)
+		}
+		t, err := getTxTime(conn, `
+			SELECT tx -> 'timestamp' FROM txs
+			WHERE height <= $1
+			ORDER BY height DESC, tx_index DESC
+			LIMIT 1
+		`, atHeight)
+		if err == pgx.ErrNoRows {
+			// no tx yet, so no NFT either
+			return time.Unix(0, 0).UTC(), nil
+		}
+		if err != nil {
+			logger.L.Errorw("Failed to get tx time at height", "error", err, "height", atHeight)
+			return time.Time{}, fmt.Errorf("failed to get tx time at height: %w", err)
+		}
+		return t, nil
+	}
+	if atTime == nil {
+		return time.Time{}, fmt.Errorf("either height or time is required")
+	}
+	// the time is not extracted if there are txs not extracted yet at or before the time
+	t, err := getTxTime(conn, `
+		SELECT tx -> 'timestamp' FROM txs
+		WHERE height > $1
+		ORDER BY height, tx_index
+		LIMIT 1
+	`, extractedHeight)
+	if err != nil && err != pgx.ErrNoRows {
+		logger.L.Errorw("Failed to get time of the first tx not extracted", "error", err, "height", extractedHeight)
+		return time.Time{}, fmt.Errorf("failed to get time of the first tx not extracted: %w", err)
+	}
+	if err == nil && !atTime.Before(t) {
+		return time.Time{}, fmt.Errorf("%w: time %s, extracted until %s", ErrOwnershipNotExtracted, atTime.UTC().Format(time.RFC3339), t.Format(time.RFC3339))
+	}
+	return atTime.UTC(), nil
+}
+
+func getOwnersAt(conn *pgxpool.Conn, q QueryOwnerRequest) (QueryOwnerResponse, error) {
+	atTime, err := GetOwnershipTime(conn, q.AtHeight, q.AtTime)
+	if err != nil {
+		return QueryOwnerResponse{}, err
+	}
+	ignoreListVariations := utils.ConvertAddressArrayPrefixes(q.IgnoreList, AddressPrefixes)
+
+	sql := fmt.Sprintf(`
+	SELECT o.owner, array_agg(o.nft_id ORDER BY o.nft_id)
+	FROM (%s) AS o
+	JOIN nft_class AS c
+		ON o.class_id = c.class_id
+	JOIN iscn AS i
+		ON c.parent_iscn_id_prefix = i.iscn_id_prefix
+	JOIN iscn_latest_version
+		ON i.iscn_id_prefix = iscn_latest_version.iscn_id_prefix
+			AND i.version = iscn_latest_version.latest_version
+	WHERE ($2 = false OR o.owner != i.owner)
+		AND ($3::text[] IS NULL OR cardinality($3::text[]) = 0 OR o.owner != ALL($3))
+		AND ($4 = true OR o.burned = false)
+	GROUP BY o.owner
+	`, nftOwnershipAtSQL(5, "e.class_id = $1"))
+	ctx, cancel := GetTimeoutContext()
+	defer cancel()
+
+	rows, err := conn.Query(ctx, sql, q.ClassId, q.ExcludeIscnOwner, ignoreListVariations, q.IncludeBurned, atTime)
+	if err != nil {
+		logger.L.Errorw("Failed to query owner at time", "error", err, "q", q, "at_time", atTime)
+		return QueryOwnerResponse{}, fmt.Errorf("query owner at time error: %w", err)
+	}
+	defer rows.Close()
+
+	res := QueryOwnerResponse{
+		Owners: make([]OwnerResponse, 0),
+	}
+	for rows.Next() {
+		var owner OwnerResponse
+		if err = rows.Scan(&owner.Owner, &owner.Nfts); err != nil {
+			logger.L.Errorw("failed to scan owner at time", "error", err, "q", q)
+			return QueryOwnerResponse{}, fmt.Errorf("query owner at time data failed: %w", err)
+		}
+		owner.Count = len(owner.Nfts)
+		res.Owners = append(res.Owners, owner)
+	}
+	res.Pagination.Count = len(res.Owners)
+	return res, nil
+}
+
+func getNftsAt(conn *pgxpool.Conn, q QueryNftRequest, p PageRequest) (QueryNftResponse, error) {
+	atTime, err := GetOwnershipTime(conn, q.AtHeight, q.AtTime)
+	if err != nil {
+		return QueryNftResponse{}, err
+	}
+	ownerVariations := utils.ConvertAddressPrefixes(q.Owner, AddressPrefixes)
+	// only the NFTs ever minted to or received by the owner are replayed
+	ownership := nftOwnershipAtSQL(8, fmt.Sprintf(`
+		(e.class_id, e.nft_id) IN (
+			SELECT class_id, nft_id FROM nft_event
+			WHERE (receiver = ANY($4) OR (action = '%s' AND sender = ANY($4)))
+				AND timestamp <= $8
+		)
+	`, ACTION_MINT))
+	sql := fmt.Sprintf(`
+	SELECT
+		n.id, n.nft_id, n.class_id, o.owner, n.uri,
+		n.uri_hash, n.metadata, o.timestamp, c.name, c.description,
+		c.symbol, c.uri, c.uri_hash, c.config, c.metadata,
+		c.latest_price, c.parent_type, c.parent_iscn_id_prefix, c.parent_account, c.created_at,
+		c.price_updated_at, CASE WHEN o.burned THEN o.timestamp END, nrm.content, crm.content
+	FROM (%s) AS o
+	JOIN nft AS n
+		ON n.class_id = o.class_id AND n.nft_id = o.nft_id
+	JOIN nft_class as c
+		ON n.class_id = c.class_id
+	LEFT JOIN resolved_metadata AS nrm
+		ON $6 = true AND nrm.status = 'resolved'
+			AND nrm.uri = n.uri AND nrm.uri_hash = COALESCE(n.uri_hash, '')
+	LEFT JOIN resolved_metadata AS crm
+		ON $6 = true AND $7 = true AND crm.status = 'resolved'
+			AND crm.uri = c.uri AND crm.uri_hash = COALESCE(c.uri_hash, '')
+	WHERE o.owner = ANY($4)
+		AND ($5 = true OR o.burned = false)
+		AND ($1 = 0 OR n.id > $1)
+		AND ($2 = 0 OR n.id < $2)
+	ORDER BY n.id %s
+	LIMIT $3
+	`, ownership, p.Order())
+	ctx, cancel := GetTimeoutContext()
+	defer cancel()
+	rows, err := conn.Query(
+		ctx, sql,
+		p.After(), p.Before(), p.Limit, ownerVariations, q.IncludeBurned,
+		q.ExpandUriMetadata, q.ExpandClasses, atTime,
+	)
+	if err != nil {
+		logger.L.Errorw("Failed to query nft by owner at time", "error", err, "q", q, "at_time", atTime)
+		return QueryNftResponse{}, fmt.Errorf("query nft at time error: %w", err)
+	}
+	defer rows.Close()
+	res := QueryNftResponse{
+		Nfts: make([]NftResponse, 0),
+	}
+	for rows.Next() {
+		var n NftResponse
+		var c NftClass
+		if err = rows.Scan(
+			&res.Pagination.NextKey, &n.NftId, &n.ClassId, &n.Owner, &n.Uri,
+			&n.UriHash, &n.Metadata, &n.Timestamp, &c.Name, &c.Description,
+			&c.Symbol, &c.URI, &c.URIHash, &c.Config, &c.Metadata,
+			&c.LatestPrice, &n.ClassParent.Type, &n.ClassParent.IscnIdPrefix, &n.ClassParent.Account, &c.CreatedAt,
+			&c.PriceUpdatedAt, &n.BurnedAt, &n.UriMetadata, &c.UriMetadata,
+		); err != nil {
+			logger.L.Errorw("failed to scan nft at time", "error", err, "q", q)
+			return QueryNftResponse{}, fmt.Errorf("query nft at time failed: %w", err)
+		}
+		if q.ExpandClasses {
+			c.Parent = n.ClassParent
+			c.Id = n.ClassId
+			n.ClassData = &c
+		}
+		res.Nfts = append(res.Nfts, n)
+	}
+	res.Pagination.Count = len(res.Nfts)
+	return res, nil
+}
+
+func getClassesOwnersAt(conn *pgxpool.Conn, q QueryClassesOwnersRequest) (QueryClassesOwnersResponse, error) {
+	atTime, err := GetOwnershipTime(conn, q.AtHeight, q.AtTime)
+	if err != nil {
+		return QueryClassesOwnersResponse{}, err
+	}
+	ownersVariations := utils.ConvertAddressArrayPrefixes(q.Owners, AddressPrefixes)
+	sql := fmt.Sprintf(`
+		SELECT DISTINCT owner, class_id
+		FROM (%s) AS o
+		WHERE ($2::text[] IS NULL OR cardinality($2::text[]) = 0 OR owner = ANY($2))
+			AND ($3 = true OR burned = false)
+		ORDER BY owner, class_id
+	`, nftOwnershipAtSQL(4, "e.class_id = ANY($1)"))
+	ctx, cancel := GetTimeoutContext()
+	defer cancel()
+	rows, err := conn.Query(ctx, sql, q.ClassIds, ownersVariations, q.IncludeBurned, atTime)
+	if err != nil {
+		logger.L.Errorw("Failed to query nft classes owners at time", "error", err, "q", q, "at_time", atTime)
+		return QueryClassesOwnersResponse{}, fmt.Errorf("error on query nft classes owners at time: %w", err)
+	}
+	defer rows.Close()
+
+	classIds := make(map[string][]string)
+	for rows.Next() {
+		var owner string
+		var classId string
+		if err := rows.Scan(&owner, &classId); err != nil {
+			logger.L.Errorw("failed to scan owner address and class ID", "error", err)
+			return QueryClassesOwnersResponse{}, fmt.Errorf("failed to scan owner address and class ID: %w", err)
+		}
+		convertedOwner, err := utils.ConvertAddressPrefix(owner, MainAddressPrefix)
+		if err != nil {
+			logger.L.Errorw("failed to convert address prefix when processing QueryClassesOwnersRequest", "error", err, "owner", owner, "class_id", classId, "request", q)
+			// non-critical error, just skip this row
+			continue
+		}
+		classIds[convertedOwner] = append(classIds[convertedOwner], classId)
+	}
+	return QueryClassesOwnersResponse{Owners: classIds}, nil
+}
+
+// GetNftHoldersAt returns the holder of each NFT of the classes at the time, ordered by class and NFT ID
+func GetNftHoldersAt(conn *pgxpool.Conn, classIds []string, atTime time.Time, includeBurned bool) ([]NftHolder, error) {
+	sql := fmt.Sprintf(`
+	SELECT class_id, nft_id, owner, timestamp
+	FROM (%s) AS o
+	WHERE $2 = true OR burned = false
+	ORDER BY class_id, nft_id
+	`, nftOwnershipAtSQL(3, "e.class_id = ANY($1)"))
+	ctx, cancel := GetTimeoutContext()
+	defer cancel()
+	rows, err := conn.Query(ctx, sql, classIds, includeBurned, atTime.UTC())
+	if err != nil {
+		logger.L.Errorw("Failed to query nft holders at time", "error", err, "class_ids", classIds, "at_time", atTime)
+		return nil, fmt.Errorf("query nft holders at time error: %w", err)
+	}
+	defer rows.Close()
+
+	holders := []NftHolder{}
+	for rows.Next() {
+		var h NftHolder
+		if err = rows.Scan(&h.ClassId, &h.NftId, &h.Owner, &h.AcquiredAt); err != nil {
+			logger.L.Errorw("failed to scan nft holder", "error", err)
+			return nil, fmt.Errorf("query nft holders at time data failed: %w", err)
+		}
+		if owner, err := utils.ConvertAddressPrefix(h.Owner, MainAddressPrefix); err == nil {
+			h.Owner = owner
+		}
+		h.AcquiredAt = h.AcquiredAt.UTC()
+		holders = append(holders, h)
+	}
+	return holders, rows.Err()
+}
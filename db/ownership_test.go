@@ -0,0 +1,186 @@
+package db_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	. "github.com/likecoin/likecoin-chain-tx-indexer/db"
+	. "github.com/likecoin/likecoin-chain-tx-indexer/test"
+)
+
+func TestOwnershipAt(t *testing.T) {
+	defer CleanupTestData(Conn)
+	prefixA := "iscn://testing/aaaaaa"
+	iscns := []IscnInsert{
+		{
+			Iscn:       prefixA + "/1",
+			IscnPrefix: prefixA,
+			Owner:      ADDR_10_LIKE,
+		},
+	}
+	nftClasses := []NftClass{
+		{
+			Id:     "nftlike1aaaaa1",
+			Parent: NftClassParent{IscnIdPrefix: prefixA},
+		},
+	}
+	burnedAt := time.Unix(1700000400, 0).UTC()
+	nfts := []Nft{
+		{
+			NftId:    "testing-nft-1",
+			ClassId:  nftClasses[0].Id,
+			Owner:    ADDR_02_LIKE,
+			BurnedAt: &burnedAt,
+		},
+		{
+			NftId:   "testing-nft-2",
+			ClassId: nftClasses[0].Id,
+			Owner:   ADDR_03_LIKE,
+		},
+	}
+	blockTimes := map[int64]time.Time{}
+	txs := []string{}
+	for _, height := range []int64{100, 200, 300, 400, 500} {
+		blockTimes[height] = time.Unix(1700000000+height, 0).UTC()
+		txs = append(txs, fmt.Sprintf(
+			`{"txhash":"TX%[1]d","height":"%[1]d","timestamp":"%[2]s"}`,
+			height, blockTimes[height].Format(time.RFC3339),
+		))
+	}
+	nftEvents := []NftEvent{
+		{
+			Action:    ACTION_MINT,
+			ClassId:   nftClasses[0].Id,
+			NftId:     nfts[0].NftId,
+			Sender:    ADDR_01_LIKE,
+			TxHash:    "TX100",
+			Timestamp: blockTimes[100],
+		},
+		{
+			Action:    ACTION_MINT,
+			ClassId:   nftClasses[0].Id,
+			NftId:     nfts[1].NftId,
+			Sender:    ADDR_01_LIKE,
+			TxHash:    "TX100",
+			Timestamp: blockTimes[100],
+		},
+		{
+			Action:    ACTION_SEND,
+			ClassId:   nftClasses[0].Id,
+			NftId:     nfts[0].NftId,
+			Sender:    ADDR_01_LIKE,
+			Receiver:  ADDR_02_LIKE,
+			TxHash:    "TX200",
+			Timestamp: blockTimes[200],
+		},
+		{
+			Action:    ACTION_BUY,
+			ClassId:   nftClasses[0].Id,
+			NftId:     nfts[1].NftId,
+			Sender:    ADDR_01_LIKE,
+			Receiver:  ADDR_03_LIKE,
+			TxHash:    "TX300",
+			Timestamp: blockTimes[300],
+		},
+		{
+			Action:    ACTION_BURN,
+			ClassId:   nftClasses[0].Id,
+			NftId:     nfts[0].NftId,
+			Sender:    ADDR_02_LIKE,
+			TxHash:    "TX400",
+			Timestamp: blockTimes[400],
+		},
+	}
+	InsertTestData(DBTestData{
+		Iscns:            iscns,
+		NftClasses:       nftClasses,
+		Nfts:             nfts,
+		NftEvents:        nftEvents,
+		Txs:              txs,
+		ExtractorHeights: map[string]int64{"nft": 400, "marketplace": 400},
+	})
+
+	ownersAt := func(q QueryOwnerRequest) map[string][]string {
+		q.ClassId = nftClasses[0].Id
+		res, err := GetOwners(Conn, q)
+		require.NoError(t, err)
+		owners := map[string][]string{}
+		for _, o := range res.Owners {
+			require.Equal(t, len(o.Nfts), o.Count)
+			owners[o.Owner] = o.Nfts
+		}
+		return owners
+	}
+	require.Equal(t, map[string][]string{}, ownersAt(QueryOwnerRequest{AtHeight: 50}))
+	require.Equal(t, map[string][]string{ADDR_01_LIKE: {nfts[0].NftId, nfts[1].NftId}}, ownersAt(QueryOwnerRequest{AtHeight: 100}))
+	// no tx between 100 and 200
+	require.Equal(t, map[string][]string{ADDR_01_LIKE: {nfts[0].NftId, nfts[1].NftId}}, ownersAt(QueryOwnerRequest{AtHeight: 199}))
+	require.Equal(t, map[string][]string{
+		ADDR_01_LIKE: {nfts[1].NftId},
+		ADDR_02_LIKE: {nfts[0].NftId},
+	}, ownersAt(QueryOwnerRequest{AtHeight: 200}))
+	require.Equal(t, map[string][]string{
+		ADDR_02_LIKE: {nfts[0].NftId},
+		ADDR_03_LIKE: {nfts[1].NftId},
+	}, ownersAt(QueryOwnerRequest{AtHeight: 300}))
+	atTime := blockTimes[400]
+	require.Equal(t, map[string][]string{ADDR_03_LIKE: {nfts[1].NftId}}, ownersAt(QueryOwnerRequest{AtTime: &atTime}))
+	require.Equal(t, map[string][]string{
+		ADDR_02_LIKE: {nfts[0].NftId},
+		ADDR_03_LIKE: {nfts[1].NftId},
+	}, ownersAt(QueryOwnerRequest{AtTime: &atTime, IncludeBurned: true}))
+	require.Equal(t, map[string][]string{ADDR_02_LIKE: {nfts[0].NftId}}, ownersAt(QueryOwnerRequest{AtHeight: 300, IgnoreList: []string{ADDR_03_LIKE}}))
+
+	_, err := GetOwners(Conn, QueryOwnerRequest{ClassId: nftClasses[0].Id, AtHeight: 500})
+	require.ErrorIs(t, err, ErrOwnershipNotExtracted)
+	atTime = blockTimes[500]
+	_, err = GetOwners(Conn, QueryOwnerRequest{ClassId: nftClasses[0].Id, AtTime: &atTime})
+	require.ErrorIs(t, err, ErrOwnershipNotExtracted)
+	atTime = blockTimes[500].Add(-1 * time.Second)
+	require.Equal(t, map[string][]string{ADDR_03_LIKE: {nfts[1].NftId}}, ownersAt(QueryOwnerRequest{AtTime: &atTime}))
+
+	nftRes, err := GetNfts(Conn, QueryNftRequest{Owner: ADDR_01_LIKE, AtHeight: 100}, PageRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, nftRes.Nfts, 2)
+	for _, n := range nftRes.Nfts {
+		require.Equal(t, ADDR_01_LIKE, n.Owner)
+		require.Equal(t, blockTimes[100], n.Timestamp.UTC())
+		require.Nil(t, n.BurnedAt)
+	}
+
+	nftRes, err = GetNfts(Conn, QueryNftRequest{Owner: ADDR_02_LIKE, AtHeight: 300}, PageRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, nftRes.Nfts, 1)
+	require.Equal(t, nfts[0].NftId, nftRes.Nfts[0].NftId)
+	require.Nil(t, nftRes.Nfts[0].BurnedAt)
+
+	nftRes, err = GetNfts(Conn, QueryNftRequest{Owner: ADDR_02_LIKE, AtHeight: 400}, PageRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Empty(t, nftRes.Nfts)
+
+	nftRes, err = GetNfts(Conn, QueryNftRequest{Owner: ADDR_02_LIKE, AtHeight: 400, IncludeBurned: true}, PageRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, nftRes.Nfts, 1)
+	require.NotNil(t, nftRes.Nfts[0].BurnedAt)
+	require.Equal(t, blockTimes[400], nftRes.Nfts[0].BurnedAt.UTC())
+
+	classesOwnersRes, err := GetClassesOwners(Conn, QueryClassesOwnersRequest{
+		ClassIds: []string{nftClasses[0].Id},
+		AtHeight: 200,
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string][]string{
+		ADDR_01_LIKE: {nftClasses[0].Id},
+		ADDR_02_LIKE: {nftClasses[0].Id},
+	}, classesOwnersRes.Owners)
+
+	holders, err := GetNftHoldersAt(Conn, []string{nftClasses[0].Id}, blockTimes[200], false)
+	require.NoError(t, err)
+	require.Equal(t, []NftHolder{
+		{ClassId: nftClasses[0].Id, NftId: nfts[0].NftId, Owner: ADDR_02_LIKE, AcquiredAt: blockTimes[200]},
+		{ClassId: nftClasses[0].Id, NftId: nfts[1].NftId, Owner: ADDR_01_LIKE, AcquiredAt: blockTimes[100]},
+	}, holders)
+}
@@ -0,0 +1,424 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"github.com/likecoin/likecoin-chain-tx-indexer/logger"
+)
+
+// schema of the shadow tables during reindexing
+const reindexSchema = "reindex"
+
+// some randomly generated number, held during an extraction round, so reindexing does not interleave with it
+const extractorLockKey = 7053626453478190233
+
+// txKeyedTables are the tables which can be rewound to a height, since each row records the tx it is extracted from
+var txKeyedTables = map[string]bool{
+	"iscn_event":              true,
+	"nft_event":               true,
+	"nft_income":              true,
+	"token_transfer":          true,
+	"token_balance_change":    true,
+	"staking_event":           true,
+	"gov_proposal":            true,
+	"gov_deposit":             true,
+	"gov_vote":                true,
+	"nft_marketplace_history": true,
+}
+
+// heightKeyedTables are the tables recorded per block by the extractors, which are rewound along with the tx keyed
+// tables by the height of the rows
+var heightKeyedTables = map[string]bool{
+	"nft_marketplace_floor_price": true,
+}
+
+// TxKeyedTables returns the tables which can be rewound to a height in alphabetical order
+func TxKeyedTables() []string {
+	tables := []string{}
+	for table := range txKeyedTables {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	return tables
+}
+
+func lockExtractors(conn *pgxpool.Conn) error {
+	_, err := conn.Exec(context.Background(), `SELECT pg_advisory_lock($1)`, extractorLockKey)
+	if err != nil {
+		return fmt.Errorf("failed to lock extractors: %w", err)
+	}
+	return nil
+}
+
+func unlockExtractors(conn *pgxpool.Conn) {
+	_, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, extractorLockKey)
+	if err != nil {
+		logger.L.Errorw("Failed to unlock extractors", "error", err)
+	}
+}
+
+// ExtractorsOfTables returns the extractors writing any of the tables, and all the tables written by them in the order
+// of declaration. Since an extractor cannot be replayed for only some of its tables, the extractors sharing tables
+// with the returned ones are also included.
+func ExtractorsOfTables(extractors []NamedExtractor, tables []string) ([]NamedExtractor, []string, error) {
+	known := map[string]bool{}
+	for _, e := range extractors {
+		for _, table := range e.Tables {
+			known[table] = true
+		}
+	}
+	selectedTables := map[string]bool{}
+	for _, table := range tables {
+		if !known[table] {
+			return nil, nil, fmt.Errorf("table %s is not written by any extractor", table)
+		}
+		selectedTables[table] = true
+	}
+	selected := make([]bool, len(extractors))
+	for changed := true; changed; {
+		changed = false
+		for i, e := range extractors {
+			if selected[i] {
+				continue
+			}
+			for _, table := range e.Tables {
+				if selectedTables[table] {
+					selected[i] = true
+					changed = true
+					break
+				}
+			}
+			if selected[i] {
+				for _, table := range e.Tables {
+					selectedTables[table] = true
+				}
+			}
+		}
+	}
+	resExtractors := []NamedExtractor{}
+	resTables := []string{}
+	added := map[string]bool{}
+	for i, e := range extractors {
+		if !selected[i] {
+			continue
+		}
+		resExtractors = append(resExtractors, e)
+		for _, table := range e.Tables {
+			if !added[table] {
+				added[table] = true
+				resTables = append(resTables, table)
+			}
+		}
+	}
+	return resExtractors, resTables, nil
+}
+
+// replayExtractors runs each extractor from progress[i] up to goals[i], with the extractors at the same progress in
+// the same pass like ExtractNamed. If checkpoint is set, the checkpoints in `meta` are moved along in the same batches,
+// where META_EXTRACTOR is the lowest checkpoint of the extractors, otherwise they are not touched.
+// Since the txs were published to pubsub when first extracted, nothing is published during the replay.
+func replayExtractors(conn *pgxpool.Conn, extractors []NamedExtractor, progress []int64, goals []int64, checkpoint bool) error {
+	keys := []string{}
+	for _, e := range extractors {
+		keys = append(keys, ExtractorMetaKey(e.Name))
+	}
+	for {
+		prevSyncedHeight := int64(-1)
+		for i := range extractors {
+			if progress[i] < goals[i] && (prevSyncedHeight < 0 || progress[i] < prevSyncedHeight) {
+				prevSyncedHeight = progress[i]
+			}
+		}
+		if prevSyncedHeight < 0 {
+			return nil
+		}
+		latestSyncingHeight := prevSyncedHeight + LIMIT
+		lagging := []NamedExtractor{}
+		laggingIndexes := []int{}
+		for i := range extractors {
+			if progress[i] >= goals[i] {
+				continue
+			}
+			if progress[i] == prevSyncedHeight {
+				lagging = append(lagging, extractors[i])
+				laggingIndexes = append(laggingIndexes, i)
+				if goals[i] < latestSyncingHeight {
+					latestSyncingHeight = goals[i]
+				}
+			} else if progress[i] < latestSyncingHeight {
+				latestSyncingHeight = progress[i]
+			}
+		}
+		batch := NewBatch(conn, int(LIMIT))
+		batch.silent = true
+		err := extractTxs(conn, &batch, lagging, "height > $1 AND height <= $2", prevSyncedHeight, latestSyncingHeight)
+		if err != nil {
+			return err
+		}
+		if checkpoint {
+			for _, e := range lagging {
+				batch.UpdateMetaHeight(ExtractorMetaKey(e.Name), latestSyncingHeight)
+			}
+			batch.Batch.Queue(`UPDATE meta SET height = (SELECT MIN(height) FROM meta WHERE id = ANY($2)) WHERE id = $1`, META_EXTRACTOR, keys)
+		}
+		err = batch.Flush()
+		if err != nil {
+			return fmt.Errorf("send batch failed: %w", err)
+		}
+		runAfterRound(conn, lagging, latestSyncingHeight, true)
+		for _, i := range laggingIndexes {
+			progress[i] = latestSyncingHeight
+		}
+		logger.L.Infow("Replayed height", "height", latestSyncingHeight)
+	}
+}
+
+// Reindex rebuilds the tables written by the extractors by replaying them over `txs` from the beginning.
+// The tables are rebuilt as shadow tables in a separate schema, while the original tables keep serving. Then the
+// extractors catch up to their latest checkpoints, and the original tables are replaced by the rebuilt rows in one
+// transaction, so readers see either the old or the new data.
+// AfterRound of the extractors also runs on the shadow tables after each round of the replay, e.g. so the marketplace
+// items expired are not copied back as active.
+func Reindex(conn *pgxpool.Conn, extractors []NamedExtractor, tables []string) (err error) {
+	ctx := context.Background()
+	var startTime time.Time
+	err = conn.QueryRow(ctx, `SELECT NOW()`).Scan(&startTime)
+	if err != nil {
+		return fmt.Errorf("failed to get database time: %w", err)
+	}
+	_, err = conn.Exec(ctx, fmt.Sprintf(`DROP SCHEMA IF EXISTS %[1]s CASCADE; CREATE SCHEMA %[1]s`, reindexSchema))
+	if err != nil {
+		return fmt.Errorf("failed to create schema for shadow tables: %w", err)
+	}
+	defer func() {
+		_, dropErr := conn.Exec(ctx, fmt.Sprintf(`DROP SCHEMA IF EXISTS %s CASCADE`, reindexSchema))
+		if dropErr != nil {
+			logger.L.Errorw("Failed to drop shadow tables", "error", dropErr)
+		}
+	}()
+	for _, table := range tables {
+		// indexes are included for the lookups and the unique constraints used by the extractors
+		_, err = conn.Exec(ctx, fmt.Sprintf(
+			`CREATE TABLE %[1]s.%[2]s (LIKE public.%[2]s INCLUDING DEFAULTS INCLUDING CONSTRAINTS INCLUDING INDEXES)`,
+			reindexSchema, table,
+		))
+		if err != nil {
+			return fmt.Errorf("failed to create shadow table %s: %w", table, err)
+		}
+	}
+	// unqualified table names in the extractors now resolve to the shadow tables, and to the public ones for the rest
+	_, err = conn.Exec(ctx, fmt.Sprintf(`SET search_path TO %s, public`, reindexSchema))
+	if err != nil {
+		return fmt.Errorf("failed to set search path: %w", err)
+	}
+	defer func() {
+		_, resetErr := conn.Exec(ctx, `RESET search_path`)
+		if resetErr != nil {
+			logger.L.Errorw("Failed to reset search path", "error", resetErr)
+		}
+	}()
+
+	goals, err := getExtractorHeights(conn, extractors)
+	if err != nil {
+		return err
+	}
+	progress := make([]int64, len(extractors))
+	err = replayExtractors(conn, extractors, progress, goals, false)
+	if err != nil {
+		return err
+	}
+
+	// no extraction round runs from now on, so the checkpoints are final
+	err = lockExtractors(conn)
+	if err != nil {
+		return err
+	}
+	defer unlockExtractors(conn)
+	goals, err = getExtractorHeights(conn, extractors)
+	if err != nil {
+		return err
+	}
+	err = replayExtractors(conn, extractors, progress, goals, false)
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Exec(ctx, `BEGIN`)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_, _ = conn.Exec(ctx, `ROLLBACK`)
+		}
+	}()
+	// delete in reverse order for the foreign keys (e.g. iscn_stakeholders referencing iscn)
+	for i := len(tables) - 1; i >= 0; i-- {
+		_, err = conn.Exec(ctx, fmt.Sprintf(`DELETE FROM public.%s`, tables[i]))
+		if err != nil {
+			return fmt.Errorf("failed to clear table %s: %w", tables[i], err)
+		}
+	}
+	for _, table := range tables {
+		_, err = conn.Exec(ctx, fmt.Sprintf(`INSERT INTO public.%[2]s SELECT * FROM %[1]s.%[2]s`, reindexSchema, table))
+		if err != nil {
+			return fmt.Errorf("failed to copy shadow table %s: %w", table, err)
+		}
+	}
+	// the failures which still exist are recorded again during the replay
+	err = resolveExtractionFailuresBefore(conn, extractors, startTime)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Exec(ctx, `COMMIT`)
+	if err != nil {
+		return fmt.Errorf("failed to commit reindexed tables: %w", err)
+	}
+	logger.L.Infow("Reindex finished", "tables", strings.Join(tables, ","))
+	return nil
+}
+
+// deleteExtractedRowsSQL returns the SQL deleting the rows of the tx keyed table extracted from the txs matching the
+// condition
+func deleteExtractedRowsSQL(table string, condition string) string {
+	return fmt.Sprintf(`
+		DELETE FROM %s
+		WHERE tx_hash IN (SELECT tx ->> 'txhash' FROM txs WHERE %s)
+	`, table, condition)
+}
+
+// Rewind deletes the rows extracted from the txs after fromHeight in the tx keyed tables written by the extractors,
+// and moves the checkpoints of the extractors back to fromHeight, so the running extractor replays them.
+// Only tx keyed tables can be rewound; the other tables written by the extractors are updated by the replay as usual,
+// which does not fix the rows extracted wrongly.
+func Rewind(conn *pgxpool.Conn, extractors []NamedExtractor, tables []string, fromHeight int64) error {
+	for _, table := range tables {
+		if !txKeyedTables[table] {
+			return fmt.Errorf("table %s cannot be rewound to a height, reindex it from the beginning instead", table)
+		}
+	}
+	extractors, _, err := ExtractorsOfTables(extractors, tables)
+	if err != nil {
+		return err
+	}
+	err = lockExtractors(conn)
+	if err != nil {
+		return err
+	}
+	defer unlockExtractors(conn)
+	return rewind(conn, extractors, fromHeight)
+}
+
+// rewind is Rewind with the extractors locked, where the extractors must include all the extractors sharing tables
+// with them
+func rewind(conn *pgxpool.Conn, extractors []NamedExtractor, fromHeight int64) (err error) {
+	tables := []string{}
+	added := map[string]bool{}
+	names := []string{}
+	for _, e := range extractors {
+		for _, table := range e.Tables {
+			if !added[table] {
+				added[table] = true
+				tables = append(tables, table)
+			}
+		}
+		names = append(names, e.Name)
+	}
+
+	ctx := context.Background()
+	_, err = conn.Exec(ctx, `BEGIN`)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_, _ = conn.Exec(ctx, `ROLLBACK`)
+		}
+	}()
+	// all the tx keyed tables written by the extractors are rewound, otherwise the replay duplicates their rows
+	for _, table := range tables {
+		sql := ""
+		if txKeyedTables[table] {
+			sql = deleteExtractedRowsSQL(table, "height > $1")
+		} else if heightKeyedTables[table] {
+			sql = fmt.Sprintf(`DELETE FROM %s WHERE height > $1`, table)
+		} else {
+			continue
+		}
+		_, err = conn.Exec(ctx, sql, fromHeight)
+		if err != nil {
+			return fmt.Errorf("failed to rewind table %s: %w", table, err)
+		}
+	}
+	for _, e := range extractors {
+		_, err = conn.Exec(ctx, `UPDATE meta SET height = LEAST(height, $2) WHERE id = $1`, ExtractorMetaKey(e.Name), fromHeight)
+		if err != nil {
+			return fmt.Errorf("failed to rewind extractor %s: %w", e.Name, err)
+		}
+	}
+	_, err = conn.Exec(ctx, `UPDATE meta SET height = LEAST(height, $2) WHERE id = $1`, META_EXTRACTOR, fromHeight)
+	if err != nil {
+		return fmt.Errorf("failed to rewind extractor height: %w", err)
+	}
+	// the failures which still exist are recorded again during the replay
+	_, err = conn.Exec(ctx, `
+		UPDATE extraction_failures SET resolved_at = NOW()
+		WHERE extractor = ANY($1) AND height > $2 AND resolved_at IS NULL
+	`, names, fromHeight)
+	if err != nil {
+		return fmt.Errorf("failed to resolve rewound extraction failures: %w", err)
+	}
+	_, err = conn.Exec(ctx, `COMMIT`)
+	if err != nil {
+		return fmt.Errorf("failed to commit rewinding: %w", err)
+	}
+	logger.L.Infow("Rewind finished", "tables", strings.Join(tables, ","), "from_height", fromHeight)
+	return nil
+}
+
+// rewindAndReplay rewinds the selected extractors to fromHeight like Rewind, and replays them forward up to their
+// checkpoints right away, so the txs after fromHeight are extracted again in order, on top of the state left by the
+// txs before. The checkpoints are moved along with the replay, so the running extractor continues the replay if it is
+// interrupted. The selected extractors must include all the extractors sharing tables with them, and all the
+// extractors must be locked.
+func rewindAndReplay(conn *pgxpool.Conn, extractors []NamedExtractor, selected []NamedExtractor, fromHeight int64) error {
+	goals, err := getExtractorHeights(conn, extractors)
+	if err != nil {
+		return err
+	}
+	rewound := []NamedExtractor{}
+	progress := make([]int64, len(extractors))
+	for i, e := range extractors {
+		progress[i] = goals[i]
+		// the extractors not reaching fromHeight yet extract the txs in the normal course
+		if goals[i] > fromHeight && containsExtractor(selected, e.Name) {
+			rewound = append(rewound, e)
+			progress[i] = fromHeight
+		}
+	}
+	if len(rewound) == 0 {
+		return nil
+	}
+	err = rewind(conn, rewound, fromHeight)
+	if err != nil {
+		return err
+	}
+	return replayExtractors(conn, extractors, progress, goals, true)
+}
+
+// Reextract extracts the txs after fromHeight again, e.g. after they are backfilled, by rewinding the extractors
+// which have extracted them to fromHeight and replaying them forward up to their checkpoints.
+func Reextract(conn *pgxpool.Conn, extractors []NamedExtractor, fromHeight int64) error {
+	err := lockExtractors(conn)
+	if err != nil {
+		return err
+	}
+	defer unlockExtractors(conn)
+	return rewindAndReplay(conn, extractors, extractors, fromHeight)
+}
@@ -0,0 +1,120 @@
+package db_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/likecoin/likecoin-chain-tx-indexer/db"
+	. "github.com/likecoin/likecoin-chain-tx-indexer/test"
+)
+
+func TestExtractorsOfTables(t *testing.T) {
+	extractors := []NamedExtractor{
+		{Name: "a", Tables: []string{"iscn", "iscn_stakeholders"}},
+		{Name: "b", Tables: []string{"nft_class", "nft_event"}},
+		{Name: "c", Tables: []string{"nft_event", "nft_marketplace"}},
+	}
+	selected, tables, err := ExtractorsOfTables(extractors, []string{"nft_marketplace"})
+	require.NoError(t, err)
+	require.Len(t, selected, 2)
+	require.Equal(t, "b", selected[0].Name)
+	require.Equal(t, "c", selected[1].Name)
+	require.Equal(t, []string{"nft_class", "nft_event", "nft_marketplace"}, tables)
+
+	selected, tables, err = ExtractorsOfTables(extractors, []string{"iscn_stakeholders"})
+	require.NoError(t, err)
+	require.Len(t, selected, 1)
+	require.Equal(t, []string{"iscn", "iscn_stakeholders"}, tables)
+
+	_, _, err = ExtractorsOfTables(extractors, []string{"txs"})
+	require.Error(t, err)
+}
+
+func TestReindex(t *testing.T) {
+	defer CleanupTestData(Conn)
+	limit := LIMIT
+	LIMIT = 2
+	defer func() { LIMIT = limit }()
+
+	txs := []string{}
+	for height := 1; height <= 5; height++ {
+		txs = append(txs, fmt.Sprintf(
+			`{"height":"%[1]d","txhash":"TX%[1]d","tx":{"body":{"messages":[],"memo":"memo-%[1]d"}},"logs":[],"timestamp":"2022-01-01T00:00:00Z"}`,
+			height,
+		))
+	}
+	InsertTestData(DBTestData{Txs: txs, LatestBlockHeight: 5})
+
+	extractors := []NamedExtractor{{
+		Name: "test_reindex",
+		Extractor: func(ctx EventContext) error {
+			ctx.Batch.Batch.Queue(
+				`INSERT INTO nft_income (class_id, nft_id, tx_hash, address, amount) VALUES ('class', 'nft', $1, 'address', 1)`,
+				ctx.TxHash,
+			)
+			return nil
+		},
+		Tables: []string{"nft_income"},
+	}}
+	rounds := []string{}
+	extractors[0].AfterRound = func(conn *pgxpool.Conn, height int64, silent bool) error {
+		rounds = append(rounds, fmt.Sprintf("%d:%t", height, silent))
+		return nil
+	}
+	getIncomeTxs := func() []string {
+		rows, err := Conn.Query(context.Background(), `SELECT tx_hash FROM nft_income ORDER BY tx_hash`)
+		require.NoError(t, err)
+		defer rows.Close()
+		txHashes := []string{}
+		for rows.Next() {
+			var txHash string
+			require.NoError(t, rows.Scan(&txHash))
+			txHashes = append(txHashes, txHash)
+		}
+		require.NoError(t, rows.Err())
+		return txHashes
+	}
+
+	// the extractor has extracted up to height 4 with a bug
+	_, err := Conn.Exec(context.Background(), `INSERT INTO meta (id, height) VALUES ($1, 4)`, ExtractorMetaKey("test_reindex"))
+	require.NoError(t, err)
+	_, err = Conn.Exec(context.Background(), `
+		INSERT INTO nft_income (class_id, nft_id, tx_hash, address, amount)
+		VALUES ('class', 'nft', 'TX1', 'wrong', 1), ('class', 'nft', 'TX4', 'wrong', 1)
+	`)
+	require.NoError(t, err)
+
+	err = Reindex(Conn, extractors, []string{"nft_income"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"TX1", "TX2", "TX3", "TX4"}, getIncomeTxs())
+	var schemaCount int
+	err = Conn.QueryRow(context.Background(), `SELECT count(*) FROM information_schema.schemata WHERE schema_name = 'reindex'`).Scan(&schemaCount)
+	require.NoError(t, err)
+	require.Zero(t, schemaCount)
+	height, err := GetMetaHeight(Conn, ExtractorMetaKey("test_reindex"))
+	require.NoError(t, err)
+	require.Equal(t, int64(4), height)
+	// the replay runs AfterRound without publishing
+	require.Equal(t, []string{"2:true", "4:true"}, rounds)
+
+	err = Rewind(Conn, extractors, []string{"nft_income"}, 2)
+	require.NoError(t, err)
+	require.Equal(t, []string{"TX1", "TX2"}, getIncomeTxs())
+	height, err = GetMetaHeight(Conn, ExtractorMetaKey("test_reindex"))
+	require.NoError(t, err)
+	require.Equal(t, int64(2), height)
+
+	for finished := false; !finished; {
+		finished, err = ExtractNamed(Conn, extractors)
+		require.NoError(t, err)
+	}
+	require.Equal(t, []string{"TX1", "TX2", "TX3", "TX4", "TX5"}, getIncomeTxs())
+	require.Equal(t, []string{"2:true", "4:true", "4:false", "5:false"}, rounds)
+
+	err = Rewind(Conn, extractors, []string{"nft_class"}, 2)
+	require.Error(t, err)
+}
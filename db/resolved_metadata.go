@@ -0,0 +1,112 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/likecoin/likecoin-chain-tx-indexer/logger"
+)
+
+// EnqueueResolveMetadata queues the uri for the metadata resolver, which is a no-op if the uri is already queued or resolved
+func (batch *Batch) EnqueueResolveMetadata(uri, uriHash string) {
+	if uri == "" {
+		return
+	}
+	batch.Batch.Queue(`
+	INSERT INTO resolved_metadata (uri, uri_hash, next_attempt_at)
+	VALUES ($1, $2, NOW() AT TIME ZONE 'UTC')
+	ON CONFLICT DO NOTHING
+	`, uri, uriHash)
+}
+
+// GetDueResolvedMetadata returns the uris which should be fetched at the given time, earliest first
+func GetDueResolvedMetadata(conn *pgxpool.Conn, now time.Time, limit int) ([]ResolvedMetadata, error) {
+	sql := `
+	SELECT uri, uri_hash, status, hash_verified, error, attempts, fetched_at, next_attempt_at
+	FROM resolved_metadata
+	WHERE next_attempt_at IS NOT NULL AND next_attempt_at <= $1
+	ORDER BY next_attempt_at
+	LIMIT $2
+	`
+	ctx, cancel := GetTimeoutContext()
+	defer cancel()
+
+	rows, err := conn.Query(ctx, sql, now.UTC(), limit)
+	if err != nil {
+		logger.L.Errorw("Failed to query due resolved metadata", "error", err)
+		return nil, fmt.Errorf("query due resolved metadata error: %w", err)
+	}
+	defer rows.Close()
+
+	res := []ResolvedMetadata{}
+	for rows.Next() {
+		var m ResolvedMetadata
+		if err = rows.Scan(
+			&m.Uri, &m.UriHash, &m.Status, &m.HashVerified, &m.Error,
+			&m.Attempts, &m.FetchedAt, &m.NextAttemptAt,
+		); err != nil {
+			logger.L.Errorw("failed to scan resolved metadata", "error", err)
+			return nil, fmt.Errorf("query resolved metadata data failed: %w", err)
+		}
+		res = append(res, m)
+	}
+	return res, nil
+}
+
+// GetResolvedMetadata returns the cached metadata of the uri, or nil if the uri is not queued
+func GetResolvedMetadata(conn *pgxpool.Conn, uri, uriHash string) (*ResolvedMetadata, error) {
+	sql := `
+	SELECT uri, uri_hash, status, content, hash_verified, error, attempts, fetched_at, next_attempt_at
+	FROM resolved_metadata
+	WHERE uri = $1 AND uri_hash = $2
+	`
+	ctx, cancel := GetTimeoutContext()
+	defer cancel()
+
+	rows, err := conn.Query(ctx, sql, uri, uriHash)
+	if err != nil {
+		logger.L.Errorw("Failed to query resolved metadata", "error", err, "uri", uri)
+		return nil, fmt.Errorf("query resolved metadata error: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	var m ResolvedMetadata
+	if err = rows.Scan(
+		&m.Uri, &m.UriHash, &m.Status, &m.Content, &m.HashVerified,
+		&m.Error, &m.Attempts, &m.FetchedAt, &m.NextAttemptAt,
+	); err != nil {
+		logger.L.Errorw("failed to scan resolved metadata", "error", err, "uri", uri)
+		return nil, fmt.Errorf("query resolved metadata data failed: %w", err)
+	}
+	return &m, nil
+}
+
+// UpdateResolvedMetadata saves the result of a fetch attempt
+func UpdateResolvedMetadata(conn *pgxpool.Conn, m ResolvedMetadata) error {
+	var content interface{}
+	if len(m.Content) > 0 {
+		content = m.Content
+	}
+	sql := `
+	UPDATE resolved_metadata
+	SET status = $3, content = $4, hash_verified = $5, error = $6,
+		attempts = $7, fetched_at = $8, next_attempt_at = $9
+	WHERE uri = $1 AND uri_hash = $2
+	`
+	ctx, cancel := GetTimeoutContext()
+	defer cancel()
+
+	_, err := conn.Exec(ctx, sql,
+		m.Uri, m.UriHash, m.Status, content, m.HashVerified,
+		m.Error, m.Attempts, m.FetchedAt, m.NextAttemptAt,
+	)
+	if err != nil {
+		logger.L.Errorw("Failed to update resolved metadata", "error", err, "uri", m.Uri)
+		return fmt.Errorf("update resolved metadata error: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS blocks (
+  height BIGINT PRIMARY KEY,
+  hash TEXT NOT NULL,
+  time TIMESTAMP NOT NULL,
+  proposer TEXT NOT NULL,
+  num_txs INT NOT NULL,
+  app_hash TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_blocks_time ON blocks (time);
@@ -0,0 +1,5 @@
+-- the existing extractors continue from the global extractor height
+INSERT INTO meta (id, height)
+SELECT id, COALESCE((SELECT height FROM meta WHERE id = 'extractor_v1'), 0)
+FROM (VALUES ('extractor_iscn'), ('extractor_nft'), ('extractor_marketplace')) AS t(id)
+ON CONFLICT DO NOTHING;
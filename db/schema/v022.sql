@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS extraction_failures (
+  id BIGSERIAL PRIMARY KEY,
+  tx_hash TEXT NOT NULL,
+  height BIGINT NOT NULL,
+  extractor TEXT NOT NULL,
+  message_index INT,
+  event_type TEXT NOT NULL DEFAULT '',
+  processor TEXT NOT NULL DEFAULT '',
+  error TEXT NOT NULL,
+  attempts INT NOT NULL DEFAULT 1,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  resolved_at TIMESTAMPTZ,
+  UNIQUE (tx_hash, extractor)
+);
+
+CREATE INDEX IF NOT EXISTS idx_extraction_failures_outstanding ON extraction_failures (height) WHERE resolved_at IS NULL;
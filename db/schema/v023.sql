@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS token_transfer (
+  id BIGSERIAL PRIMARY KEY,
+  height BIGINT NOT NULL,
+  tx_hash TEXT NOT NULL,
+  message_index INT,
+  type TEXT NOT NULL,
+  sender TEXT NOT NULL,
+  recipient TEXT NOT NULL,
+  denom TEXT NOT NULL,
+  amount NUMERIC NOT NULL,
+  timestamp TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_token_transfer_sender ON token_transfer (sender, id);
+CREATE INDEX IF NOT EXISTS idx_token_transfer_recipient ON token_transfer (recipient, id);
+CREATE INDEX IF NOT EXISTS idx_token_transfer_tx_hash ON token_transfer (tx_hash);
+
+CREATE TABLE IF NOT EXISTS token_balance_change (
+  id BIGSERIAL PRIMARY KEY,
+  height BIGINT NOT NULL,
+  tx_hash TEXT NOT NULL,
+  address TEXT NOT NULL,
+  denom TEXT NOT NULL,
+  amount NUMERIC NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_token_balance_change_address ON token_balance_change (address, denom, height);
+CREATE INDEX IF NOT EXISTS idx_token_balance_change_tx_hash ON token_balance_change (tx_hash);
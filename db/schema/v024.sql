@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS staking_event (
+  id BIGSERIAL PRIMARY KEY,
+  height BIGINT NOT NULL,
+  tx_hash TEXT NOT NULL,
+  message_index INT NOT NULL,
+  action TEXT NOT NULL,
+  delegator TEXT NOT NULL,
+  validator TEXT NOT NULL,
+  destination_validator TEXT NOT NULL DEFAULT '',
+  denom TEXT NOT NULL,
+  amount NUMERIC NOT NULL,
+  completion_time TIMESTAMP,
+  timestamp TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_staking_event_delegator ON staking_event (delegator, id);
+CREATE INDEX IF NOT EXISTS idx_staking_event_validator ON staking_event (validator, id);
+CREATE INDEX IF NOT EXISTS idx_staking_event_destination_validator ON staking_event (destination_validator, id)
+  WHERE destination_validator != '';
+CREATE INDEX IF NOT EXISTS idx_staking_event_tx_hash ON staking_event (tx_hash);
@@ -0,0 +1,48 @@
+CREATE TABLE IF NOT EXISTS gov_proposal (
+  id BIGSERIAL PRIMARY KEY,
+  proposal_id BIGINT NOT NULL UNIQUE,
+  height BIGINT NOT NULL,
+  tx_hash TEXT NOT NULL,
+  proposer TEXT NOT NULL,
+  proposal_type TEXT NOT NULL,
+  title TEXT NOT NULL,
+  description TEXT NOT NULL,
+  content JSONB,
+  timestamp TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_gov_proposal_proposer ON gov_proposal (proposer, id);
+CREATE INDEX IF NOT EXISTS idx_gov_proposal_tx_hash ON gov_proposal (tx_hash);
+
+CREATE TABLE IF NOT EXISTS gov_deposit (
+  id BIGSERIAL PRIMARY KEY,
+  proposal_id BIGINT NOT NULL,
+  height BIGINT NOT NULL,
+  tx_hash TEXT NOT NULL,
+  message_index INT NOT NULL,
+  depositor TEXT NOT NULL,
+  denom TEXT NOT NULL,
+  amount NUMERIC NOT NULL,
+  timestamp TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_gov_deposit_proposal_id ON gov_deposit (proposal_id, id);
+CREATE INDEX IF NOT EXISTS idx_gov_deposit_depositor ON gov_deposit (depositor, id);
+CREATE INDEX IF NOT EXISTS idx_gov_deposit_tx_hash ON gov_deposit (tx_hash);
+
+-- a weighted vote has a row for each option
+CREATE TABLE IF NOT EXISTS gov_vote (
+  id BIGSERIAL PRIMARY KEY,
+  proposal_id BIGINT NOT NULL,
+  height BIGINT NOT NULL,
+  tx_hash TEXT NOT NULL,
+  message_index INT NOT NULL,
+  voter TEXT NOT NULL,
+  option TEXT NOT NULL,
+  weight NUMERIC NOT NULL,
+  timestamp TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_gov_vote_proposal_id ON gov_vote (proposal_id, id);
+CREATE INDEX IF NOT EXISTS idx_gov_vote_voter ON gov_vote (voter, id);
+CREATE INDEX IF NOT EXISTS idx_gov_vote_tx_hash ON gov_vote (tx_hash);
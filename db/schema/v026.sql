@@ -0,0 +1,29 @@
+-- an ICS-20 transfer from the send or receive packet, updated by the acknowledgement or timeout of the packet
+CREATE TABLE IF NOT EXISTS ibc_transfer (
+  id BIGSERIAL PRIMARY KEY,
+  direction TEXT NOT NULL,
+  src_port TEXT NOT NULL,
+  src_channel TEXT NOT NULL,
+  dst_port TEXT NOT NULL,
+  dst_channel TEXT NOT NULL,
+  sequence BIGINT NOT NULL,
+  sender TEXT NOT NULL,
+  receiver TEXT NOT NULL,
+  denom TEXT NOT NULL,
+  amount NUMERIC NOT NULL,
+  status TEXT NOT NULL,
+  error TEXT NOT NULL DEFAULT '',
+  timeout_height TEXT NOT NULL DEFAULT '',
+  timeout_timestamp BIGINT NOT NULL DEFAULT 0,
+  height BIGINT NOT NULL,
+  tx_hash TEXT NOT NULL,
+  timestamp TIMESTAMP,
+  completed_height BIGINT,
+  completed_tx_hash TEXT,
+  completed_at TIMESTAMP,
+  UNIQUE (direction, src_port, src_channel, sequence)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ibc_transfer_sender ON ibc_transfer (sender, id);
+CREATE INDEX IF NOT EXISTS idx_ibc_transfer_receiver ON ibc_transfer (receiver, id);
+CREATE INDEX IF NOT EXISTS idx_ibc_transfer_status ON ibc_transfer (status, id);
@@ -0,0 +1,22 @@
+-- authz grants, where a grant replaced by another grant of the same granter, grantee and message type is revoked by
+-- the tx of the new grant
+CREATE TABLE IF NOT EXISTS authz_grant (
+  id BIGSERIAL PRIMARY KEY,
+  granter TEXT NOT NULL,
+  grantee TEXT NOT NULL,
+  msg_type_url TEXT NOT NULL,
+  authorization_type TEXT NOT NULL,
+  authorization JSONB NOT NULL,
+  expiration TIMESTAMP,
+  height BIGINT NOT NULL,
+  tx_hash TEXT NOT NULL,
+  timestamp TIMESTAMP,
+  revoked_height BIGINT,
+  revoked_tx_hash TEXT,
+  revoked_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_authz_grant_unrevoked ON authz_grant (granter, grantee, msg_type_url)
+  WHERE revoked_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_authz_grant_granter ON authz_grant (granter, id);
+CREATE INDEX IF NOT EXISTS idx_authz_grant_grantee ON authz_grant (grantee, id);
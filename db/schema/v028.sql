@@ -0,0 +1,6 @@
+-- the grantee executing the message through authz, and the granter signing the message
+ALTER TABLE nft_event
+  ADD COLUMN IF NOT EXISTS executor TEXT NOT NULL DEFAULT '',
+  ADD COLUMN IF NOT EXISTS granter TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_nft_event_executor ON nft_event (executor) WHERE executor != '';
@@ -0,0 +1,20 @@
+-- the history of each ISCN, where the owner sending the ISCN is recorded as sender and the new owner as receiver
+CREATE TABLE IF NOT EXISTS iscn_event (
+  id BIGSERIAL PRIMARY KEY,
+  action TEXT NOT NULL,
+  iscn_id TEXT NOT NULL,
+  iscn_id_prefix TEXT NOT NULL,
+  version INT NOT NULL,
+  sender TEXT NOT NULL DEFAULT '',
+  receiver TEXT NOT NULL DEFAULT '',
+  executor TEXT NOT NULL DEFAULT '',
+  granter TEXT NOT NULL DEFAULT '',
+  height BIGINT NOT NULL,
+  tx_hash TEXT NOT NULL,
+  timestamp TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_iscn_event_iscn_id_prefix ON iscn_event (iscn_id_prefix, id);
+CREATE INDEX IF NOT EXISTS idx_iscn_event_tx_hash ON iscn_event (tx_hash);
+-- extracting the same tx again does not duplicate the events
+CREATE UNIQUE INDEX IF NOT EXISTS idx_iscn_event_action_iscn_id_tx_hash ON iscn_event (action, iscn_id, tx_hash);
@@ -0,0 +1,2 @@
+-- burned NFTs are kept with the last owner, and excluded from the queries by default
+ALTER TABLE nft ADD COLUMN IF NOT EXISTS burned_at TIMESTAMP;
@@ -0,0 +1,56 @@
+-- the structured class config of likenft, where max_supply 0 is unlimited
+ALTER TABLE nft_class
+  ADD COLUMN IF NOT EXISTS burnable BOOLEAN NOT NULL DEFAULT false,
+  ADD COLUMN IF NOT EXISTS max_supply NUMERIC NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS blind_box_reveal_time TIMESTAMP,
+  ADD COLUMN IF NOT EXISTS royalty_rate_basis_points BIGINT;
+
+CREATE TABLE IF NOT EXISTS nft_class_mint_period (
+  id BIGSERIAL PRIMARY KEY,
+  class_id TEXT NOT NULL,
+  start_time TIMESTAMP NOT NULL,
+  allowed_addresses TEXT[] NOT NULL DEFAULT '{}',
+  mint_price NUMERIC NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_nft_class_mint_period_class_id ON nft_class_mint_period (class_id, start_time);
+
+CREATE TABLE IF NOT EXISTS nft_class_royalty_stakeholder (
+  id BIGSERIAL PRIMARY KEY,
+  class_id TEXT NOT NULL,
+  account TEXT NOT NULL,
+  weight NUMERIC NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_nft_class_royalty_stakeholder_class_id ON nft_class_royalty_stakeholder (class_id);
+CREATE INDEX IF NOT EXISTS idx_nft_class_royalty_stakeholder_account ON nft_class_royalty_stakeholder (account);
+
+-- the class config and royalty config set by each tx, with NULL config for deleted royalty configs
+CREATE TABLE IF NOT EXISTS nft_class_config_history (
+  id BIGSERIAL PRIMARY KEY,
+  class_id TEXT NOT NULL,
+  config_type TEXT NOT NULL,
+  config JSONB,
+  height BIGINT NOT NULL,
+  tx_hash TEXT NOT NULL,
+  timestamp TIMESTAMP,
+  UNIQUE (class_id, config_type, tx_hash)
+);
+
+-- fill the class configs extracted before, while the royalty configs and history need reindexing
+UPDATE nft_class SET
+  burnable = COALESCE((config->>'burnable')::boolean, false),
+  max_supply = COALESCE((config->>'max_supply')::numeric, 0),
+  blind_box_reveal_time = (config#>>'{blind_box_config,reveal_time}')::timestamp
+WHERE jsonb_typeof(config) = 'object';
+
+INSERT INTO nft_class_mint_period (class_id, start_time, allowed_addresses, mint_price)
+SELECT
+  c.class_id,
+  (p->>'start_time')::timestamp,
+  ARRAY(SELECT jsonb_array_elements_text(COALESCE(p->'allowed_addresses', '[]'::jsonb))),
+  COALESCE((p->>'mint_price')::numeric, 0)
+FROM nft_class AS c, jsonb_array_elements(
+  CASE WHEN jsonb_typeof(c.config#>'{blind_box_config,mint_periods}') = 'array'
+  THEN c.config#>'{blind_box_config,mint_periods}' END
+) AS p;
@@ -0,0 +1,29 @@
+-- the off-chain metadata pointed by nft_class.uri and nft.uri, fetched by the metadata resolver
+-- uri_hash is '' when the uri has no hash to validate against
+CREATE TABLE IF NOT EXISTS resolved_metadata (
+  uri TEXT NOT NULL,
+  uri_hash TEXT NOT NULL DEFAULT '',
+  status TEXT NOT NULL DEFAULT 'pending',
+  content JSONB,
+  hash_verified BOOLEAN NOT NULL DEFAULT false,
+  error TEXT NOT NULL DEFAULT '',
+  attempts INT NOT NULL DEFAULT 0,
+  fetched_at TIMESTAMP,
+  next_attempt_at TIMESTAMP,
+  PRIMARY KEY (uri, uri_hash)
+);
+
+CREATE INDEX IF NOT EXISTS idx_resolved_metadata_next_attempt_at ON resolved_metadata (next_attempt_at)
+  WHERE next_attempt_at IS NOT NULL;
+
+INSERT INTO resolved_metadata (uri, uri_hash, next_attempt_at)
+SELECT DISTINCT uri, COALESCE(uri_hash, ''), NOW() AT TIME ZONE 'UTC'
+FROM nft_class
+WHERE uri IS NOT NULL AND uri <> ''
+ON CONFLICT DO NOTHING;
+
+INSERT INTO resolved_metadata (uri, uri_hash, next_attempt_at)
+SELECT DISTINCT uri, COALESCE(uri_hash, ''), NOW() AT TIME ZONE 'UTC'
+FROM nft
+WHERE uri IS NOT NULL AND uri <> ''
+ON CONFLICT DO NOTHING;
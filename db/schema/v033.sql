@@ -0,0 +1,12 @@
+-- listings and offers are kept after being filled, cancelled or expired, where expired is set from the block time
+ALTER TABLE nft_marketplace ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'active';
+
+CREATE INDEX IF NOT EXISTS idx_nft_marketplace_active_expiration ON nft_marketplace (expiration)
+  WHERE status = 'active';
+
+UPDATE nft_marketplace SET status = 'expired'
+WHERE expiration <= (
+  SELECT to_timestamp(height / 1e9) AT TIME ZONE 'UTC'
+  FROM meta
+  WHERE id = 'latest_block_time_epoch_ns'
+);
@@ -0,0 +1,22 @@
+-- every create, update, delete and fill of marketplace listings and offers, where creator is the seller of a listing
+-- or the buyer of an offer, and counterparty is the other side of the fill, linked to the buy_nft / sell_nft event
+CREATE TABLE IF NOT EXISTS nft_marketplace_history (
+  id BIGSERIAL PRIMARY KEY,
+  action TEXT NOT NULL,
+  type TEXT NOT NULL,
+  class_id TEXT NOT NULL,
+  nft_id TEXT NOT NULL,
+  creator TEXT NOT NULL,
+  price BIGINT,
+  expiration TIMESTAMP,
+  counterparty TEXT NOT NULL DEFAULT '',
+  nft_event_id BIGINT,
+  height BIGINT NOT NULL,
+  tx_hash TEXT NOT NULL,
+  timestamp TIMESTAMP,
+  UNIQUE (action, type, class_id, nft_id, creator, tx_hash)
+);
+
+CREATE INDEX IF NOT EXISTS idx_nft_marketplace_history_class_id ON nft_marketplace_history (class_id, id);
+CREATE INDEX IF NOT EXISTS idx_nft_marketplace_history_creator ON nft_marketplace_history (type, creator);
+CREATE INDEX IF NOT EXISTS idx_nft_marketplace_history_tx_hash ON nft_marketplace_history (tx_hash);
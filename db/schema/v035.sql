@@ -0,0 +1,17 @@
+-- floor price of the active listings per class, recorded by the extractor at the block of each change of the listings
+-- of the class, and at the end of each extraction round for the listings expired, only when the floor price or the
+-- listing count changes, where floor_price is NULL when there is no active listing left.
+CREATE TABLE IF NOT EXISTS nft_marketplace_floor_price (
+  id BIGSERIAL PRIMARY KEY,
+  class_id TEXT NOT NULL,
+  floor_price BIGINT,
+  listing_count INT NOT NULL,
+  height BIGINT NOT NULL,
+  timestamp TIMESTAMP NOT NULL,
+  UNIQUE (class_id, height)
+);
+
+CREATE INDEX IF NOT EXISTS idx_nft_marketplace_floor_price_class_id ON nft_marketplace_floor_price (class_id, id);
+
+CREATE INDEX IF NOT EXISTS idx_nft_marketplace_active_class_price ON nft_marketplace (class_id, type, price)
+  WHERE status = 'active';
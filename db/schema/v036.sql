@@ -0,0 +1,2 @@
+-- for replaying the ownership changes of NFTs up to a point in time
+CREATE INDEX IF NOT EXISTS idx_nft_event_class_nft_timestamp ON nft_event (class_id, nft_id, timestamp, id);
@@ -0,0 +1,149 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"github.com/likecoin/likecoin-chain-tx-indexer/logger"
+	"github.com/likecoin/likecoin-chain-tx-indexer/utils"
+)
+
+func (batch *Batch) InsertStakingEvent(e StakingEvent) {
+	convertedDelegator, err := utils.ConvertAddressPrefix(e.Delegator, MainAddressPrefix)
+	if err == nil {
+		e.Delegator = convertedDelegator
+	}
+	convertedValidator, err := utils.ConvertAddressPrefix(e.Validator, MainValidatorAddressPrefix)
+	if err == nil {
+		e.Validator = convertedValidator
+	}
+	if e.DestinationValidator != "" {
+		convertedDestination, err := utils.ConvertAddressPrefix(e.DestinationValidator, MainValidatorAddressPrefix)
+		if err == nil {
+			e.DestinationValidator = convertedDestination
+		}
+	}
+	var completionTime interface{}
+	if e.CompletionTime != nil {
+		completionTime = e.CompletionTime.UTC()
+	}
+	batch.Batch.Queue(`
+	INSERT INTO staking_event (
+		height, tx_hash, message_index, action, delegator,
+		validator, destination_validator, denom, amount, completion_time,
+		timestamp
+	)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`,
+		e.Height, e.TxHash, e.MessageIndex, e.Action, e.Delegator,
+		e.Validator, e.DestinationValidator, e.Denom, e.Amount, completionTime,
+		e.Timestamp.UTC(),
+	)
+}
+
+// GetStakingEvents returns the staking events of the delegator and the validator. The redelegations to the validator
+// are also included.
+func GetStakingEvents(conn *pgxpool.Conn, q QueryStakingEventsRequest, p PageRequest) (QueryStakingEventsResponse, error) {
+	delegatorVariations := utils.ConvertAddressPrefixes(q.Delegator, AddressPrefixes)
+	validatorVariations := utils.ConvertAddressPrefixes(q.Validator, ValidatorAddressPrefixes)
+	sql := fmt.Sprintf(`
+	SELECT
+		id, height, tx_hash, message_index, action,
+		delegator, validator, destination_validator, denom, amount::text,
+		completion_time, timestamp
+	FROM staking_event
+	WHERE ($1 = 0 OR id > $1)
+		AND ($2 = 0 OR id < $2)
+		AND ($4::text[] IS NULL OR cardinality($4::text[]) = 0 OR delegator = ANY($4))
+		AND ($5::text[] IS NULL OR cardinality($5::text[]) = 0 OR validator = ANY($5) OR destination_validator = ANY($5))
+		AND ($6::text[] IS NULL OR cardinality($6::text[]) = 0 OR action = ANY($6))
+	ORDER BY id %s
+	LIMIT $3
+	`, p.Order())
+
+	ctx, cancel := GetTimeoutContext()
+	defer cancel()
+
+	rows, err := conn.Query(
+		ctx, sql,
+		p.After(), p.Before(), p.Limit, delegatorVariations, validatorVariations,
+		q.Action,
+	)
+	if err != nil {
+		logger.L.Errorw("Failed to query staking events", "error", err)
+		return QueryStakingEventsResponse{}, fmt.Errorf("query staking events error: %w", err)
+	}
+	defer rows.Close()
+
+	res := QueryStakingEventsResponse{
+		Events: []StakingEvent{},
+	}
+	for rows.Next() {
+		var e StakingEvent
+		if err = rows.Scan(
+			&res.Pagination.NextKey, &e.Height, &e.TxHash, &e.MessageIndex, &e.Action,
+			&e.Delegator, &e.Validator, &e.DestinationValidator, &e.Denom, &e.Amount,
+			&e.CompletionTime, &e.Timestamp,
+		); err != nil {
+			logger.L.Errorw("failed to scan staking events", "error", err, "q", q)
+			return QueryStakingEventsResponse{}, fmt.Errorf("query staking events data failed: %w", err)
+		}
+		res.Events = append(res.Events, e)
+	}
+	res.Pagination.Count = len(res.Events)
+	return res, nil
+}
+
+// GetDelegations returns the total amounts of the staking events of the delegator and the validator in each time
+// bucket, where the interval is a field of `date_trunc`, e.g. day, week or month
+func GetDelegations(conn *pgxpool.Conn, q QueryDelegationsRequest) (QueryDelegationsResponse, error) {
+	delegatorVariations := utils.ConvertAddressPrefixes(q.Delegator, AddressPrefixes)
+	validatorVariations := utils.ConvertAddressPrefixes(q.Validator, ValidatorAddressPrefixes)
+	sql := `
+	SELECT
+		date_trunc($1, timestamp) AS bucket,
+		COALESCE(SUM(amount) FILTER (WHERE action = 'delegate'), 0)::text,
+		COALESCE(SUM(amount) FILTER (WHERE action = 'undelegate'), 0)::text,
+		COALESCE(SUM(amount) FILTER (
+			WHERE action = 'redelegate'
+				AND ($3::text[] IS NULL OR cardinality($3::text[]) = 0 OR destination_validator = ANY($3))
+		), 0)::text,
+		COALESCE(SUM(amount) FILTER (
+			WHERE action = 'redelegate'
+				AND ($3::text[] IS NULL OR cardinality($3::text[]) = 0 OR validator = ANY($3))
+		), 0)::text,
+		COALESCE(SUM(amount) FILTER (WHERE action = 'withdraw_rewards'), 0)::text
+	FROM staking_event
+	WHERE denom = $4
+		AND ($2::text[] IS NULL OR cardinality($2::text[]) = 0 OR delegator = ANY($2))
+		AND ($3::text[] IS NULL OR cardinality($3::text[]) = 0 OR validator = ANY($3) OR destination_validator = ANY($3))
+		AND ($5 = 0 OR timestamp > to_timestamp($5))
+		AND ($6 = 0 OR timestamp < to_timestamp($6))
+	GROUP BY bucket
+	ORDER BY bucket
+	`
+
+	ctx, cancel := GetTimeoutContext()
+	defer cancel()
+
+	rows, err := conn.Query(ctx, sql, q.Interval, delegatorVariations, validatorVariations, q.Denom, q.After, q.Before)
+	if err != nil {
+		logger.L.Errorw("Failed to query delegations", "error", err)
+		return QueryDelegationsResponse{}, fmt.Errorf("query delegations error: %w", err)
+	}
+	defer rows.Close()
+
+	res := QueryDelegationsResponse{
+		Buckets: []DelegationBucket{},
+	}
+	for rows.Next() {
+		var b DelegationBucket
+		if err = rows.Scan(&b.Time, &b.Delegated, &b.Undelegated, &b.RedelegatedIn, &b.RedelegatedOut, &b.Rewards); err != nil {
+			logger.L.Errorw("failed to scan delegations", "error", err, "q", q)
+			return QueryDelegationsResponse{}, fmt.Errorf("query delegations data failed: %w", err)
+		}
+		res.Buckets = append(res.Buckets, b)
+	}
+	return res, nil
+}
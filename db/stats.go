@@ -46,12 +46,13 @@ func GetNftCount(conn *pgxpool.Conn, q QueryNftCountRequest) (count QueryCountRe
 		AND (i.version = iscn_latest_version.latest_version)
 	WHERE ($1 = true OR i.owner != n.owner)
 		AND ($2::text[] IS NULL OR n.owner != ALL($2))
+		AND ($3 = true OR n.burned_at IS NULL)
 	`
 	ignoreListVariations := utils.ConvertAddressArrayPrefixes(q.IgnoreList, AddressPrefixes)
 	ctx, cancel := GetTimeoutContext()
 	defer cancel()
 
-	err = conn.QueryRow(ctx, sql, q.IncludeOwner, ignoreListVariations).Scan(&count.Count)
+	err = conn.QueryRow(ctx, sql, q.IncludeOwner, ignoreListVariations, q.IncludeBurned).Scan(&count.Count)
 	if err != nil {
 		err = fmt.Errorf("get nft count failed: %w", err)
 		logger.L.Error(err, q)
@@ -158,14 +159,15 @@ func GetNftReturningCreatorCount(conn *pgxpool.Conn, q QueryNftReturningCreatorC
 	return res, nil
 }
 
-func GetNftOwnerCount(conn *pgxpool.Conn) (count QueryCountResponse, err error) {
+func GetNftOwnerCount(conn *pgxpool.Conn, q QueryNftOwnerCountRequest) (count QueryCountResponse, err error) {
 	sql := `
-	SELECT COUNT(DISTINCT owner) FROM nft;
+	SELECT COUNT(DISTINCT owner) FROM nft
+	WHERE ($1 = true OR burned_at IS NULL);
 	`
 	ctx, cancel := GetTimeoutContext()
 	defer cancel()
 
-	err = conn.QueryRow(ctx, sql).Scan(&count.Count)
+	err = conn.QueryRow(ctx, sql, q.IncludeBurned).Scan(&count.Count)
 	if err != nil {
 		err = fmt.Errorf("get nft owner count failed: %w", err)
 		logger.L.Error(err)
@@ -173,9 +175,10 @@ func GetNftOwnerCount(conn *pgxpool.Conn) (count QueryCountResponse, err error)
 	return
 }
 
-func GetNftOwnerList(conn *pgxpool.Conn, p PageRequest) (res QueryNftOwnerListResponse, err error) {
+func GetNftOwnerList(conn *pgxpool.Conn, q QueryNftOwnerListRequest, p PageRequest) (res QueryNftOwnerListResponse, err error) {
 	sql := `
 	SELECT owner, COUNT(id) FROM nft
+	WHERE ($3 = true OR burned_at IS NULL)
 	GROUP BY owner
 	ORDER BY COUNT(id) DESC
 	OFFSET $1
@@ -185,7 +188,7 @@ func GetNftOwnerList(conn *pgxpool.Conn, p PageRequest) (res QueryNftOwnerListRe
 	ctx, cancel := GetTimeoutContext()
 	defer cancel()
 
-	rows, err := conn.Query(ctx, sql, p.Offset, p.Limit)
+	rows, err := conn.Query(ctx, sql, p.Offset, p.Limit, q.IncludeBurned)
 	if err != nil {
 		err = fmt.Errorf("get nft owner list failed: %w", err)
 		logger.L.Error(err)
@@ -2,6 +2,7 @@ package db_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -303,6 +304,7 @@ func TestNftCreatorCount(t *testing.T) {
 
 func TestNftOwnerCount(t *testing.T) {
 	defer CleanupTestData(Conn)
+	burnedAt := time.Unix(1234567890, 0).UTC()
 	nfts := []Nft{
 		{
 			NftId: "testing-nft-1123123098",
@@ -328,12 +330,21 @@ func TestNftOwnerCount(t *testing.T) {
 			NftId: "testing-nft-1123123103",
 			Owner: ADDR_03_LIKE,
 		},
+		{
+			NftId:    "testing-nft-1123123104",
+			Owner:    ADDR_04_LIKE,
+			BurnedAt: &burnedAt,
+		},
 	}
 	InsertTestData(DBTestData{Nfts: nfts})
 
-	res, err := GetNftOwnerCount(Conn)
+	res, err := GetNftOwnerCount(Conn, QueryNftOwnerCountRequest{})
 	require.NoError(t, err)
 	require.Equal(t, uint64(3), res.Count)
+
+	res, err = GetNftOwnerCount(Conn, QueryNftOwnerCountRequest{IncludeBurned: true})
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), res.Count)
 }
 
 func TestNftOwnerList(t *testing.T) {
@@ -393,7 +404,7 @@ func TestNftOwnerList(t *testing.T) {
 	}
 
 	for i, testCase := range testCases {
-		res, err := GetNftOwnerList(Conn, testCase.pagination)
+		res, err := GetNftOwnerList(Conn, QueryNftOwnerListRequest{}, testCase.pagination)
 		require.NoError(t, err)
 		require.Equal(t, len(testCase.owners), len(res.Owners), "test case #%02d: %s", i, testCase.name)
 		for j, resOwner := range res.Owners {
@@ -0,0 +1,135 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"github.com/likecoin/likecoin-chain-tx-indexer/logger"
+	"github.com/likecoin/likecoin-chain-tx-indexer/utils"
+)
+
+func (batch *Batch) InsertTokenTransfer(t TokenTransfer) {
+	convertedSender, err := utils.ConvertAddressPrefix(t.Sender, MainAddressPrefix)
+	if err == nil {
+		t.Sender = convertedSender
+	}
+	convertedRecipient, err := utils.ConvertAddressPrefix(t.Recipient, MainAddressPrefix)
+	if err == nil {
+		t.Recipient = convertedRecipient
+	}
+	batch.Batch.Queue(`
+	INSERT INTO token_transfer (height, tx_hash, message_index, type, sender, recipient, denom, amount, timestamp)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, t.Height, t.TxHash, t.MessageIndex, t.Type, t.Sender, t.Recipient, t.Denom, t.Amount, t.Timestamp.UTC())
+}
+
+func (batch *Batch) InsertTokenBalanceChange(c TokenBalanceChange) {
+	convertedAddress, err := utils.ConvertAddressPrefix(c.Address, MainAddressPrefix)
+	if err == nil {
+		c.Address = convertedAddress
+	}
+	batch.Batch.Queue(`
+	INSERT INTO token_balance_change (height, tx_hash, address, denom, amount)
+	VALUES ($1, $2, $3, $4, $5)
+	`, c.Height, c.TxHash, c.Address, c.Denom, c.Amount)
+}
+
+func GetTokenTransfers(conn *pgxpool.Conn, q QueryTokenTransfersRequest, p PageRequest) (QueryTokenTransfersResponse, error) {
+	addressVariations := utils.ConvertAddressPrefixes(q.Address, AddressPrefixes)
+	senderVariations := utils.ConvertAddressPrefixes(q.Sender, AddressPrefixes)
+	recipientVariations := utils.ConvertAddressPrefixes(q.Recipient, AddressPrefixes)
+	sql := fmt.Sprintf(`
+	SELECT id, height, tx_hash, message_index, type, sender, recipient, denom, amount::text, timestamp
+	FROM token_transfer
+	WHERE ($1 = 0 OR id > $1)
+		AND ($2 = 0 OR id < $2)
+		AND ($4::text[] IS NULL OR cardinality($4::text[]) = 0 OR sender = ANY($4) OR recipient = ANY($4))
+		AND ($5::text[] IS NULL OR cardinality($5::text[]) = 0 OR sender = ANY($5))
+		AND ($6::text[] IS NULL OR cardinality($6::text[]) = 0 OR recipient = ANY($6))
+		AND ($7 = '' OR denom = $7)
+		AND ($8 = '' OR type = $8)
+	ORDER BY id %s
+	LIMIT $3
+	`, p.Order())
+
+	ctx, cancel := GetTimeoutContext()
+	defer cancel()
+
+	rows, err := conn.Query(
+		ctx, sql,
+		p.After(), p.Before(), p.Limit, addressVariations, senderVariations,
+		recipientVariations, q.Denom, q.Type,
+	)
+	if err != nil {
+		logger.L.Errorw("Failed to query token transfers", "error", err)
+		return QueryTokenTransfersResponse{}, fmt.Errorf("query token transfers error: %w", err)
+	}
+	defer rows.Close()
+
+	res := QueryTokenTransfersResponse{
+		Transfers: []TokenTransfer{},
+	}
+	for rows.Next() {
+		var t TokenTransfer
+		if err = rows.Scan(
+			&res.Pagination.NextKey, &t.Height, &t.TxHash, &t.MessageIndex, &t.Type,
+			&t.Sender, &t.Recipient, &t.Denom, &t.Amount, &t.Timestamp,
+		); err != nil {
+			logger.L.Errorw("failed to scan token transfers", "error", err, "q", q)
+			return QueryTokenTransfersResponse{}, fmt.Errorf("query token transfers data failed: %w", err)
+		}
+		res.Transfers = append(res.Transfers, t)
+	}
+	res.Pagination.Count = len(res.Transfers)
+	return res, nil
+}
+
+// GetTokenBalance sums up the balance changes of the address up to the height, or the latest height if it is 0.
+// Balance changes outside txs, e.g. genesis balances and the unbonded tokens returned at the end of blocks, are not
+// included.
+func GetTokenBalance(conn *pgxpool.Conn, q QueryTokenBalanceRequest) (QueryTokenBalanceResponse, error) {
+	addressVariations := utils.ConvertAddressPrefixes(q.Address, AddressPrefixes)
+	height := q.Height
+	if height == 0 {
+		latestHeight, err := GetLatestHeight(conn)
+		if err != nil {
+			return QueryTokenBalanceResponse{}, fmt.Errorf("failed to get latest height: %w", err)
+		}
+		height = latestHeight
+	}
+	sql := `
+	SELECT denom, SUM(amount)::text
+	FROM token_balance_change
+	WHERE address = ANY($1)
+		AND height <= $2
+		AND ($3 = '' OR denom = $3)
+	GROUP BY denom
+	ORDER BY denom
+	`
+
+	ctx, cancel := GetTimeoutContext()
+	defer cancel()
+
+	rows, err := conn.Query(ctx, sql, addressVariations, height, q.Denom)
+	if err != nil {
+		logger.L.Errorw("Failed to query token balance", "error", err)
+		return QueryTokenBalanceResponse{}, fmt.Errorf("query token balance error: %w", err)
+	}
+	defer rows.Close()
+
+	res := QueryTokenBalanceResponse{
+		Address:  q.Address,
+		Height:   height,
+		Balances: []TokenBalance{},
+	}
+	for rows.Next() {
+		var b TokenBalance
+		if err = rows.Scan(&b.Denom, &b.Amount); err != nil {
+			logger.L.Errorw("failed to scan token balance", "error", err, "q", q)
+			return QueryTokenBalanceResponse{}, fmt.Errorf("query token balance data failed: %w", err)
+		}
+		res.Balances = append(res.Balances, b)
+	}
+	return res, nil
+}
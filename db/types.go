@@ -73,6 +73,38 @@ func (q IscnQuery) Empty() bool {
 		q.StakeholderName == ""
 }
 
+type IscnEventAction string
+
+const (
+	ISCN_ACTION_CREATE   IscnEventAction = "create"
+	ISCN_ACTION_UPDATE   IscnEventAction = "update"
+	ISCN_ACTION_TRANSFER IscnEventAction = "transfer"
+)
+
+type IscnEvent struct {
+	Action       IscnEventAction `json:"action"`
+	IscnId       string          `json:"iscn_id"`
+	IscnIdPrefix string          `json:"iscn_id_prefix"`
+	Version      int             `json:"version"`
+	Sender       string          `json:"sender"`
+	Receiver     string          `json:"receiver"`
+	Executor     string          `json:"executor,omitempty"`
+	Granter      string          `json:"granter,omitempty"`
+	Height       int64           `json:"height"`
+	TxHash       string          `json:"tx_hash"`
+	Timestamp    time.Time       `json:"timestamp"`
+}
+
+type QueryIscnHistoryRequest struct {
+	IscnIdPrefix string
+	Action       []IscnEventAction `form:"action"`
+}
+
+type QueryIscnHistoryResponse struct {
+	Pagination PageResponse `json:"pagination"`
+	Events     []IscnEvent  `json:"events"`
+}
+
 type NftClass struct {
 	Id             string          `json:"id"`
 	Name           string          `json:"name"`
@@ -86,6 +118,83 @@ type NftClass struct {
 	CreatedAt      time.Time       `json:"created_at"`
 	LatestPrice    uint64          `json:"latest_price,omitempty"`
 	PriceUpdatedAt *time.Time      `json:"price_updated_at,omitempty"`
+	UriMetadata    json.RawMessage `json:"uri_metadata,omitempty"`
+}
+
+type NftClassConfig struct {
+	Burnable       bool               `json:"burnable"`
+	MaxSupply      uint64             `json:"max_supply,string"`
+	BlindBoxConfig *NftBlindBoxConfig `json:"blind_box_config"`
+}
+
+type NftBlindBoxConfig struct {
+	MintPeriods []NftMintPeriod `json:"mint_periods"`
+	RevealTime  time.Time       `json:"reveal_time"`
+}
+
+type NftMintPeriod struct {
+	StartTime        time.Time `json:"start_time"`
+	AllowedAddresses []string  `json:"allowed_addresses"`
+	MintPrice        uint64    `json:"mint_price,string"`
+}
+
+type NftRoyaltyConfig struct {
+	RateBasisPoints uint64                  `json:"rate_basis_points,string"`
+	Stakeholders    []NftRoyaltyStakeholder `json:"stakeholders"`
+}
+
+type NftRoyaltyStakeholder struct {
+	Account string `json:"account"`
+	Weight  uint64 `json:"weight,string"`
+}
+
+type NftClassConfigType string
+
+const (
+	NFT_CLASS_CONFIG_TYPE_CLASS   NftClassConfigType = "class"
+	NFT_CLASS_CONFIG_TYPE_ROYALTY NftClassConfigType = "royalty"
+)
+
+type NftClassConfigHistory struct {
+	ClassId    string             `json:"class_id"`
+	ConfigType NftClassConfigType `json:"config_type"`
+	Config     json.RawMessage    `json:"config"`
+	Height     int64              `json:"height"`
+	TxHash     string             `json:"tx_hash"`
+	Timestamp  time.Time          `json:"timestamp"`
+}
+
+type QueryNftClassConfigHistoryRequest struct {
+	ClassId    string               `form:"class_id" binding:"required"`
+	ConfigType []NftClassConfigType `form:"config_type"`
+}
+
+type QueryNftClassConfigHistoryResponse struct {
+	Pagination PageResponse            `json:"pagination"`
+	History    []NftClassConfigHistory `json:"history"`
+}
+
+type ResolvedMetadataStatus string
+
+const (
+	RESOLVED_METADATA_STATUS_PENDING       ResolvedMetadataStatus = "pending"
+	RESOLVED_METADATA_STATUS_RESOLVED      ResolvedMetadataStatus = "resolved"
+	RESOLVED_METADATA_STATUS_FAILED        ResolvedMetadataStatus = "failed"
+	RESOLVED_METADATA_STATUS_HASH_MISMATCH ResolvedMetadataStatus = "hash_mismatch"
+	RESOLVED_METADATA_STATUS_UNSUPPORTED   ResolvedMetadataStatus = "unsupported"
+)
+
+// ResolvedMetadata is the cached off-chain metadata of an uri, where NextAttemptAt is nil when no more fetch is needed
+type ResolvedMetadata struct {
+	Uri           string
+	UriHash       string
+	Status        ResolvedMetadataStatus
+	Content       json.RawMessage
+	HashVerified  bool
+	Error         string
+	Attempts      int
+	FetchedAt     *time.Time
+	NextAttemptAt *time.Time
 }
 
 type NftClassParent struct {
@@ -119,6 +228,8 @@ type Nft struct {
 	Timestamp      time.Time       `json:"timestamp"`
 	LatestPrice    uint64          `json:"latest_price,omitempty"`
 	PriceUpdatedAt *NoTimeZoneTime `json:"price_updated_at,omitempty"`
+	BurnedAt       *time.Time      `json:"burned_at,omitempty"`
+	UriMetadata    json.RawMessage `json:"uri_metadata,omitempty"`
 }
 
 type NftEventAction string
@@ -130,6 +241,7 @@ const (
 	ACTION_UPDATE_CLASS NftEventAction = "update_class"
 	ACTION_BUY          NftEventAction = "buy_nft"
 	ACTION_SELL         NftEventAction = "sell_nft"
+	ACTION_BURN         NftEventAction = "burn_nft"
 )
 
 type NftEvent struct {
@@ -143,15 +255,28 @@ type NftEvent struct {
 	Timestamp time.Time          `json:"timestamp"`
 	Memo      string             `json:"memo"`
 	Price     uint64             `json:"price,omitempty"`
+	// Executor is the grantee executing the message through authz, and Granter is the signer of the message
+	Executor string `json:"executor,omitempty"`
+	Granter  string `json:"granter,omitempty"`
 }
 
+type NftMarketplaceItemStatus string
+
+const (
+	MARKETPLACE_STATUS_ACTIVE    NftMarketplaceItemStatus = "active"
+	MARKETPLACE_STATUS_EXPIRED   NftMarketplaceItemStatus = "expired"
+	MARKETPLACE_STATUS_FILLED    NftMarketplaceItemStatus = "filled"
+	MARKETPLACE_STATUS_CANCELLED NftMarketplaceItemStatus = "cancelled"
+)
+
 type NftMarketplaceItem struct {
-	Type       string    `json:"action,omitempty"`
-	ClassId    string    `json:"class_id"`
-	NftId      string    `json:"nft_id"`
-	Creator    string    `json:"creator"`
-	Price      uint64    `json:"price,omitempty"`
-	Expiration time.Time `json:"expiration,omitempty"`
+	Type       string                   `json:"action,omitempty"`
+	ClassId    string                   `json:"class_id"`
+	NftId      string                   `json:"nft_id"`
+	Creator    string                   `json:"creator"`
+	Price      uint64                   `json:"price,omitempty"`
+	Expiration time.Time                `json:"expiration,omitempty"`
+	Status     NftMarketplaceItemStatus `json:"status,omitempty"`
 }
 
 type NftIncome struct {
@@ -225,11 +350,16 @@ type iscnResponseData struct {
 }
 
 type QueryClassRequest struct {
-	IscnIdPrefix    string   `form:"iscn_id_prefix"`
-	Account         string   `form:"account"`
-	IscnOwner       []string `form:"iscn_owner"`
-	Owner           string   `form:"owner"`
-	AllIscnVersions bool     `form:"all_iscn_versions"`
+	IscnIdPrefix      string   `form:"iscn_id_prefix"`
+	Account           string   `form:"account"`
+	IscnOwner         []string `form:"iscn_owner"`
+	Owner             string   `form:"owner"`
+	AllIscnVersions   bool     `form:"all_iscn_versions"`
+	IncludeBurned     bool     `form:"include_burned"`
+	HasBlindBox       *bool    `form:"has_blind_box"`
+	MintableNow       bool     `form:"mintable_now"`
+	MaxSupplyLte      uint64   `form:"max_supply_lte"`
+	ExpandUriMetadata bool     `form:"expand_uri_metadata"`
 }
 
 type QueryClassResponse struct {
@@ -246,8 +376,12 @@ type NftClassResponse struct {
 }
 
 type QueryNftRequest struct {
-	Owner         string `form:"owner" binding:"required"`
-	ExpandClasses bool   `form:"expand_classes"`
+	Owner             string     `form:"owner" binding:"required"`
+	ExpandClasses     bool       `form:"expand_classes"`
+	IncludeBurned     bool       `form:"include_burned"`
+	ExpandUriMetadata bool       `form:"expand_uri_metadata"`
+	AtHeight          int64      `form:"at_height"`
+	AtTime            *time.Time `form:"at_time"`
 }
 
 type QueryNftResponse struct {
@@ -262,9 +396,12 @@ type NftResponse struct {
 }
 
 type QueryOwnerRequest struct {
-	ClassId          string   `form:"class_id" binding:"required"`
-	ExcludeIscnOwner bool     `form:"exclude_iscn_owner"`
-	IgnoreList       []string `form:"ignore_list"`
+	ClassId          string     `form:"class_id" binding:"required"`
+	ExcludeIscnOwner bool       `form:"exclude_iscn_owner"`
+	IgnoreList       []string   `form:"ignore_list"`
+	IncludeBurned    bool       `form:"include_burned"`
+	AtHeight         int64      `form:"at_height"`
+	AtTime           *time.Time `form:"at_time"`
 }
 
 type QueryOwnerResponse struct {
@@ -290,6 +427,8 @@ type QueryEventsRequest struct {
 	ActionType     []NftEventAction `form:"action_type"`
 	IgnoreFromList []string         `form:"ignore_from_list"`
 	IgnoreToList   []string         `form:"ignore_to_list"`
+	Executor       []string         `form:"executor"`
+	ViaAuthz       *bool            `form:"via_authz"`
 }
 
 type QueryEventsResponse struct {
@@ -345,6 +484,7 @@ type QueryRankingRequest struct {
 	After           int64    `form:"after"`
 	Before          int64    `form:"before"`
 	OrderBy         string   `form:"order_by"`
+	IncludeBurned   bool     `form:"include_burned"`
 }
 
 type QueryRankingResponse struct {
@@ -366,6 +506,7 @@ type QueryCollectorRequest struct {
 	IncludeOwner    bool     `form:"include_owner,default=true"`
 	PriceBy         string   `form:"price_by,default=nft"`
 	OrderBy         string   `form:"order_by,default=price"`
+	IncludeBurned   bool     `form:"include_burned"`
 }
 
 type QueryCollectorResponse struct {
@@ -380,6 +521,7 @@ type QueryCreatorRequest struct {
 	IncludeOwner    bool     `form:"include_owner,default=true"`
 	PriceBy         string   `form:"price_by,default=nft"`
 	OrderBy         string   `form:"order_by,default=price"`
+	IncludeBurned   bool     `form:"include_burned"`
 }
 
 type QueryCreatorResponse struct {
@@ -405,6 +547,7 @@ type QueryUserStatRequest struct {
 	User            string   `form:"user"`
 	IgnoreList      []string `form:"ignore_list"`
 	AllIscnVersions bool     `form:"all_iscn_versions"`
+	IncludeBurned   bool     `form:"include_burned"`
 }
 
 type QueryUserStatResponse struct {
@@ -442,8 +585,9 @@ type QueryNftReturningCreatorCountResponse struct {
 }
 
 type QueryNftCountRequest struct {
-	IncludeOwner bool     `form:"include_owner"`
-	IgnoreList   []string `form:"ignore_list"`
+	IncludeOwner  bool     `form:"include_owner"`
+	IgnoreList    []string `form:"ignore_list"`
+	IncludeBurned bool     `form:"include_burned"`
 }
 
 type QueryNftTradeStatsRequest struct {
@@ -454,6 +598,14 @@ type QueryNftTradeStatsResponse struct {
 	TotalVolume uint64 `json:"total_volume"`
 }
 
+type QueryNftOwnerCountRequest struct {
+	IncludeBurned bool `form:"include_burned"`
+}
+
+type QueryNftOwnerListRequest struct {
+	IncludeBurned bool `form:"include_burned"`
+}
+
 type QueryNftOwnerListResponse struct {
 	Owners     []OwnerResponse `json:"owners"`
 	Pagination PageResponse    `json:"pagination"`
@@ -465,6 +617,8 @@ type QueryNftMarketplaceItemsRequest struct {
 	NftId   string `form:"nft_id"`
 	Creator string `form:"creator"`
 	Expand  bool   `form:"expand"`
+	// ActiveOnly excludes the expired, filled and cancelled items unless explicitly set to false
+	ActiveOnly *bool `form:"active_only"`
 }
 
 type NftMarketplaceItemResponse struct {
@@ -478,12 +632,121 @@ type QueryNftMarketplaceItemsResponse struct {
 	Pagination PageResponse                 `json:"pagination"`
 }
 
+type NftMarketplaceAction string
+
+const (
+	MARKETPLACE_ACTION_CREATE NftMarketplaceAction = "create"
+	MARKETPLACE_ACTION_UPDATE NftMarketplaceAction = "update"
+	MARKETPLACE_ACTION_DELETE NftMarketplaceAction = "delete"
+	MARKETPLACE_ACTION_FILL   NftMarketplaceAction = "fill"
+)
+
+// NftMarketplaceHistory is a change of a listing or offer, where NftEvent is the buy_nft or sell_nft event of a fill
+type NftMarketplaceHistory struct {
+	Action       NftMarketplaceAction `json:"action"`
+	Type         string               `json:"type"`
+	ClassId      string               `json:"class_id"`
+	NftId        string               `json:"nft_id"`
+	Creator      string               `json:"creator"`
+	Price        uint64               `json:"price"`
+	Expiration   *time.Time           `json:"expiration,omitempty"`
+	Counterparty string               `json:"counterparty,omitempty"`
+	NftEvent     *NftEvent            `json:"nft_event,omitempty"`
+	Height       int64                `json:"height"`
+	TxHash       string               `json:"tx_hash"`
+	Timestamp    time.Time            `json:"timestamp"`
+}
+
+type QueryNftMarketplaceHistoryRequest struct {
+	ClassId string                 `form:"class_id" binding:"required"`
+	NftId   string                 `form:"nft_id"`
+	Type    string                 `form:"type"`
+	Creator string                 `form:"creator"`
+	Action  []NftMarketplaceAction `form:"action"`
+}
+
+type QueryNftMarketplaceHistoryResponse struct {
+	Pagination PageResponse            `json:"pagination"`
+	History    []NftMarketplaceHistory `json:"history"`
+}
+
+type QueryNftMarketplaceSellerStatsRequest struct {
+	Seller  []string `form:"seller"`
+	ClassId string   `form:"class_id"`
+}
+
+// NftMarketplaceSellerStat summarizes the listings of a seller, where AvgFillSeconds is the average time from the
+// creation of a listing to its fill
+type NftMarketplaceSellerStat struct {
+	Seller         string  `json:"seller"`
+	ListingCount   int     `json:"listing_count"`
+	FilledCount    int     `json:"filled_count"`
+	CancelledCount int     `json:"cancelled_count"`
+	FillRate       float64 `json:"fill_rate"`
+	FilledVolume   uint64  `json:"filled_volume"`
+	AvgFillSeconds float64 `json:"avg_fill_seconds"`
+}
+
+type QueryNftMarketplaceSellerStatsResponse struct {
+	Pagination PageResponse               `json:"pagination"`
+	Sellers    []NftMarketplaceSellerStat `json:"sellers"`
+}
+
+type QueryNftMarketplaceClassStatsRequest struct {
+	ClassIds    []string `form:"class_id" binding:"required"`
+	BucketSize  uint64   `form:"bucket_size"`
+	DepthLevels uint     `form:"depth_levels,default=10"`
+}
+
+// NftMarketplacePriceLevel counts the active listings or offers with price in [Price, Price + bucket_size)
+type NftMarketplacePriceLevel struct {
+	Price uint64 `json:"price"`
+	Count int    `json:"count"`
+}
+
+// NftMarketplaceClassStat is the order book summary of the active listings and offers of a class, where Spread is
+// FloorPrice - BestOffer and may be negative
+type NftMarketplaceClassStat struct {
+	ClassId      string                     `json:"class_id"`
+	FloorPrice   *uint64                    `json:"floor_price"`
+	BestOffer    *uint64                    `json:"best_offer"`
+	Spread       *int64                     `json:"spread"`
+	ListingCount int                        `json:"listing_count"`
+	OfferCount   int                        `json:"offer_count"`
+	ListingDepth []NftMarketplacePriceLevel `json:"listing_depth,omitempty"`
+	OfferDepth   []NftMarketplacePriceLevel `json:"offer_depth,omitempty"`
+}
+
+type QueryNftMarketplaceClassStatsResponse struct {
+	Classes []NftMarketplaceClassStat `json:"classes"`
+}
+
+type QueryNftMarketplaceFloorPriceHistoryRequest struct {
+	ClassId string `form:"class_id" binding:"required"`
+	After   int64  `form:"after"`
+	Before  int64  `form:"before"`
+}
+
+type NftMarketplaceFloorPriceSnapshot struct {
+	ClassId      string    `json:"class_id"`
+	FloorPrice   *uint64   `json:"floor_price"`
+	ListingCount int       `json:"listing_count"`
+	Height       int64     `json:"height"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+type QueryNftMarketplaceFloorPriceHistoryResponse struct {
+	Pagination PageResponse                       `json:"pagination"`
+	Snapshots  []NftMarketplaceFloorPriceSnapshot `json:"snapshots"`
+}
+
 type QueryCollectorTopRankedCreatorsRequest struct {
 	Collector       string   `form:"collector" binding:"required"`
 	IgnoreList      []string `form:"ignore_list"`
 	AllIscnVersions bool     `form:"all_iscn_versions"`
 	IncludeOwner    bool     `form:"include_owner,default=true"`
 	Top             uint     `form:"top,default=5"`
+	IncludeBurned   bool     `form:"include_burned"`
 }
 
 type CollectorTopRankedCreator struct {
@@ -496,11 +759,383 @@ type QueryCollectorTopRankedCreatorsResponse struct {
 }
 
 type QueryClassesOwnersRequest struct {
-	ClassIds []string `form:"class_ids" binding:"required"`
-	Owners   []string `form:"owners"`
+	ClassIds      []string   `form:"class_ids" binding:"required"`
+	Owners        []string   `form:"owners"`
+	IncludeBurned bool       `form:"include_burned"`
+	AtHeight      int64      `form:"at_height"`
+	AtTime        *time.Time `form:"at_time"`
 }
 
 type QueryClassesOwnersResponse struct {
 	// key: owner address, value: class IDs
 	Owners map[string][]string `json:"owners"`
 }
+
+// NftHolder is the owner of an NFT at a point in time, where AcquiredAt is the time of the last ownership change
+type NftHolder struct {
+	ClassId    string    `json:"class_id"`
+	NftId      string    `json:"nft_id"`
+	Owner      string    `json:"owner"`
+	AcquiredAt time.Time `json:"acquired_at"`
+}
+
+type Block struct {
+	Height   int64     `json:"height"`
+	Hash     string    `json:"hash"`
+	Time     time.Time `json:"time"`
+	Proposer string    `json:"proposer"`
+	NumTxs   int       `json:"num_txs"`
+	AppHash  string    `json:"app_hash"`
+}
+
+type QueryBlocksRequest struct {
+	FromHeight int64      `form:"from_height"`
+	ToHeight   int64      `form:"to_height"`
+	FromTime   *time.Time `form:"from_time"`
+	ToTime     *time.Time `form:"to_time"`
+}
+
+type QueryBlocksResponse struct {
+	Blocks     []Block      `json:"blocks"`
+	Pagination PageResponse `json:"pagination"`
+}
+
+// IndexedTx is the identity of a stored tx, for verifying the index against the chain
+type IndexedTx struct {
+	Height      int64
+	TxIndex     int
+	TxHash      string
+	Placeholder bool
+}
+
+type ExtractionFailure struct {
+	Id           int64      `json:"id"`
+	TxHash       string     `json:"tx_hash"`
+	Height       int64      `json:"height"`
+	Extractor    string     `json:"extractor"`
+	MessageIndex *int       `json:"message_index,omitempty"`
+	EventType    string     `json:"event_type,omitempty"`
+	Processor    string     `json:"processor,omitempty"`
+	Error        string     `json:"error"`
+	Attempts     int        `json:"attempts"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	ResolvedAt   *time.Time `json:"resolved_at,omitempty"`
+}
+
+type QueryExtractionFailuresResponse struct {
+	Failures []ExtractionFailure `json:"failures"`
+	Count    int64               `json:"count"`
+}
+
+type RetryExtractionFailuresResponse struct {
+	Retried  int `json:"retried"`
+	Resolved int `json:"resolved"`
+	Failed   int `json:"failed"`
+	Skipped  int `json:"skipped"`
+}
+
+const (
+	TOKEN_TRANSFER_TYPE_TRANSFER = "transfer"
+	TOKEN_TRANSFER_TYPE_FEE      = "fee"
+)
+
+type TokenTransfer struct {
+	Height       int64     `json:"height"`
+	TxHash       string    `json:"tx_hash"`
+	MessageIndex *int      `json:"message_index,omitempty"`
+	Type         string    `json:"type"`
+	Sender       string    `json:"sender"`
+	Recipient    string    `json:"recipient"`
+	Denom        string    `json:"denom"`
+	Amount       string    `json:"amount"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// TokenBalanceChange is the change of the balance of an address in a tx, negative for spending
+type TokenBalanceChange struct {
+	Height  int64
+	TxHash  string
+	Address string
+	Denom   string
+	Amount  string
+}
+
+type QueryTokenTransfersRequest struct {
+	Address   string `form:"address"`
+	Sender    string `form:"sender"`
+	Recipient string `form:"recipient"`
+	Denom     string `form:"denom"`
+	Type      string `form:"type"`
+}
+
+type QueryTokenTransfersResponse struct {
+	Pagination PageResponse    `json:"pagination"`
+	Transfers  []TokenTransfer `json:"transfers"`
+}
+
+type QueryTokenBalanceRequest struct {
+	Address string `form:"address" binding:"required"`
+	Height  int64  `form:"height"`
+	Denom   string `form:"denom"`
+}
+
+type TokenBalance struct {
+	Denom  string `json:"denom"`
+	Amount string `json:"amount"`
+}
+
+type QueryTokenBalanceResponse struct {
+	Address  string         `json:"address"`
+	Height   int64          `json:"height"`
+	Balances []TokenBalance `json:"balances"`
+}
+
+type StakingAction string
+
+const (
+	STAKING_ACTION_DELEGATE         StakingAction = "delegate"
+	STAKING_ACTION_UNDELEGATE       StakingAction = "undelegate"
+	STAKING_ACTION_REDELEGATE       StakingAction = "redelegate"
+	STAKING_ACTION_WITHDRAW_REWARDS StakingAction = "withdraw_rewards"
+)
+
+type StakingEvent struct {
+	Height               int64         `json:"height"`
+	TxHash               string        `json:"tx_hash"`
+	MessageIndex         int           `json:"message_index"`
+	Action               StakingAction `json:"action"`
+	Delegator            string        `json:"delegator"`
+	Validator            string        `json:"validator"`
+	DestinationValidator string        `json:"destination_validator,omitempty"`
+	Denom                string        `json:"denom"`
+	Amount               string        `json:"amount"`
+	CompletionTime       *time.Time    `json:"completion_time,omitempty"`
+	Timestamp            time.Time     `json:"timestamp"`
+}
+
+type QueryStakingEventsRequest struct {
+	Delegator string          `form:"delegator"`
+	Validator string          `form:"validator"`
+	Action    []StakingAction `form:"action"`
+}
+
+type QueryStakingEventsResponse struct {
+	Pagination PageResponse   `json:"pagination"`
+	Events     []StakingEvent `json:"events"`
+}
+
+type QueryDelegationsRequest struct {
+	Delegator string `form:"delegator"`
+	Validator string `form:"validator"`
+	Denom     string `form:"denom,default=nanolike"`
+	Interval  string `form:"interval,default=day"`
+	After     int64  `form:"after"`
+	Before    int64  `form:"before"`
+}
+
+// DelegationBucket is the total amounts in a time bucket. For the redelegations, the source validator is counted as
+// redelegated out, and the destination validator as redelegated in.
+type DelegationBucket struct {
+	Time           time.Time `json:"time"`
+	Delegated      string    `json:"delegated"`
+	Undelegated    string    `json:"undelegated"`
+	RedelegatedIn  string    `json:"redelegated_in"`
+	RedelegatedOut string    `json:"redelegated_out"`
+	Rewards        string    `json:"rewards"`
+}
+
+type QueryDelegationsResponse struct {
+	Buckets []DelegationBucket `json:"buckets"`
+}
+
+type GovProposal struct {
+	ProposalId   uint64          `json:"proposal_id"`
+	Height       int64           `json:"height"`
+	TxHash       string          `json:"tx_hash"`
+	Proposer     string          `json:"proposer"`
+	ProposalType string          `json:"proposal_type"`
+	Title        string          `json:"title"`
+	Description  string          `json:"description"`
+	Content      json.RawMessage `json:"content,omitempty"`
+	Timestamp    time.Time       `json:"timestamp"`
+}
+
+type GovDeposit struct {
+	ProposalId   uint64    `json:"proposal_id"`
+	Height       int64     `json:"height"`
+	TxHash       string    `json:"tx_hash"`
+	MessageIndex int       `json:"message_index"`
+	Depositor    string    `json:"depositor"`
+	Denom        string    `json:"denom"`
+	Amount       string    `json:"amount"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// GovVote is an option of a vote, so a weighted vote has a GovVote for each option
+type GovVote struct {
+	ProposalId   uint64    `json:"proposal_id"`
+	Height       int64     `json:"height"`
+	TxHash       string    `json:"tx_hash"`
+	MessageIndex int       `json:"message_index"`
+	Voter        string    `json:"voter"`
+	Option       string    `json:"option"`
+	Weight       string    `json:"weight"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+type QueryGovProposalsRequest struct {
+	Proposer string `form:"proposer"`
+}
+
+type QueryGovProposalsResponse struct {
+	Pagination PageResponse  `json:"pagination"`
+	Proposals  []GovProposal `json:"proposals"`
+}
+
+type QueryGovDepositsResponse struct {
+	Pagination PageResponse `json:"pagination"`
+	Deposits   []GovDeposit `json:"deposits"`
+}
+
+type QueryGovVotesRequest struct {
+	ProposalId uint64   `form:"proposal_id"`
+	Voter      string   `form:"voter"`
+	Option     []string `form:"option"`
+}
+
+type QueryGovVotesResponse struct {
+	Pagination PageResponse `json:"pagination"`
+	Votes      []GovVote    `json:"votes"`
+}
+
+const (
+	GOV_VOTER_TYPE_VALIDATOR = "validator"
+	GOV_VOTER_TYPE_DELEGATOR = "delegator"
+)
+
+type GovTurnoutOption struct {
+	Option string `json:"option"`
+	Voters int64  `json:"voters"`
+	Weight string `json:"weight"`
+}
+
+type GovTurnout struct {
+	VoterType string             `json:"voter_type"`
+	Voters    int64              `json:"voters"`
+	Options   []GovTurnoutOption `json:"options"`
+}
+
+type QueryGovTurnoutResponse struct {
+	ProposalId uint64       `json:"proposal_id"`
+	Turnout    []GovTurnout `json:"turnout"`
+}
+
+const (
+	IBC_TRANSFER_DIRECTION_SEND    = "send"
+	IBC_TRANSFER_DIRECTION_RECEIVE = "receive"
+)
+
+const (
+	// the packet is sent and waiting for the acknowledgement or timeout
+	IBC_TRANSFER_STATUS_PENDING      = "pending"
+	IBC_TRANSFER_STATUS_ACKNOWLEDGED = "acknowledged"
+	IBC_TRANSFER_STATUS_RECEIVED     = "received"
+	// the counterparty or this chain failed to receive the packet, with the error acknowledgement
+	IBC_TRANSFER_STATUS_FAILED    = "failed"
+	IBC_TRANSFER_STATUS_TIMED_OUT = "timed_out"
+)
+
+type IbcTransfer struct {
+	Direction        string     `json:"direction"`
+	SrcPort          string     `json:"src_port"`
+	SrcChannel       string     `json:"src_channel"`
+	DstPort          string     `json:"dst_port"`
+	DstChannel       string     `json:"dst_channel"`
+	Sequence         uint64     `json:"sequence"`
+	Sender           string     `json:"sender"`
+	Receiver         string     `json:"receiver"`
+	Denom            string     `json:"denom"`
+	Amount           string     `json:"amount"`
+	Status           string     `json:"status"`
+	Error            string     `json:"error,omitempty"`
+	TimeoutHeight    string     `json:"timeout_height"`
+	TimeoutTimestamp uint64     `json:"timeout_timestamp"`
+	Height           int64      `json:"height"`
+	TxHash           string     `json:"tx_hash"`
+	Timestamp        time.Time  `json:"timestamp"`
+	CompletedHeight  *int64     `json:"completed_height,omitempty"`
+	CompletedTxHash  *string    `json:"completed_tx_hash,omitempty"`
+	CompletedAt      *time.Time `json:"completed_at,omitempty"`
+}
+
+// IbcPacketCompletion is the acknowledgement or timeout of a sent packet
+type IbcPacketCompletion struct {
+	SrcPort    string
+	SrcChannel string
+	Sequence   uint64
+	Status     string
+	Error      string
+	Height     int64
+	TxHash     string
+	Timestamp  time.Time
+}
+
+type QueryIbcTransfersRequest struct {
+	Address   string   `form:"address"`
+	Direction string   `form:"direction"`
+	Channel   string   `form:"channel"`
+	Denom     string   `form:"denom"`
+	Status    []string `form:"status"`
+}
+
+type QueryIbcTransfersResponse struct {
+	Pagination PageResponse  `json:"pagination"`
+	Transfers  []IbcTransfer `json:"transfers"`
+}
+
+type AuthzGrant struct {
+	Granter           string          `json:"granter"`
+	Grantee           string          `json:"grantee"`
+	MsgTypeUrl        string          `json:"msg_type_url"`
+	AuthorizationType string          `json:"authorization_type"`
+	Authorization     json.RawMessage `json:"authorization"`
+	Expiration        *time.Time      `json:"expiration,omitempty"`
+	Height            int64           `json:"height"`
+	TxHash            string          `json:"tx_hash"`
+	Timestamp         time.Time       `json:"timestamp"`
+	RevokedHeight     *int64          `json:"revoked_height,omitempty"`
+	RevokedTxHash     *string         `json:"revoked_tx_hash,omitempty"`
+	RevokedAt         *time.Time      `json:"revoked_at,omitempty"`
+	// Active is true if the grant is neither revoked nor expired
+	Active bool `json:"active"`
+}
+
+// AuthzGrantRef identifies the grants of the granter to the grantee for the message type
+type AuthzGrantRef struct {
+	Granter    string
+	Grantee    string
+	MsgTypeUrl string
+}
+
+// AuthzRevocation is the revocation of a grant by MsgRevoke, or by MsgExec using up the authorization
+type AuthzRevocation struct {
+	Granter    string
+	Grantee    string
+	MsgTypeUrl string
+	Height     int64
+	TxHash     string
+	Timestamp  time.Time
+}
+
+type QueryAuthzGrantsRequest struct {
+	Granter    string `form:"granter"`
+	Grantee    string `form:"grantee"`
+	MsgTypeUrl string `form:"msg_type_url"`
+	ActiveOnly bool   `form:"active_only"`
+}
+
+type QueryAuthzGrantsResponse struct {
+	Pagination PageResponse `json:"pagination"`
+	Grants     []AuthzGrant `json:"grants"`
+}
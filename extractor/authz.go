@@ -0,0 +1,64 @@
+package extractor
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/likecoin/likecoin-chain-tx-indexer/db"
+	"github.com/likecoin/likecoin-chain-tx-indexer/utils"
+)
+
+type grantMessage struct {
+	Granter string `json:"granter"`
+	Grantee string `json:"grantee"`
+	Grant   struct {
+		Authorization json.RawMessage `json:"authorization"`
+		Expiration    *time.Time      `json:"expiration"`
+	} `json:"grant"`
+}
+
+func grantAuthz(payload *Payload, event *types.StringEvent) error {
+	var message grantMessage
+	if err := json.Unmarshal(payload.GetMessage(), &message); err != nil {
+		return fmt.Errorf("failed to unmarshal grant message: %w", err)
+	}
+	var authorization struct {
+		Type string `json:"@type"`
+	}
+	if err := json.Unmarshal(message.Grant.Authorization, &authorization); err != nil {
+		return fmt.Errorf("failed to unmarshal authorization: %w", err)
+	}
+	payload.Batch.InsertAuthzGrant(db.AuthzGrant{
+		Granter:           message.Granter,
+		Grantee:           message.Grantee,
+		MsgTypeUrl:        utils.GetEventValue(event, "msg_type_url"),
+		AuthorizationType: authorization.Type,
+		Authorization:     message.Grant.Authorization,
+		Expiration:        message.Grant.Expiration,
+		Height:            payload.Height,
+		TxHash:            payload.TxHash,
+		Timestamp:         payload.Timestamp,
+	})
+	return nil
+}
+
+// revokeAuthz revokes the grant by the event, since the grant may also be revoked by MsgExec using up the authorization
+func revokeAuthz(payload *Payload, event *types.StringEvent) error {
+	payload.Batch.RevokeAuthzGrant(db.AuthzRevocation{
+		Granter:    utils.GetEventValue(event, "granter"),
+		Grantee:    utils.GetEventValue(event, "grantee"),
+		MsgTypeUrl: utils.GetEventValue(event, "msg_type_url"),
+		Height:     payload.Height,
+		TxHash:     payload.TxHash,
+		Timestamp:  payload.Timestamp,
+	})
+	return nil
+}
+
+func init() {
+	authzExtractor.RegisterType("cosmos.authz.v1beta1.EventGrant", grantAuthz)
+	authzExtractor.RegisterType("cosmos.authz.v1beta1.EventRevoke", revokeAuthz)
+}
@@ -0,0 +1,86 @@
+package extractor_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	. "github.com/likecoin/likecoin-chain-tx-indexer/db"
+	"github.com/likecoin/likecoin-chain-tx-indexer/extractor"
+	. "github.com/likecoin/likecoin-chain-tx-indexer/test"
+)
+
+func grantMessageAndEvents(granter, grantee, authorization, msgTypeUrl, expiration string) (string, string) {
+	message := fmt.Sprintf(
+		`{"@type":"/cosmos.authz.v1beta1.MsgGrant","granter":"%s","grantee":"%s","grant":{"authorization":%s,"expiration":"%s"}}`,
+		granter, grantee, authorization, expiration,
+	)
+	events := fmt.Sprintf(
+		`{"events":[{"type":"cosmos.authz.v1beta1.EventGrant","attributes":[{"key":"grantee","value":"\"%s\""},{"key":"granter","value":"\"%s\""},{"key":"msg_type_url","value":"\"%s\""}]},{"type":"message","attributes":[{"key":"action","value":"/cosmos.authz.v1beta1.MsgGrant"}]}]}`,
+		grantee, granter, msgTypeUrl,
+	)
+	return message, events
+}
+
+func TestAuthzGrant(t *testing.T) {
+	defer CleanupTestData(Conn)
+	timestamp := time.Unix(1234567890, 0).UTC().Format(time.RFC3339)
+	sendAuthorization := `{"@type":"/cosmos.bank.v1beta1.SendAuthorization","spend_limit":[{"denom":"nanolike","amount":"1000"}]}`
+	nftSendAuthorization := `{"@type":"/cosmos.authz.v1beta1.GenericAuthorization","msg":"/cosmos.nft.v1beta1.MsgSend"}`
+	msg1, events1 := grantMessageAndEvents(ADDR_01_LIKE, ADDR_03_LIKE, sendAuthorization, "/cosmos.bank.v1beta1.MsgSend", "2099-01-01T00:00:00Z")
+	msg2, events2 := grantMessageAndEvents(ADDR_01_LIKE, ADDR_04_LIKE, nftSendAuthorization, "/cosmos.nft.v1beta1.MsgSend", "2020-01-01T00:00:00Z")
+	msg3, events3 := grantMessageAndEvents(ADDR_02_LIKE, ADDR_03_LIKE, sendAuthorization, "/cosmos.bank.v1beta1.MsgSend", "2099-01-01T00:00:00Z")
+	msg4, events4 := grantMessageAndEvents(ADDR_01_LIKE, ADDR_03_LIKE, sendAuthorization, "/cosmos.bank.v1beta1.MsgSend", "2099-06-01T00:00:00Z")
+	txs := []string{
+		fmt.Sprintf(
+			`{"height":"10","txhash":"AAAAAA","tx":{"body":{"messages":[%s,%s,%s],"memo":""}},"logs":[%s,%s,%s],"timestamp":"%s"}`,
+			msg1, msg2, msg3, events1, events2, events3, timestamp,
+		),
+		// replacing the grant of ADDR_01 to ADDR_03
+		fmt.Sprintf(
+			`{"height":"20","txhash":"BBBBBB","tx":{"body":{"messages":[%s],"memo":""}},"logs":[%s],"timestamp":"%s"}`,
+			msg4, events4, timestamp,
+		),
+		fmt.Sprintf(
+			`{"height":"30","txhash":"CCCCCC","tx":{"body":{"messages":[{"@type":"/cosmos.authz.v1beta1.MsgRevoke","granter":"%[1]s","grantee":"%[2]s","msg_type_url":"/cosmos.bank.v1beta1.MsgSend"}],"memo":""}},"logs":[{"events":[{"type":"cosmos.authz.v1beta1.EventRevoke","attributes":[{"key":"grantee","value":"\"%[2]s\""},{"key":"granter","value":"\"%[1]s\""},{"key":"msg_type_url","value":"\"/cosmos.bank.v1beta1.MsgSend\""}]},{"type":"message","attributes":[{"key":"action","value":"/cosmos.authz.v1beta1.MsgRevoke"}]}]}],"timestamp":"%[3]s"}`,
+			ADDR_02_LIKE, ADDR_03_LIKE, timestamp,
+		),
+	}
+	InsertTestData(DBTestData{Txs: txs})
+
+	finished, err := ExtractNamed(Conn, extractor.Extractors)
+	require.NoError(t, err)
+	require.True(t, finished)
+
+	p := PageRequest{Limit: 10}
+	res, err := GetAuthzGrants(Conn, QueryAuthzGrantsRequest{Grantee: ADDR_03_COSMOS}, p)
+	require.NoError(t, err)
+	require.Len(t, res.Grants, 3)
+	require.Equal(t, ADDR_01_LIKE, res.Grants[0].Granter)
+	require.Equal(t, "/cosmos.bank.v1beta1.SendAuthorization", res.Grants[0].AuthorizationType)
+	require.Equal(t, "/cosmos.bank.v1beta1.MsgSend", res.Grants[0].MsgTypeUrl)
+	require.Equal(t, "BBBBBB", *res.Grants[0].RevokedTxHash)
+	require.False(t, res.Grants[0].Active)
+	require.Equal(t, ADDR_02_LIKE, res.Grants[1].Granter)
+	require.Equal(t, int64(30), *res.Grants[1].RevokedHeight)
+	require.False(t, res.Grants[1].Active)
+	require.Equal(t, "BBBBBB", res.Grants[2].TxHash)
+	require.Nil(t, res.Grants[2].RevokedAt)
+	require.Equal(t, time.Date(2099, 6, 1, 0, 0, 0, 0, time.UTC), res.Grants[2].Expiration.UTC())
+	require.True(t, res.Grants[2].Active)
+
+	res, err = GetAuthzGrants(Conn, QueryAuthzGrantsRequest{Granter: ADDR_01_LIKE, ActiveOnly: true}, p)
+	require.NoError(t, err)
+	require.Len(t, res.Grants, 1)
+	require.Equal(t, ADDR_03_LIKE, res.Grants[0].Grantee)
+
+	// expired
+	res, err = GetAuthzGrants(Conn, QueryAuthzGrantsRequest{Grantee: ADDR_04_LIKE}, p)
+	require.NoError(t, err)
+	require.Len(t, res.Grants, 1)
+	require.Equal(t, "/cosmos.authz.v1beta1.GenericAuthorization", res.Grants[0].AuthorizationType)
+	require.Nil(t, res.Grants[0].RevokedAt)
+	require.False(t, res.Grants[0].Active)
+}
@@ -0,0 +1,51 @@
+package extractor
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/likecoin/likecoin-chain-tx-indexer/db"
+	"github.com/likecoin/likecoin-chain-tx-indexer/utils"
+)
+
+const (
+	CmdApiAddresses = "api-address"
+)
+
+var (
+	DefaultApiAddresses = []string{"like17m4vwrnhjmd20uu7tst7nv0kap6ee7js69jfrs"}
+
+	// ApiAddresses are the grantees executing the token sends of NFT sales through authz, see sendNft.
+	// They are also the default API sender addresses for the NFT ranking and stats of the HTTP API.
+	ApiAddresses = DefaultApiAddresses
+)
+
+// ConfigExtractorCmd is not named ConfigCmd as the other packages, since the internal tests of the package dot-import
+// db, which has its own ConfigCmd
+func ConfigExtractorCmd(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringSlice(CmdApiAddresses, DefaultApiAddresses, "API addresses executing the token sends of NFT sales through authz, also the default API sender addresses for NFT ranking and stats")
+}
+
+func SetupExtractorFromCmdArgs(cmd *cobra.Command) {
+	apiAddresses, err := cmd.Flags().GetStringSlice(CmdApiAddresses)
+	if err != nil {
+		panic(err)
+	}
+	ApiAddresses = apiAddresses
+}
+
+func isApiAddress(address string) bool {
+	converted, err := utils.ConvertAddressPrefix(address, db.MainAddressPrefix)
+	if err == nil {
+		address = converted
+	}
+	for _, apiAddress := range ApiAddresses {
+		convertedApiAddress, err := utils.ConvertAddressPrefix(apiAddress, db.MainAddressPrefix)
+		if err == nil {
+			apiAddress = convertedApiAddress
+		}
+		if address == apiAddress {
+			return true
+		}
+	}
+	return false
+}
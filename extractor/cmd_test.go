@@ -0,0 +1,24 @@
+package extractor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	. "github.com/likecoin/likecoin-chain-tx-indexer/test"
+)
+
+func TestIsApiAddress(t *testing.T) {
+	defer func() { ApiAddresses = DefaultApiAddresses }()
+	require.True(t, isApiAddress(DefaultApiAddresses[0]))
+	require.False(t, isApiAddress(ADDR_01_LIKE))
+
+	ApiAddresses = []string{ADDR_02_COSMOS}
+	require.False(t, isApiAddress(ADDR_01_LIKE))
+	require.True(t, isApiAddress(ADDR_02_LIKE))
+	require.True(t, isApiAddress(ADDR_02_COSMOS))
+	require.False(t, isApiAddress(""))
+
+	ApiAddresses = []string{}
+	require.False(t, isApiAddress(ADDR_01_LIKE))
+}
@@ -2,7 +2,10 @@ package extractor
 
 import (
 	"encoding/json"
+	"reflect"
+	"runtime"
 	"strconv"
+	"strings"
 
 	"github.com/cosmos/cosmos-sdk/types"
 
@@ -11,8 +14,6 @@ import (
 	"github.com/likecoin/likecoin-chain-tx-indexer/utils"
 )
 
-var eventExtractor = NewEventExtractor()
-
 func extractAuthzEventsList(events types.StringEvents) (db.EventsList, error) {
 	authzEvents := make(map[int]types.StringEvents)
 	specialEvents := []types.StringEvent{}
@@ -80,9 +81,41 @@ func extractAuthzMessages(msg json.RawMessage) ([]json.RawMessage, error) {
 	return msgExec.Msgs, nil
 }
 
+func getAuthzGrantee(msg json.RawMessage) string {
+	var msgExec struct {
+		Grantee string `json:"grantee"`
+	}
+	if err := json.Unmarshal(msg, &msgExec); err != nil {
+		logger.L.Warnw("Failed to unmarshal MsgExec", "error", err)
+		return ""
+	}
+	return msgExec.Grantee
+}
+
+// getMessageSigner returns the signer of the message from the common signer fields of the messages
+func getMessageSigner(msg json.RawMessage) string {
+	var signers struct {
+		Creator     string `json:"creator"`
+		Sender      string `json:"sender"`
+		From        string `json:"from"`
+		FromAddress string `json:"from_address"`
+	}
+	if err := json.Unmarshal(msg, &signers); err != nil {
+		logger.L.Warnw("Failed to unmarshal message for signer", "error", err)
+		return ""
+	}
+	for _, signer := range []string{signers.Creator, signers.Sender, signers.From, signers.FromAddress} {
+		if signer != "" {
+			return signer
+		}
+	}
+	return ""
+}
+
 func EventContextFromAuthz(ctx db.EventContext, msgIndex int) (db.EventContext, error) {
 	authzCtx := ctx
 	authzCtx.AuthzParent = &ctx
+	authzCtx.AuthzMsgIndex = msgIndex
 	var err error
 	authzCtx.Messages, err = extractAuthzMessages(ctx.Messages[msgIndex])
 	if err != nil {
@@ -123,13 +156,28 @@ func (payload *Payload) GetEvents() types.StringEvents {
 	return payload.EventsList[payload.MsgIndex].Events
 }
 
+// GetAuthzActors returns the grantee executing the message through MsgExec and the granter signing the message, or
+// empty strings if the message is not executed through authz
+func (payload *Payload) GetAuthzActors() (executor string, granter string) {
+	if payload.AuthzParent == nil {
+		return "", ""
+	}
+	executor = getAuthzGrantee(payload.AuthzParent.Messages[payload.AuthzMsgIndex])
+	granter = getMessageSigner(payload.GetMessage())
+	return executor, granter
+}
+
 type EventProcessor func(payload *Payload, event *types.StringEvent) error
 
+// TxProcessor processes each tx once, e.g. for the fee, instead of the events of each message
+type TxProcessor func(ctx db.EventContext) error
+
 type EventExtractor struct {
 	typeKeyValueMap map[string]map[string]map[string][]EventProcessor
 	typeKeyMap      map[string]map[string][]EventProcessor
 	typeMap         map[string][]EventProcessor
 	wildcards       []EventProcessor
+	txProcessors    []TxProcessor
 }
 
 func NewEventExtractor() *EventExtractor {
@@ -165,15 +213,39 @@ func (e *EventExtractor) RegisterAll(processor EventProcessor) {
 	e.wildcards = append(e.wildcards, processor)
 }
 
+func (e *EventExtractor) RegisterTx(processor TxProcessor) {
+	e.txProcessors = append(e.txProcessors, processor)
+}
+
 func (e *EventExtractor) runProcessors(payload *Payload, event *types.StringEvent, processors []EventProcessor) error {
 	for _, processor := range processors {
 		if err := processor(payload, event); err != nil {
-			return err
+			return processorError(payload, event, processor, err)
 		}
 	}
 	return nil
 }
 
+// processorError records where the processor failed, for the extraction_failures table
+func processorError(payload *Payload, event *types.StringEvent, processor EventProcessor, err error) error {
+	eventType := ""
+	if event != nil {
+		eventType = event.Type
+	}
+	return &db.ExtractionError{
+		MessageIndex: payload.MsgIndex,
+		EventType:    eventType,
+		Processor:    funcName(processor),
+		Err:          err,
+	}
+}
+
+// funcName returns the name of the function, e.g. "extractor.insertIscn"
+func funcName(f interface{}) string {
+	name := runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+	return name[strings.LastIndex(name, "/")+1:]
+}
+
 func (e *EventExtractor) extractTypeKeyValue(payload *Payload, event *types.StringEvent) error {
 	kvMap := e.typeKeyValueMap[event.Type]
 	if kvMap == nil {
@@ -223,6 +295,18 @@ func (e *EventExtractor) extractAll(payload *Payload) error {
 }
 
 func (e *EventExtractor) Extract(ctx db.EventContext) error {
+	// the messages executed by authz are extracted recursively in the same tx
+	if ctx.AuthzParent == nil {
+		for _, processor := range e.txProcessors {
+			if err := processor(ctx); err != nil {
+				return &db.ExtractionError{
+					MessageIndex: -1,
+					Processor:    funcName(processor),
+					Err:          err,
+				}
+			}
+		}
+	}
 	payload := PayloadFromEventContext(ctx)
 	for payload.Next() {
 		events := payload.GetEvents()
@@ -9,8 +9,51 @@ import (
 	"github.com/likecoin/likecoin-chain-tx-indexer/logger"
 )
 
+const marketplaceExtractorName = "marketplace"
+
+// ExtractFunc runs all the extractors on a tx, for extracting with the global META_EXTRACTOR checkpoint
 var ExtractFunc db.Extractor
 
+// Extractors are the named extractors, each with its own checkpoint.
+// They run in the order of registration on each tx, since later extractors may depend on the data extracted by the
+// earlier ones.
+var Extractors []db.NamedExtractor
+
+// authz runs before nft, since the price of an NFT sale is counted with the authz grants extracted from the same tx.
+var (
+	iscnExtractor        = Register("iscn", "iscn", "iscn_stakeholders", "iscn_latest_version", "iscn_event")
+	authzExtractor       = Register("authz", "authz_grant")
+	nftExtractor         = Register("nft", "authz_grant", "nft_class", "nft", "nft_event", "nft_income", "nft_class_mint_period", "nft_class_royalty_stakeholder", "nft_class_config_history")
+	marketplaceExtractor = Register(marketplaceExtractorName, "nft_class", "nft", "nft_event", "nft_income", "nft_marketplace", "nft_marketplace_history", "nft_marketplace_floor_price")
+	tokenExtractor       = Register("token", "token_transfer", "token_balance_change")
+	stakingExtractor     = Register("staking", "staking_event")
+	govExtractor         = Register("gov", "gov_proposal", "gov_deposit", "gov_vote")
+	ibcExtractor         = Register("ibc", "ibc_transfer")
+)
+
+// Register creates an event extractor with its own checkpoint under the name.
+// A newly registered extractor starts from height 0, and catches up alone without reprocessing the others.
+// The tables written by the extractor are listed with parent tables before the ones referencing them, together with
+// the tables of other extractors read by it.
+func Register(name string, tables ...string) *EventExtractor {
+	e := NewEventExtractor()
+	Extractors = append(Extractors, db.NamedExtractor{Name: name, Extractor: e.Extract, Tables: tables})
+	return e
+}
+
+// setAfterRound sets the function running after each round of extraction of the extractor
+func setAfterRound(name string, afterRound func(conn *pgxpool.Conn, height int64, silent bool) error) {
+	for i := range Extractors {
+		if Extractors[i].Name == name {
+			Extractors[i].AfterRound = afterRound
+		}
+	}
+}
+
+func extractAll(ctx db.EventContext) error {
+	return db.CombineExtractors(Extractors)(ctx)
+}
+
 // TODO: should we make extractor synchronous with poller instead of async?
 func Run(pool *pgxpool.Pool) chan<- int64 {
 	trigger := make(chan int64, 100)
@@ -32,7 +75,7 @@ func Run(pool *pgxpool.Pool) chan<- int64 {
 					continue
 				}
 			}
-			finished, err = db.Extract(conn, ExtractFunc)
+			finished, err = db.ExtractNamed(conn, Extractors)
 			if err != nil {
 				logger.L.Errorw("Extract error", "error", err)
 				time.Sleep(5 * time.Second)
@@ -48,5 +91,6 @@ func Run(pool *pgxpool.Pool) chan<- int64 {
 }
 
 func init() {
-	ExtractFunc = eventExtractor.Extract
+	ExtractFunc = extractAll
+	setAfterRound(marketplaceExtractorName, expireMarketplaceItems)
 }
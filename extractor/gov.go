@@ -0,0 +1,166 @@
+package extractor
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/likecoin/likecoin-chain-tx-indexer/db"
+	"github.com/likecoin/likecoin-chain-tx-indexer/utils"
+)
+
+type govContent struct {
+	Type        string `json:"@type"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+// submitProposalMessage has the fields of both gov v1beta1 MsgSubmitProposal with content, and gov v1
+// MsgSubmitProposal with messages
+type submitProposalMessage struct {
+	Proposer string          `json:"proposer"`
+	Content  json.RawMessage `json:"content"`
+	Messages json.RawMessage `json:"messages"`
+	Title    string          `json:"title"`
+	Summary  string          `json:"summary"`
+}
+
+func getProposalId(event *types.StringEvent) (uint64, error) {
+	proposalIdStr := utils.GetEventValue(event, "proposal_id")
+	proposalId, err := strconv.ParseUint(proposalIdStr, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse proposal id %s: %w", proposalIdStr, err)
+	}
+	return proposalId, nil
+}
+
+func submitProposal(payload *Payload, event *types.StringEvent) error {
+	proposalId, err := getProposalId(event)
+	if err != nil {
+		return err
+	}
+	var message submitProposalMessage
+	if err := json.Unmarshal(payload.GetMessage(), &message); err != nil {
+		return fmt.Errorf("failed to unmarshal submit proposal message: %w", err)
+	}
+	p := db.GovProposal{
+		ProposalId:   proposalId,
+		Height:       payload.Height,
+		TxHash:       payload.TxHash,
+		Proposer:     message.Proposer,
+		ProposalType: utils.GetEventValue(event, "proposal_type"),
+		Title:        message.Title,
+		Description:  message.Summary,
+		Content:      message.Content,
+		Timestamp:    payload.Timestamp,
+	}
+	if len(message.Content) > 0 {
+		var content govContent
+		if err := json.Unmarshal(message.Content, &content); err != nil {
+			return fmt.Errorf("failed to unmarshal proposal content: %w", err)
+		}
+		if p.ProposalType == "" {
+			p.ProposalType = content.Type
+		}
+		p.Title = content.Title
+		p.Description = content.Description
+	} else if len(message.Messages) > 0 {
+		p.Content = message.Messages
+		var messages []govContent
+		if err := json.Unmarshal(message.Messages, &messages); err != nil {
+			return fmt.Errorf("failed to unmarshal proposal messages: %w", err)
+		}
+		if p.ProposalType == "" && len(messages) > 0 {
+			p.ProposalType = messages[0].Type
+		}
+	}
+	payload.Batch.InsertGovProposal(p)
+	return nil
+}
+
+// depositProposal records the deposits of both MsgDeposit and the initial deposit of MsgSubmitProposal
+func depositProposal(payload *Payload, event *types.StringEvent) error {
+	proposalId, err := getProposalId(event)
+	if err != nil {
+		return err
+	}
+	var message struct {
+		Depositor string `json:"depositor"`
+		Proposer  string `json:"proposer"`
+	}
+	if err := json.Unmarshal(payload.GetMessage(), &message); err != nil {
+		return fmt.Errorf("failed to unmarshal deposit message: %w", err)
+	}
+	depositor := message.Depositor
+	if depositor == "" {
+		depositor = message.Proposer
+	}
+	amount := utils.GetEventValue(event, "amount")
+	coins, err := types.ParseCoinsNormalized(amount)
+	if err != nil {
+		return fmt.Errorf("failed to parse deposit amount %s: %w", amount, err)
+	}
+	for _, coin := range coins {
+		payload.Batch.InsertGovDeposit(db.GovDeposit{
+			ProposalId:   proposalId,
+			Height:       payload.Height,
+			TxHash:       payload.TxHash,
+			MessageIndex: payload.MsgIndex,
+			Depositor:    depositor,
+			Denom:        coin.Denom,
+			Amount:       coin.Amount.String(),
+			Timestamp:    payload.Timestamp,
+		})
+	}
+	return nil
+}
+
+type weightedVoteOption struct {
+	Option string `json:"option"`
+	Weight string `json:"weight"`
+}
+
+// vote records the options from the message, since the format of the option attribute differs between SDK versions
+func vote(payload *Payload, event *types.StringEvent) error {
+	proposalId, err := getProposalId(event)
+	if err != nil {
+		return err
+	}
+	// MsgVote has option, and MsgVoteWeighted has options
+	var message struct {
+		Voter   string               `json:"voter"`
+		Option  string               `json:"option"`
+		Options []weightedVoteOption `json:"options"`
+	}
+	if err := json.Unmarshal(payload.GetMessage(), &message); err != nil {
+		return fmt.Errorf("failed to unmarshal vote message: %w", err)
+	}
+	options := message.Options
+	if len(options) == 0 {
+		options = []weightedVoteOption{{Option: message.Option, Weight: "1"}}
+	}
+	for _, option := range options {
+		if option.Option == "" {
+			return fmt.Errorf("no option in vote message")
+		}
+		payload.Batch.InsertGovVote(db.GovVote{
+			ProposalId:   proposalId,
+			Height:       payload.Height,
+			TxHash:       payload.TxHash,
+			MessageIndex: payload.MsgIndex,
+			Voter:        message.Voter,
+			Option:       option.Option,
+			Weight:       option.Weight,
+			Timestamp:    payload.Timestamp,
+		})
+	}
+	return nil
+}
+
+func init() {
+	govExtractor.RegisterType("submit_proposal", submitProposal)
+	govExtractor.RegisterType("proposal_deposit", depositProposal)
+	govExtractor.RegisterType("proposal_vote", vote)
+}
@@ -0,0 +1,102 @@
+package extractor_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	. "github.com/likecoin/likecoin-chain-tx-indexer/db"
+	"github.com/likecoin/likecoin-chain-tx-indexer/extractor"
+	. "github.com/likecoin/likecoin-chain-tx-indexer/test"
+	"github.com/likecoin/likecoin-chain-tx-indexer/utils"
+)
+
+func TestGov(t *testing.T) {
+	defer CleanupTestData(Conn)
+	timestamp := time.Unix(1234567890, 0).UTC().Format(time.RFC3339)
+	validator, err := utils.ConvertAddressPrefix(ADDR_03_LIKE, MainValidatorAddressPrefix)
+	require.NoError(t, err)
+	txs := []string{
+		// validator operated by ADDR_03
+		fmt.Sprintf(
+			`{"height":"10","txhash":"AAAAAA","tx":{"body":{"messages":[{"@type":"/cosmos.staking.v1beta1.MsgCreateValidator","delegator_address":"%[1]s","validator_address":"%[2]s","value":{"denom":"nanolike","amount":"1000"}}],"memo":""}},"logs":[{"msg_index":0,"log":"","events":[{"type":"create_validator","attributes":[{"key":"validator","value":"%[2]s"},{"key":"amount","value":"1000nanolike"}]}]}],"timestamp":"%[3]s"}`,
+			ADDR_03_LIKE, validator, timestamp,
+		),
+		fmt.Sprintf(
+			`{"height":"20","txhash":"BBBBBB","tx":{"body":{"messages":[{"@type":"/cosmos.gov.v1beta1.MsgSubmitProposal","content":{"@type":"/cosmos.gov.v1beta1.TextProposal","title":"Proposal","description":"Some description"},"initial_deposit":[{"denom":"nanolike","amount":"1000"}],"proposer":"%[1]s"}],"memo":""}},"logs":[{"msg_index":0,"log":"","events":[{"type":"proposal_deposit","attributes":[{"key":"amount","value":"1000nanolike"},{"key":"proposal_id","value":"1"}]},{"type":"submit_proposal","attributes":[{"key":"proposal_id","value":"1"},{"key":"proposal_type","value":"Text"}]}]}],"timestamp":"%[2]s"}`,
+			ADDR_01_LIKE, timestamp,
+		),
+		fmt.Sprintf(
+			`{"height":"30","txhash":"CCCCCC","tx":{"body":{"messages":[{"@type":"/cosmos.gov.v1beta1.MsgDeposit","proposal_id":"1","depositor":"%[1]s","amount":[{"denom":"nanolike","amount":"500"}]},{"@type":"/cosmos.gov.v1beta1.MsgVote","proposal_id":"1","voter":"%[1]s","option":"VOTE_OPTION_NO"},{"@type":"/cosmos.gov.v1beta1.MsgVoteWeighted","proposal_id":"1","voter":"%[2]s","options":[{"option":"VOTE_OPTION_YES","weight":"0.700000000000000000"},{"option":"VOTE_OPTION_ABSTAIN","weight":"0.300000000000000000"}]}],"memo":""}},"logs":[{"msg_index":0,"log":"","events":[{"type":"proposal_deposit","attributes":[{"key":"amount","value":"500nanolike"},{"key":"proposal_id","value":"1"}]}]},{"msg_index":1,"log":"","events":[{"type":"proposal_vote","attributes":[{"key":"option","value":"option:VOTE_OPTION_NO weight:\"1.000000000000000000\""},{"key":"proposal_id","value":"1"}]}]},{"msg_index":2,"log":"","events":[{"type":"proposal_vote","attributes":[{"key":"option","value":"option:VOTE_OPTION_YES weight:\"0.700000000000000000\"\noption:VOTE_OPTION_ABSTAIN weight:\"0.300000000000000000\""},{"key":"proposal_id","value":"1"}]}]}],"timestamp":"%[3]s"}`,
+			ADDR_02_LIKE, ADDR_03_LIKE, timestamp,
+		),
+		// vote executed by ADDR_04 through authz, and ADDR_02 changing the vote
+		fmt.Sprintf(
+			`{"height":"40","txhash":"DDDDDD","tx":{"body":{"messages":[{"@type":"/cosmos.authz.v1beta1.MsgExec","grantee":"%[3]s","msgs":[{"@type":"/cosmos.gov.v1beta1.MsgVote","proposal_id":"1","voter":"%[1]s","option":"VOTE_OPTION_YES"}]},{"@type":"/cosmos.gov.v1beta1.MsgVote","proposal_id":"1","voter":"%[2]s","option":"VOTE_OPTION_YES"}],"memo":""}},"logs":[{"msg_index":0,"log":"","events":[{"type":"message","attributes":[{"key":"action","value":"/cosmos.authz.v1beta1.MsgExec"},{"key":"module","value":"governance"},{"key":"sender","value":"%[1]s"},{"key":"authz_msg_index","value":"0"}]},{"type":"proposal_vote","attributes":[{"key":"option","value":"option:VOTE_OPTION_YES weight:\"1.000000000000000000\""},{"key":"proposal_id","value":"1"},{"key":"authz_msg_index","value":"0"}]}]},{"msg_index":1,"log":"","events":[{"type":"proposal_vote","attributes":[{"key":"option","value":"option:VOTE_OPTION_YES weight:\"1.000000000000000000\""},{"key":"proposal_id","value":"1"}]}]}],"timestamp":"%[4]s"}`,
+			ADDR_01_LIKE, ADDR_02_LIKE, ADDR_04_LIKE, timestamp,
+		),
+	}
+	InsertTestData(DBTestData{Txs: txs})
+
+	finished, err := ExtractNamed(Conn, extractor.Extractors)
+	require.NoError(t, err)
+	require.True(t, finished)
+
+	p := PageRequest{Limit: 10}
+	proposals, err := GetGovProposals(Conn, QueryGovProposalsRequest{Proposer: ADDR_01_COSMOS}, p)
+	require.NoError(t, err)
+	require.Len(t, proposals.Proposals, 1)
+	require.Equal(t, uint64(1), proposals.Proposals[0].ProposalId)
+	require.Equal(t, ADDR_01_LIKE, proposals.Proposals[0].Proposer)
+	require.Equal(t, "Text", proposals.Proposals[0].ProposalType)
+	require.Equal(t, "Proposal", proposals.Proposals[0].Title)
+	require.Equal(t, "Some description", proposals.Proposals[0].Description)
+
+	deposits, err := GetGovDeposits(Conn, 1, p)
+	require.NoError(t, err)
+	require.Len(t, deposits.Deposits, 2)
+	require.Equal(t, ADDR_01_LIKE, deposits.Deposits[0].Depositor)
+	require.Equal(t, "1000", deposits.Deposits[0].Amount)
+	require.Equal(t, ADDR_02_LIKE, deposits.Deposits[1].Depositor)
+	require.Equal(t, "500", deposits.Deposits[1].Amount)
+
+	votes, err := GetGovVotes(Conn, QueryGovVotesRequest{ProposalId: 1}, p)
+	require.NoError(t, err)
+	require.Len(t, votes.Votes, 5)
+	require.Equal(t, ADDR_02_LIKE, votes.Votes[0].Voter)
+	require.Equal(t, "VOTE_OPTION_NO", votes.Votes[0].Option)
+	require.Equal(t, "1", votes.Votes[0].Weight)
+	require.Equal(t, ADDR_03_LIKE, votes.Votes[1].Voter)
+	require.Equal(t, "VOTE_OPTION_YES", votes.Votes[1].Option)
+	require.Equal(t, "0.700000000000000000", votes.Votes[1].Weight)
+	require.Equal(t, ADDR_01_LIKE, votes.Votes[3].Voter)
+	require.Equal(t, "DDDDDD", votes.Votes[3].TxHash)
+
+	votes, err = GetGovVotes(Conn, QueryGovVotesRequest{Voter: ADDR_02_COSMOS}, p)
+	require.NoError(t, err)
+	require.Len(t, votes.Votes, 2)
+	require.Equal(t, "VOTE_OPTION_NO", votes.Votes[0].Option)
+	require.Equal(t, "VOTE_OPTION_YES", votes.Votes[1].Option)
+
+	turnout, err := GetGovTurnout(Conn, 1)
+	require.NoError(t, err)
+	require.Equal(t, []GovTurnout{
+		{
+			VoterType: GOV_VOTER_TYPE_DELEGATOR,
+			Voters:    2,
+			Options: []GovTurnoutOption{
+				{Option: "VOTE_OPTION_YES", Voters: 2, Weight: "2"},
+			},
+		},
+		{
+			VoterType: GOV_VOTER_TYPE_VALIDATOR,
+			Voters:    1,
+			Options: []GovTurnoutOption{
+				{Option: "VOTE_OPTION_ABSTAIN", Voters: 1, Weight: "0.300000000000000000"},
+				{Option: "VOTE_OPTION_YES", Voters: 1, Weight: "0.700000000000000000"},
+			},
+		},
+	}, turnout.Turnout)
+}
@@ -0,0 +1,147 @@
+package extractor
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/likecoin/likecoin-chain-tx-indexer/db"
+	"github.com/likecoin/likecoin-chain-tx-indexer/utils"
+)
+
+// port of ICS-20 fungible token transfers
+const ibcTransferPort = "transfer"
+
+// fungibleTokenPacketData is the packet data of ICS-20 transfers, where the denom is the denom trace on the sending
+// chain, e.g. "nanolike" or "transfer/channel-0/uosmo"
+type fungibleTokenPacketData struct {
+	Denom    string `json:"denom"`
+	Amount   string `json:"amount"`
+	Sender   string `json:"sender"`
+	Receiver string `json:"receiver"`
+}
+
+func getPacketSequence(event *types.StringEvent) (uint64, error) {
+	sequenceStr := utils.GetEventValue(event, "packet_sequence")
+	sequence, err := strconv.ParseUint(sequenceStr, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse packet sequence %s: %w", sequenceStr, err)
+	}
+	return sequence, nil
+}
+
+func parseIbcTransfer(payload *Payload, event *types.StringEvent, direction string, status string) (db.IbcTransfer, error) {
+	var data fungibleTokenPacketData
+	packetData := utils.GetEventValue(event, "packet_data")
+	if err := json.Unmarshal([]byte(packetData), &data); err != nil {
+		return db.IbcTransfer{}, fmt.Errorf("failed to unmarshal packet data %s: %w", packetData, err)
+	}
+	sequence, err := getPacketSequence(event)
+	if err != nil {
+		return db.IbcTransfer{}, err
+	}
+	var timeoutTimestamp uint64
+	timeoutTimestampStr := utils.GetEventValue(event, "packet_timeout_timestamp")
+	if timeoutTimestampStr != "" {
+		timeoutTimestamp, err = strconv.ParseUint(timeoutTimestampStr, 10, 64)
+		if err != nil {
+			return db.IbcTransfer{}, fmt.Errorf("failed to parse packet timeout timestamp %s: %w", timeoutTimestampStr, err)
+		}
+	}
+	return db.IbcTransfer{
+		Direction:        direction,
+		SrcPort:          utils.GetEventValue(event, "packet_src_port"),
+		SrcChannel:       utils.GetEventValue(event, "packet_src_channel"),
+		DstPort:          utils.GetEventValue(event, "packet_dst_port"),
+		DstChannel:       utils.GetEventValue(event, "packet_dst_channel"),
+		Sequence:         sequence,
+		Sender:           data.Sender,
+		Receiver:         data.Receiver,
+		Denom:            data.Denom,
+		Amount:           data.Amount,
+		Status:           status,
+		TimeoutHeight:    utils.GetEventValue(event, "packet_timeout_height"),
+		TimeoutTimestamp: timeoutTimestamp,
+		Height:           payload.Height,
+		TxHash:           payload.TxHash,
+		Timestamp:        payload.Timestamp,
+	}, nil
+}
+
+func sendPacket(payload *Payload, event *types.StringEvent) error {
+	if utils.GetEventValue(event, "packet_src_port") != ibcTransferPort {
+		return nil
+	}
+	t, err := parseIbcTransfer(payload, event, db.IBC_TRANSFER_DIRECTION_SEND, db.IBC_TRANSFER_STATUS_PENDING)
+	if err != nil {
+		return err
+	}
+	payload.Batch.InsertIbcTransfer(t)
+	return nil
+}
+
+// recvPacket records the received transfer, which fails if the transfer module writes an error acknowledgement
+func recvPacket(payload *Payload, event *types.StringEvent) error {
+	if utils.GetEventValue(event, "packet_dst_port") != ibcTransferPort {
+		return nil
+	}
+	t, err := parseIbcTransfer(payload, event, db.IBC_TRANSFER_DIRECTION_RECEIVE, db.IBC_TRANSFER_STATUS_RECEIVED)
+	if err != nil {
+		return err
+	}
+	events := payload.GetEvents()
+	if utils.GetEventsValue(events, "fungible_token_packet", "success") == "false" {
+		t.Status = db.IBC_TRANSFER_STATUS_FAILED
+		t.Error = utils.GetEventsValue(events, "fungible_token_packet", "error")
+	}
+	payload.Batch.InsertIbcTransfer(t)
+	return nil
+}
+
+func completeSentPacket(payload *Payload, event *types.StringEvent, status string, errorMessage string) error {
+	sequence, err := getPacketSequence(event)
+	if err != nil {
+		return err
+	}
+	payload.Batch.CompleteIbcTransfer(db.IbcPacketCompletion{
+		SrcPort:    utils.GetEventValue(event, "packet_src_port"),
+		SrcChannel: utils.GetEventValue(event, "packet_src_channel"),
+		Sequence:   sequence,
+		Status:     status,
+		Error:      errorMessage,
+		Height:     payload.Height,
+		TxHash:     payload.TxHash,
+		Timestamp:  payload.Timestamp,
+	})
+	return nil
+}
+
+// acknowledgePacket completes the sent transfer, which fails with the error acknowledgement from the counterparty,
+// where the tokens are refunded to the sender
+func acknowledgePacket(payload *Payload, event *types.StringEvent) error {
+	if utils.GetEventValue(event, "packet_src_port") != ibcTransferPort {
+		return nil
+	}
+	errorMessage := utils.GetEventsValue(payload.GetEvents(), "fungible_token_packet", "error")
+	status := db.IBC_TRANSFER_STATUS_ACKNOWLEDGED
+	if errorMessage != "" {
+		status = db.IBC_TRANSFER_STATUS_FAILED
+	}
+	return completeSentPacket(payload, event, status, errorMessage)
+}
+
+func timeoutPacket(payload *Payload, event *types.StringEvent) error {
+	if utils.GetEventValue(event, "packet_src_port") != ibcTransferPort {
+		return nil
+	}
+	return completeSentPacket(payload, event, db.IBC_TRANSFER_STATUS_TIMED_OUT, "")
+}
+
+func init() {
+	ibcExtractor.RegisterType("send_packet", sendPacket)
+	ibcExtractor.RegisterType("recv_packet", recvPacket)
+	ibcExtractor.RegisterType("acknowledge_packet", acknowledgePacket)
+	ibcExtractor.RegisterType("timeout_packet", timeoutPacket)
+}
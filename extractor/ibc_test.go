@@ -0,0 +1,100 @@
+package extractor_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	. "github.com/likecoin/likecoin-chain-tx-indexer/db"
+	"github.com/likecoin/likecoin-chain-tx-indexer/extractor"
+	. "github.com/likecoin/likecoin-chain-tx-indexer/test"
+)
+
+func ibcPacketAttributes(srcChannel, dstChannel string, sequence int, sender, receiver, denom string) string {
+	return fmt.Sprintf(
+		`{"key":"packet_data","value":"{\"amount\":\"100\",\"denom\":\"%[5]s\",\"receiver\":\"%[4]s\",\"sender\":\"%[3]s\"}"},{"key":"packet_timeout_height","value":"1-1000"},{"key":"packet_timeout_timestamp","value":"1234567890000000000"},{"key":"packet_sequence","value":"%[6]d"},{"key":"packet_src_port","value":"transfer"},{"key":"packet_src_channel","value":"%[1]s"},{"key":"packet_dst_port","value":"transfer"},{"key":"packet_dst_channel","value":"%[2]s"}`,
+		srcChannel, dstChannel, sender, receiver, denom, sequence,
+	)
+}
+
+func TestIbcTransfer(t *testing.T) {
+	defer CleanupTestData(Conn)
+	timestamp := time.Unix(1234567890, 0).UTC().Format(time.RFC3339)
+	osmoAddr := "osmo1counterparty"
+	sendTx := func(height int, txHash string, sequence int) string {
+		return fmt.Sprintf(
+			`{"height":"%[1]d","txhash":"%[2]s","tx":{"body":{"messages":[{"@type":"/ibc.applications.transfer.v1.MsgTransfer","source_port":"transfer","source_channel":"channel-0","token":{"denom":"nanolike","amount":"100"},"sender":"%[3]s","receiver":"%[4]s"}],"memo":""}},"logs":[{"msg_index":0,"log":"","events":[{"type":"ibc_transfer","attributes":[{"key":"sender","value":"%[3]s"},{"key":"receiver","value":"%[4]s"}]},{"type":"send_packet","attributes":[%[5]s]}]}],"timestamp":"%[6]s"}`,
+			height, txHash, ADDR_01_LIKE, osmoAddr,
+			ibcPacketAttributes("channel-0", "channel-1", sequence, ADDR_01_LIKE, osmoAddr, "nanolike"),
+			timestamp,
+		)
+	}
+	packetAttributes := func(sequence int) string {
+		return fmt.Sprintf(
+			`{"key":"packet_timeout_height","value":"1-1000"},{"key":"packet_timeout_timestamp","value":"1234567890000000000"},{"key":"packet_sequence","value":"%d"},{"key":"packet_src_port","value":"transfer"},{"key":"packet_src_channel","value":"channel-0"},{"key":"packet_dst_port","value":"transfer"},{"key":"packet_dst_channel","value":"channel-1"}`,
+			sequence,
+		)
+	}
+	txs := []string{
+		sendTx(10, "AAAAAA", 1),
+		sendTx(11, "BBBBBB", 2),
+		sendTx(12, "CCCCCC", 3),
+		sendTx(13, "DDDDDD", 4),
+		// relayer tx with successful and error acknowledgements, and a received transfer
+		fmt.Sprintf(
+			`{"height":"20","txhash":"EEEEEE","tx":{"body":{"messages":[{"@type":"/ibc.core.client.v1.MsgUpdateClient"},{"@type":"/ibc.core.channel.v1.MsgAcknowledgement"},{"@type":"/ibc.core.channel.v1.MsgAcknowledgement"},{"@type":"/ibc.core.channel.v1.MsgRecvPacket"}],"memo":""}},"logs":[{"msg_index":0,"log":"","events":[{"type":"update_client","attributes":[{"key":"client_id","value":"07-tendermint-0"}]}]},{"msg_index":1,"log":"","events":[{"type":"acknowledge_packet","attributes":[%[1]s]},{"type":"fungible_token_packet","attributes":[{"key":"module","value":"transfer"},{"key":"receiver","value":"%[4]s"},{"key":"denom","value":"nanolike"},{"key":"amount","value":"100"},{"key":"acknowledgement","value":"result:\"\\001\" "},{"key":"success","value":"\u0001"}]}]},{"msg_index":2,"log":"","events":[{"type":"acknowledge_packet","attributes":[%[2]s]},{"type":"fungible_token_packet","attributes":[{"key":"module","value":"transfer"},{"key":"receiver","value":"%[4]s"},{"key":"denom","value":"nanolike"},{"key":"amount","value":"100"},{"key":"acknowledgement","value":"error:\"ABCI code: 1: error handling packet: see events for details\" "},{"key":"error","value":"ABCI code: 1: error handling packet: see events for details"}]}]},{"msg_index":3,"log":"","events":[{"type":"recv_packet","attributes":[%[3]s]},{"type":"fungible_token_packet","attributes":[{"key":"module","value":"transfer"},{"key":"sender","value":"%[4]s"},{"key":"receiver","value":"%[5]s"},{"key":"denom","value":"transfer/channel-0/nanolike"},{"key":"amount","value":"100"},{"key":"success","value":"true"}]}]}],"timestamp":"%[6]s"}`,
+			packetAttributes(1), packetAttributes(2),
+			ibcPacketAttributes("channel-1", "channel-0", 5, osmoAddr, ADDR_02_COSMOS, "transfer/channel-0/nanolike"),
+			osmoAddr, ADDR_02_COSMOS, timestamp,
+		),
+		fmt.Sprintf(
+			`{"height":"30","txhash":"FFFFFF","tx":{"body":{"messages":[{"@type":"/ibc.core.channel.v1.MsgTimeout"}],"memo":""}},"logs":[{"msg_index":0,"log":"","events":[{"type":"timeout_packet","attributes":[%[1]s]},{"type":"timeout","attributes":[{"key":"module","value":"transfer"},{"key":"refund_receiver","value":"%[2]s"},{"key":"refund_denom","value":"nanolike"},{"key":"refund_amount","value":"100"}]}]}],"timestamp":"%[3]s"}`,
+			packetAttributes(3), ADDR_01_LIKE, timestamp,
+		),
+	}
+	InsertTestData(DBTestData{Txs: txs})
+
+	finished, err := ExtractNamed(Conn, extractor.Extractors)
+	require.NoError(t, err)
+	require.True(t, finished)
+
+	p := PageRequest{Limit: 10}
+	res, err := GetIbcTransfers(Conn, QueryIbcTransfersRequest{Address: ADDR_01_COSMOS}, p)
+	require.NoError(t, err)
+	require.Len(t, res.Transfers, 4)
+	require.Equal(t, IBC_TRANSFER_DIRECTION_SEND, res.Transfers[0].Direction)
+	require.Equal(t, ADDR_01_LIKE, res.Transfers[0].Sender)
+	require.Equal(t, osmoAddr, res.Transfers[0].Receiver)
+	require.Equal(t, "channel-0", res.Transfers[0].SrcChannel)
+	require.Equal(t, uint64(1), res.Transfers[0].Sequence)
+	require.Equal(t, "nanolike", res.Transfers[0].Denom)
+	require.Equal(t, "100", res.Transfers[0].Amount)
+	require.Equal(t, IBC_TRANSFER_STATUS_ACKNOWLEDGED, res.Transfers[0].Status)
+	require.Equal(t, "EEEEEE", *res.Transfers[0].CompletedTxHash)
+	require.Equal(t, IBC_TRANSFER_STATUS_FAILED, res.Transfers[1].Status)
+	require.Contains(t, res.Transfers[1].Error, "error handling packet")
+	require.Equal(t, IBC_TRANSFER_STATUS_TIMED_OUT, res.Transfers[2].Status)
+	require.Equal(t, int64(30), *res.Transfers[2].CompletedHeight)
+	require.Equal(t, IBC_TRANSFER_STATUS_PENDING, res.Transfers[3].Status)
+	require.Nil(t, res.Transfers[3].CompletedTxHash)
+
+	res, err = GetIbcTransfers(Conn, QueryIbcTransfersRequest{
+		Address: ADDR_01_LIKE,
+		Status:  []string{IBC_TRANSFER_STATUS_FAILED, IBC_TRANSFER_STATUS_TIMED_OUT},
+	}, p)
+	require.NoError(t, err)
+	require.Len(t, res.Transfers, 2)
+
+	res, err = GetIbcTransfers(Conn, QueryIbcTransfersRequest{Address: osmoAddr, Direction: IBC_TRANSFER_DIRECTION_RECEIVE}, p)
+	require.NoError(t, err)
+	require.Len(t, res.Transfers, 1)
+	require.Equal(t, ADDR_02_LIKE, res.Transfers[0].Receiver)
+	require.Equal(t, "transfer/channel-0/nanolike", res.Transfers[0].Denom)
+	require.Equal(t, IBC_TRANSFER_STATUS_RECEIVED, res.Transfers[0].Status)
+
+	res, err = GetIbcTransfers(Conn, QueryIbcTransfersRequest{Channel: "channel-0"}, p)
+	require.NoError(t, err)
+	require.Len(t, res.Transfers, 5)
+}
@@ -72,18 +72,49 @@ func insertIscn(payload *Payload, event *types.StringEvent) error {
 		Data:         data.Record,
 	}
 	payload.Batch.InsertIscn(iscn)
+
+	e := db.IscnEvent{
+		Action:       db.ISCN_ACTION_CREATE,
+		IscnId:       iscn.Iscn,
+		IscnIdPrefix: iscn.IscnPrefix,
+		Version:      iscn.Version,
+		Receiver:     iscn.Owner,
+		Height:       payload.Height,
+		TxHash:       payload.TxHash,
+		Timestamp:    payload.Timestamp,
+	}
+	if iscn.Version > 1 {
+		e.Action = db.ISCN_ACTION_UPDATE
+		e.Sender = getMessageSigner(message)
+	}
+	e.Executor, e.Granter = payload.GetAuthzActors()
+	payload.Batch.InsertIscnEvent(e)
 	return nil
 }
 
 func transferIscn(payload *Payload, event *types.StringEvent) error {
-	events := payload.GetEvents()
+	if utils.GetEventValue(event, "ipld") != "" {
+		// the owner of a new record or version, which is handled by insertIscn
+		return nil
+	}
 	iscnId := utils.GetEventValue(event, "iscn_id")
 	newOwner := utils.GetEventValue(event, "owner")
 	payload.Batch.Batch.Queue(`UPDATE iscn SET owner = $2 WHERE iscn_id = $1`, iscnId, newOwner)
 
-	// TODO: sender could be different from message.sender in authz
-	sender := utils.GetEventsValue(events, "message", "sender")
-	logger.L.Debugf("Send ISCN %s from %s to %s\n", iscnId, sender, newOwner)
+	e := db.IscnEvent{
+		Action:       db.ISCN_ACTION_TRANSFER,
+		IscnId:       iscnId,
+		IscnIdPrefix: utils.GetEventValue(event, "iscn_id_prefix"),
+		Version:      GetIscnVersion(iscnId),
+		// the signer of the message is the owner sending the ISCN, also when executed by a grantee through authz
+		Sender:    getMessageSigner(payload.GetMessage()),
+		Receiver:  newOwner,
+		Height:    payload.Height,
+		TxHash:    payload.TxHash,
+		Timestamp: payload.Timestamp,
+	}
+	e.Executor, e.Granter = payload.GetAuthzActors()
+	payload.Batch.InsertIscnEvent(e)
 	return nil
 }
 
@@ -98,6 +129,6 @@ func GetIscnVersion(iscn string) int {
 }
 
 func init() {
-	eventExtractor.RegisterTypeKey("iscn_record", "ipld", insertIscn)
-	eventExtractor.RegisterTypeKey("iscn_record", "owner", transferIscn)
+	iscnExtractor.RegisterTypeKey("iscn_record", "ipld", insertIscn)
+	iscnExtractor.RegisterTypeKey("iscn_record", "owner", transferIscn)
 }
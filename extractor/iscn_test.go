@@ -212,3 +212,84 @@ func TestIscn(t *testing.T) {
 		require.Equal(t, v, resFingerprints[i])
 	}
 }
+
+func TestIscnHistory(t *testing.T) {
+	defer CleanupTestData(Conn)
+	iscnIdPrefix := "iscn://testing/ISCNAAAAAA"
+	iscnId1 := iscnIdPrefix + "/1"
+	iscnId2 := iscnIdPrefix + "/2"
+	timestamp := time.Unix(123456789, 0).UTC()
+	txs := []string{
+		fmt.Sprintf(
+			`{"height":"1234","txhash":"AAAAAA","tx":{"body":{"messages":[{"@type":"/likechain.iscn.MsgCreateIscnRecord","from":"%[1]s","record":{"contentMetadata":{}}}],"memo":""}},"logs":[{"msg_index":0,"log":"","events":[{"type":"iscn_record","attributes":[{"key":"iscn_id","value":"%[2]s"},{"key":"iscn_id_prefix","value":"%[3]s"},{"key":"owner","value":"%[1]s"},{"key":"ipld","value":"ipldxxxxxxxxxx"}]},{"type":"message","attributes":[{"key":"action","value":"create_iscn_record"},{"key":"sender","value":"%[1]s"}]}]}],"timestamp":"%[4]s"}`,
+			ADDR_01_LIKE, iscnId1, iscnIdPrefix, timestamp.Format(time.RFC3339),
+		),
+		fmt.Sprintf(
+			`{"height":"1235","txhash":"BBBBBB","tx":{"body":{"messages":[{"@type":"/likechain.iscn.MsgUpdateIscnRecord","from":"%[1]s","iscn_id":"%[2]s","record":{"contentMetadata":{}}}],"memo":""}},"logs":[{"msg_index":0,"log":"","events":[{"type":"iscn_record","attributes":[{"key":"iscn_id","value":"%[3]s"},{"key":"iscn_id_prefix","value":"%[4]s"},{"key":"owner","value":"%[1]s"},{"key":"ipld","value":"ipldyyyyyyyyyy"}]},{"type":"message","attributes":[{"key":"action","value":"update_iscn_record"},{"key":"sender","value":"%[1]s"}]}]}],"timestamp":"%[5]s"}`,
+			ADDR_01_LIKE, iscnId1, iscnId2, iscnIdPrefix, timestamp.Add(time.Hour).Format(time.RFC3339),
+		),
+		fmt.Sprintf(
+			`{"height":"1236","txhash":"CCCCCC","tx":{"body":{"messages":[{"@type":"/likechain.iscn.MsgChangeIscnRecordOwnership","from":"%[1]s","iscn_id":"%[2]s","new_owner":"%[3]s"}],"memo":""}},"logs":[{"msg_index":0,"log":"","events":[{"type":"iscn_record","attributes":[{"key":"iscn_id","value":"%[2]s"},{"key":"iscn_id_prefix","value":"%[4]s"},{"key":"owner","value":"%[3]s"}]},{"type":"message","attributes":[{"key":"action","value":"msg_change_iscn_record_ownership"},{"key":"sender","value":"%[1]s"}]}]}],"timestamp":"%[5]s"}`,
+			ADDR_01_LIKE, iscnId2, ADDR_02_LIKE, iscnIdPrefix, timestamp.Add(2*time.Hour).Format(time.RFC3339),
+		),
+		// ADDR_03 sending the ISCN on behalf of ADDR_02 through authz
+		fmt.Sprintf(
+			`{"height":"1237","txhash":"DDDDDD","tx":{"body":{"messages":[{"@type":"/cosmos.authz.v1beta1.MsgExec","grantee":"%[5]s","msgs":[{"@type":"/likechain.iscn.MsgChangeIscnRecordOwnership","from":"%[1]s","iscn_id":"%[2]s","new_owner":"%[3]s"}]}],"memo":""}},"logs":[{"msg_index":0,"log":"","events":[{"type":"iscn_record","attributes":[{"key":"iscn_id","value":"%[2]s"},{"key":"iscn_id_prefix","value":"%[4]s"},{"key":"owner","value":"%[3]s"},{"key":"authz_msg_index","value":"0"}]},{"type":"message","attributes":[{"key":"action","value":"/cosmos.authz.v1beta1.MsgExec"},{"key":"sender","value":"%[1]s"},{"key":"authz_msg_index","value":"0"}]}]}],"timestamp":"%[6]s"}`,
+			ADDR_02_LIKE, iscnId2, ADDR_04_LIKE, iscnIdPrefix, ADDR_03_LIKE, timestamp.Add(3*time.Hour).Format(time.RFC3339),
+		),
+	}
+	InsertTestData(DBTestData{Txs: txs})
+
+	finished, err := ExtractNamed(Conn, extractor.Extractors)
+	require.NoError(t, err)
+	require.True(t, finished)
+	// extracting the txs again does not duplicate the events
+	err = Reextract(Conn, extractor.Extractors, 1233)
+	require.NoError(t, err)
+
+	res, err := GetIscnHistory(Conn, QueryIscnHistoryRequest{IscnIdPrefix: iscnIdPrefix}, PageRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, res.Events, 4)
+
+	require.Equal(t, ISCN_ACTION_CREATE, res.Events[0].Action)
+	require.Equal(t, iscnId1, res.Events[0].IscnId)
+	require.Equal(t, 1, res.Events[0].Version)
+	require.Empty(t, res.Events[0].Sender)
+	require.Equal(t, ADDR_01_LIKE, res.Events[0].Receiver)
+	require.Equal(t, "AAAAAA", res.Events[0].TxHash)
+	require.Equal(t, int64(1234), res.Events[0].Height)
+	require.Equal(t, timestamp, res.Events[0].Timestamp.UTC())
+
+	require.Equal(t, ISCN_ACTION_UPDATE, res.Events[1].Action)
+	require.Equal(t, iscnId2, res.Events[1].IscnId)
+	require.Equal(t, 2, res.Events[1].Version)
+	require.Equal(t, ADDR_01_LIKE, res.Events[1].Sender)
+	require.Equal(t, ADDR_01_LIKE, res.Events[1].Receiver)
+
+	require.Equal(t, ISCN_ACTION_TRANSFER, res.Events[2].Action)
+	require.Equal(t, iscnId2, res.Events[2].IscnId)
+	require.Equal(t, ADDR_01_LIKE, res.Events[2].Sender)
+	require.Equal(t, ADDR_02_LIKE, res.Events[2].Receiver)
+	require.Empty(t, res.Events[2].Executor)
+	require.Equal(t, timestamp.Add(2*time.Hour), res.Events[2].Timestamp.UTC())
+
+	require.Equal(t, ISCN_ACTION_TRANSFER, res.Events[3].Action)
+	require.Equal(t, ADDR_02_LIKE, res.Events[3].Sender)
+	require.Equal(t, ADDR_04_LIKE, res.Events[3].Receiver)
+	require.Equal(t, ADDR_03_LIKE, res.Events[3].Executor)
+	require.Equal(t, ADDR_02_LIKE, res.Events[3].Granter)
+
+	res, err = GetIscnHistory(Conn, QueryIscnHistoryRequest{
+		IscnIdPrefix: iscnIdPrefix,
+		Action:       []IscnEventAction{ISCN_ACTION_TRANSFER},
+	}, PageRequest{Limit: 10, Reverse: true})
+	require.NoError(t, err)
+	require.Len(t, res.Events, 2)
+	require.Equal(t, "DDDDDD", res.Events[0].TxHash)
+	require.Equal(t, "CCCCCC", res.Events[1].TxHash)
+
+	iscnRes, err := QueryIscn(Conn, IscnQuery{IscnId: iscnId2}, PageRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, iscnRes.Records, 1)
+	require.Equal(t, ADDR_04_LIKE, iscnRes.Records[0].Data.Owner)
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/cosmos/cosmos-sdk/types"
+	"github.com/jackc/pgx/v4/pgxpool"
 
 	"github.com/likecoin/likecoin-chain-tx-indexer/db"
 	"github.com/likecoin/likecoin-chain-tx-indexer/logger"
@@ -46,62 +47,116 @@ func parseMessage(payload *Payload) (db.NftMarketplaceItem, error) {
 	}, nil
 }
 
-func createListing(payload *Payload, event *types.StringEvent) error {
+func insertMarketplaceHistory(payload *Payload, action db.NftMarketplaceAction, item db.NftMarketplaceItem) {
+	h := db.NftMarketplaceHistory{
+		Action:    action,
+		Type:      item.Type,
+		ClassId:   item.ClassId,
+		NftId:     item.NftId,
+		Creator:   item.Creator,
+		Price:     item.Price,
+		Height:    payload.Height,
+		TxHash:    payload.TxHash,
+		Timestamp: payload.Timestamp,
+	}
+	if !item.Expiration.IsZero() {
+		h.Expiration = &item.Expiration
+	}
+	payload.Batch.InsertNftMarketplaceHistory(h)
+}
+
+// snapshotFloorPrice records the floor price of the class after its listings are changed by the message
+func snapshotFloorPrice(payload *Payload, classId string) {
+	payload.Batch.SnapshotNftMarketplaceFloorPrice(classId, payload.Height, payload.Timestamp)
+}
+
+// hasMessageEvent checks whether the message emits an event of the type, e.g. a deal deleting the listing or offer
+func hasMessageEvent(payload *Payload, eventType string) bool {
+	for _, event := range payload.GetEvents() {
+		if event.Type == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func putListing(payload *Payload, action db.NftMarketplaceAction) error {
 	item, err := parseMessage(payload)
 	if err != nil {
 		return err
 	}
 	item.Type = "listing"
-	payload.Batch.DeleteNFTMarketplaceItemSilently(item)
+	payload.Batch.CancelStaleNFTMarketplaceItems(item, payload.Height, payload.TxHash, payload.Timestamp)
 	payload.Batch.InsertNFTMarketplaceItem(item)
+	insertMarketplaceHistory(payload, action, item)
+	snapshotFloorPrice(payload, item.ClassId)
 	return nil
 }
 
-func deleteListing(payload *Payload, event *types.StringEvent) error {
-	item := db.NftMarketplaceItem{
+func createListing(payload *Payload, event *types.StringEvent) error {
+	return putListing(payload, db.MARKETPLACE_ACTION_CREATE)
+}
+
+func getListingFromEvent(event *types.StringEvent) db.NftMarketplaceItem {
+	return db.NftMarketplaceItem{
 		Type:    "listing",
 		ClassId: utils.GetEventValue(event, "class_id"),
 		NftId:   utils.GetEventValue(event, "nft_id"),
 		Creator: utils.GetEventValue(event, "seller"),
 	}
+}
+
+func deleteListing(payload *Payload, event *types.StringEvent) error {
+	item := getListingFromEvent(event)
+	// the listing deleted by a buy is recorded as filled instead
+	if !hasMessageEvent(payload, "likechain.likenft.v1.EventBuyNFT") {
+		insertMarketplaceHistory(payload, db.MARKETPLACE_ACTION_DELETE, item)
+	}
 	payload.Batch.DeleteNFTMarketplaceItem(item)
+	snapshotFloorPrice(payload, item.ClassId)
 	return nil
 }
 
 func updateListing(payload *Payload, event *types.StringEvent) error {
-	err := deleteListing(payload, event)
-	if err != nil {
-		return err
-	}
-	return createListing(payload, event)
+	return putListing(payload, db.MARKETPLACE_ACTION_UPDATE)
 }
-func createOffer(payload *Payload, event *types.StringEvent) error {
+
+func putOffer(payload *Payload, action db.NftMarketplaceAction) error {
 	item, err := parseMessage(payload)
 	if err != nil {
 		return err
 	}
 	item.Type = "offer"
 	payload.Batch.InsertNFTMarketplaceItem(item)
+	insertMarketplaceHistory(payload, action, item)
 	return nil
 }
 
-func deleteOffer(payload *Payload, event *types.StringEvent) error {
-	item := db.NftMarketplaceItem{
+func createOffer(payload *Payload, event *types.StringEvent) error {
+	return putOffer(payload, db.MARKETPLACE_ACTION_CREATE)
+}
+
+func getOfferFromEvent(event *types.StringEvent) db.NftMarketplaceItem {
+	return db.NftMarketplaceItem{
 		Type:    "offer",
 		ClassId: utils.GetEventValue(event, "class_id"),
 		NftId:   utils.GetEventValue(event, "nft_id"),
 		Creator: utils.GetEventValue(event, "buyer"),
 	}
+}
+
+func deleteOffer(payload *Payload, event *types.StringEvent) error {
+	item := getOfferFromEvent(event)
+	// the offer deleted by a sell is recorded as filled instead
+	if !hasMessageEvent(payload, "likechain.likenft.v1.EventSellNFT") {
+		insertMarketplaceHistory(payload, db.MARKETPLACE_ACTION_DELETE, item)
+	}
 	payload.Batch.DeleteNFTMarketplaceItem(item)
 	return nil
 }
 
 func updateOffer(payload *Payload, event *types.StringEvent) error {
-	err := deleteOffer(payload, event)
-	if err != nil {
-		return err
-	}
-	return createOffer(payload, event)
+	return putOffer(payload, db.MARKETPLACE_ACTION_UPDATE)
 }
 
 func getPriceFromEvent(event *types.StringEvent) uint64 {
@@ -139,11 +194,91 @@ func marketplaceDeal(payload *Payload, event *types.StringEvent, actionType db.N
 	for _, income := range incomes {
 		payload.Batch.InsertNftIncome(income)
 	}
+	item := db.NftMarketplaceItem{
+		Type:    "listing",
+		ClassId: e.ClassId,
+		NftId:   e.NftId,
+		Creator: e.Sender,
+	}
+	if actionType == db.ACTION_SELL {
+		item.Type = "offer"
+		item.Creator = e.Receiver
+	}
+	payload.Batch.FillNFTMarketplaceItem(item)
+
 	attachNftEvent(&e, payload)
 	payload.Batch.InsertNftEvent(e)
+
+	counterparty := e.Receiver
+	if actionType == db.ACTION_SELL {
+		counterparty = e.Sender
+	}
+	payload.Batch.InsertNftMarketplaceHistory(db.NftMarketplaceHistory{
+		Action:       db.MARKETPLACE_ACTION_FILL,
+		Type:         item.Type,
+		ClassId:      item.ClassId,
+		NftId:        item.NftId,
+		Creator:      item.Creator,
+		Price:        e.Price,
+		Counterparty: counterparty,
+		Height:       payload.Height,
+		TxHash:       payload.TxHash,
+		Timestamp:    payload.Timestamp,
+	})
+	if item.Type == "listing" {
+		snapshotFloorPrice(payload, item.ClassId)
+	}
 	return nil
 }
 
+// blockTimeAt returns the time of the block at the height, where ok is false when it is unknown
+func blockTimeAt(conn *pgxpool.Conn, height int64) (blockTime time.Time, ok bool, err error) {
+	block, err := db.GetBlockByHeight(conn, height)
+	if err == nil {
+		return block.Time, true, nil
+	}
+	// blocks may be missing, e.g. imported from the tendermint data, then the latest block time is used at the latest
+	// height
+	latestHeight, err := db.GetLatestHeight(conn)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to get latest height: %w", err)
+	}
+	if height < latestHeight {
+		return time.Time{}, false, nil
+	}
+	blockTime, err = db.GetLatestBlockTime(conn)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to get latest block time: %w", err)
+	}
+	return blockTime, true, nil
+}
+
+// expireMarketplaceItems is the AfterRound of the marketplace extractor, which marks the listings and offers expired
+// by the time of the block reached, and records the floor prices of the classes with expired listings at the block
+func expireMarketplaceItems(conn *pgxpool.Conn, height int64, silent bool) error {
+	blockTime, ok, err := blockTimeAt(conn, height)
+	if err != nil || !ok {
+		return err
+	}
+	items, err := db.ExpireNftMarketplaceItems(conn, blockTime, silent)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	logger.L.Infow("Marketplace items expired", "count", len(items), "block_time", blockTime)
+	classIds := []string{}
+	added := map[string]bool{}
+	for _, item := range items {
+		if item.Type == "listing" && !added[item.ClassId] {
+			added[item.ClassId] = true
+			classIds = append(classIds, item.ClassId)
+		}
+	}
+	return db.SnapshotNftMarketplaceFloorPrices(conn, classIds, height, blockTime)
+}
+
 // marketplace messages may contain multiple coin_received events,
 // should not directly use GetEventValue() or GetEventsValue() since it only returns the first one
 func GetIncomesFromBuySellNftMsg(events types.StringEvents, txHash string) []db.NftIncome {
@@ -214,12 +349,12 @@ func GetIncomesFromBuySellNftMsg(events types.StringEvents, txHash string) []db.
 }
 
 func init() {
-	eventExtractor.RegisterType("likechain.likenft.v1.EventBuyNFT", buyNft)
-	eventExtractor.RegisterType("likechain.likenft.v1.EventSellNFT", sellNft)
-	eventExtractor.RegisterType("likechain.likenft.v1.EventCreateListing", createListing)
-	eventExtractor.RegisterType("likechain.likenft.v1.EventUpdateListing", updateListing)
-	eventExtractor.RegisterType("likechain.likenft.v1.EventDeleteListing", deleteListing)
-	eventExtractor.RegisterType("likechain.likenft.v1.EventCreateOffer", createOffer)
-	eventExtractor.RegisterType("likechain.likenft.v1.EventUpdateOffer", updateOffer)
-	eventExtractor.RegisterType("likechain.likenft.v1.EventDeleteOffer", deleteOffer)
+	marketplaceExtractor.RegisterType("likechain.likenft.v1.EventBuyNFT", buyNft)
+	marketplaceExtractor.RegisterType("likechain.likenft.v1.EventSellNFT", sellNft)
+	marketplaceExtractor.RegisterType("likechain.likenft.v1.EventCreateListing", createListing)
+	marketplaceExtractor.RegisterType("likechain.likenft.v1.EventUpdateListing", updateListing)
+	marketplaceExtractor.RegisterType("likechain.likenft.v1.EventDeleteListing", deleteListing)
+	marketplaceExtractor.RegisterType("likechain.likenft.v1.EventCreateOffer", createOffer)
+	marketplaceExtractor.RegisterType("likechain.likenft.v1.EventUpdateOffer", updateOffer)
+	marketplaceExtractor.RegisterType("likechain.likenft.v1.EventDeleteOffer", deleteOffer)
 }
@@ -296,6 +296,13 @@ func TestListing(t *testing.T) {
 	require.Equal(t, updatedPrice1, itemsRes.Items[0].Price)
 	require.Equal(t, expiration.Add(2*time.Second), itemsRes.Items[0].Expiration)
 
+	activeOnly := false
+	itemsRes, err = GetNftMarketplaceItems(Conn, QueryNftMarketplaceItemsRequest{Type: "listing", ActiveOnly: &activeOnly}, PageRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, itemsRes.Items, 2)
+	require.Equal(t, MARKETPLACE_STATUS_CANCELLED, itemsRes.Items[0].Status)
+	require.Equal(t, MARKETPLACE_STATUS_ACTIVE, itemsRes.Items[1].Status)
+
 	stakeholder1 := ADDR_03_LIKE
 	stakeholder2 := ADDR_01_LIKE
 	royalty1 := uint64(10000000000)
@@ -325,6 +332,12 @@ func TestListing(t *testing.T) {
 	require.NoError(t, err)
 	require.Empty(t, itemsRes.Items)
 
+	itemsRes, err = GetNftMarketplaceItems(Conn, QueryNftMarketplaceItemsRequest{Type: "listing", ActiveOnly: &activeOnly}, PageRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, itemsRes.Items, 2)
+	require.Equal(t, MARKETPLACE_STATUS_CANCELLED, itemsRes.Items[0].Status)
+	require.Equal(t, MARKETPLACE_STATUS_FILLED, itemsRes.Items[1].Status)
+
 	eventsRes, err := GetNftEvents(Conn,
 		QueryEventsRequest{
 			ClassId:    nftClasses[0].Id,
@@ -356,6 +369,71 @@ func TestListing(t *testing.T) {
 	require.Equal(t, updatedPrice1, classIncome.Sales)
 	require.Equal(t, incomesRes.TotalAmount, classIncome.TotalAmount)
 	require.Equal(t, incomesRes.TotalSales, classIncome.Sales)
+
+	historyRes, err := GetNftMarketplaceHistory(Conn, QueryNftMarketplaceHistoryRequest{
+		ClassId: nftClasses[0].Id,
+	}, PageRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, historyRes.History, 5)
+	historyTable := []struct {
+		action  NftMarketplaceAction
+		nftId   string
+		creator string
+		price   uint64
+		txHash  string
+	}{
+		{MARKETPLACE_ACTION_CREATE, nfts[0].NftId, ADDR_01_LIKE, initPrice1, "AAAAAA"},
+		{MARKETPLACE_ACTION_CREATE, nfts[1].NftId, ADDR_02_LIKE, initPrice2, "AAAAAB"},
+		{MARKETPLACE_ACTION_UPDATE, nfts[0].NftId, ADDR_01_LIKE, updatedPrice1, "AAAAAC"},
+		{MARKETPLACE_ACTION_DELETE, nfts[1].NftId, ADDR_02_LIKE, initPrice2, "AAAAAD"},
+		{MARKETPLACE_ACTION_FILL, nfts[0].NftId, ADDR_01_LIKE, updatedPrice1, "AAAAAE"},
+	}
+	for i, v := range historyTable {
+		h := historyRes.History[i]
+		require.Equal(t, v.action, h.Action, "Error in test case #%02d", i)
+		require.Equal(t, "listing", h.Type, "Error in test case #%02d", i)
+		require.Equal(t, v.nftId, h.NftId, "Error in test case #%02d", i)
+		require.Equal(t, v.creator, h.Creator, "Error in test case #%02d", i)
+		require.Equal(t, v.price, h.Price, "Error in test case #%02d", i)
+		require.Equal(t, v.txHash, h.TxHash, "Error in test case #%02d", i)
+	}
+	require.Equal(t, expiration.Add(1*time.Second), *historyRes.History[3].Expiration)
+	fill := historyRes.History[4]
+	require.Equal(t, ADDR_02_LIKE, fill.Counterparty)
+	require.NotNil(t, fill.NftEvent)
+	require.Equal(t, ACTION_BUY, fill.NftEvent.Action)
+	require.Equal(t, ADDR_01_LIKE, fill.NftEvent.Sender)
+	require.Equal(t, ADDR_02_LIKE, fill.NftEvent.Receiver)
+	require.Equal(t, updatedPrice1, fill.NftEvent.Price)
+	require.Nil(t, historyRes.History[0].NftEvent)
+
+	historyRes, err = GetNftMarketplaceHistory(Conn, QueryNftMarketplaceHistoryRequest{
+		ClassId: nftClasses[0].Id,
+		Creator: ADDR_02_LIKE,
+		Action:  []NftMarketplaceAction{MARKETPLACE_ACTION_DELETE},
+	}, PageRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, historyRes.History, 1)
+	require.Equal(t, "AAAAAD", historyRes.History[0].TxHash)
+
+	sellersRes, err := GetNftMarketplaceSellerStats(Conn, QueryNftMarketplaceSellerStatsRequest{
+		ClassId: nftClasses[0].Id,
+	}, PageRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, sellersRes.Sellers, 2)
+	require.Equal(t, 2, sellersRes.Pagination.Total)
+	require.Equal(t, NftMarketplaceSellerStat{
+		Seller:       ADDR_01_LIKE,
+		ListingCount: 1,
+		FilledCount:  1,
+		FillRate:     1,
+		FilledVolume: updatedPrice1,
+	}, sellersRes.Sellers[0])
+	require.Equal(t, NftMarketplaceSellerStat{
+		Seller:         ADDR_02_LIKE,
+		ListingCount:   1,
+		CancelledCount: 1,
+	}, sellersRes.Sellers[1])
 }
 
 func TestOffer(t *testing.T) {
@@ -506,6 +584,13 @@ func TestOffer(t *testing.T) {
 	require.Equal(t, updatedPrice1, itemsRes.Items[0].Price)
 	require.Equal(t, expiration.Add(2*time.Second), itemsRes.Items[0].Expiration)
 
+	activeOnly := false
+	itemsRes, err = GetNftMarketplaceItems(Conn, QueryNftMarketplaceItemsRequest{Type: "offer", ActiveOnly: &activeOnly}, PageRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, itemsRes.Items, 2)
+	require.Equal(t, MARKETPLACE_STATUS_CANCELLED, itemsRes.Items[0].Status)
+	require.Equal(t, MARKETPLACE_STATUS_ACTIVE, itemsRes.Items[1].Status)
+
 	stakeholder1 := ADDR_03_LIKE
 	stakeholder2 := ADDR_01_LIKE
 	royalty1 := uint64(10000000000)
@@ -535,6 +620,12 @@ func TestOffer(t *testing.T) {
 	require.NoError(t, err)
 	require.Empty(t, itemsRes.Items)
 
+	itemsRes, err = GetNftMarketplaceItems(Conn, QueryNftMarketplaceItemsRequest{Type: "offer", ActiveOnly: &activeOnly}, PageRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, itemsRes.Items, 2)
+	require.Equal(t, MARKETPLACE_STATUS_CANCELLED, itemsRes.Items[0].Status)
+	require.Equal(t, MARKETPLACE_STATUS_FILLED, itemsRes.Items[1].Status)
+
 	eventsRes, err := GetNftEvents(Conn,
 		QueryEventsRequest{
 			ClassId:    nftClasses[0].Id,
@@ -567,3 +658,156 @@ func TestOffer(t *testing.T) {
 	require.Equal(t, incomesRes.TotalAmount, classIncome.TotalAmount)
 	require.Equal(t, incomesRes.TotalSales, classIncome.Sales)
 }
+
+func TestExpireMarketplaceItems(t *testing.T) {
+	defer CleanupTestData(Conn)
+	expiration := time.Unix(1700000000, 0).UTC()
+	classId := "nftlike1aaaaa1"
+	nftId := "testing-nft-1"
+	listingPrice := uint64(100)
+	items := []NftMarketplaceItem{
+		{
+			Type:       "offer",
+			ClassId:    classId,
+			NftId:      nftId,
+			Creator:    ADDR_02_LIKE,
+			Price:      200,
+			Expiration: expiration.Add(100 * time.Second),
+		},
+	}
+	txs := []string{
+		fmt.Sprintf(
+			`{"txhash":"AAAAAA","height":"5","tx":{"body":{"messages":[{"@type":"/likechain.likenft.v1.MsgCreateListing","creator":"%[1]s","class_id":"%[2]s","nft_id":"%[3]s","price":"%[4]d","expiration":"%[5]s"}],"memo":"AAAAAA"}},"logs":[{"msg_index":0,"log":"","events":[{"type":"message","attributes":[{"key":"action","value":"create_listing"}]},{"type":"likechain.likenft.v1.EventCreateListing","attributes":[{"key":"class_id","value":"\"%[2]s\""},{"key":"nft_id","value":"\"%[3]s\""},{"key":"seller","value":"\"%[1]s\""}]}]}],"timestamp":"%[6]s"}`,
+			ADDR_01_LIKE, classId, nftId, listingPrice, expiration.Format(time.RFC3339), expiration.Add(-10*time.Second).Format(time.RFC3339),
+		),
+	}
+	InsertTestData(DBTestData{
+		NftMarketplaceItems: items,
+		Txs:                 txs,
+		Blocks: []Block{
+			{Height: 5, Time: expiration.Add(-10 * time.Second)},
+			{Height: 6, Time: expiration.Add(10 * time.Second)},
+		},
+		LatestBlockHeight: 5,
+	})
+
+	activeOnly := false
+	queryStatuses := func() []NftMarketplaceItemStatus {
+		statuses := []NftMarketplaceItemStatus{}
+		for _, itemType := range []string{"listing", "offer"} {
+			res, err := GetNftMarketplaceItems(Conn, QueryNftMarketplaceItemsRequest{Type: itemType, ActiveOnly: &activeOnly}, PageRequest{Limit: 10})
+			require.NoError(t, err)
+			require.Len(t, res.Items, 1)
+			statuses = append(statuses, res.Items[0].Status)
+		}
+		return statuses
+	}
+
+	_, err := ExtractNamed(Conn, extractor.Extractors)
+	require.NoError(t, err)
+	require.Equal(t, []NftMarketplaceItemStatus{MARKETPLACE_STATUS_ACTIVE, MARKETPLACE_STATUS_ACTIVE}, queryStatuses())
+
+	InsertTestData(DBTestData{LatestBlockHeight: 6})
+	_, err = ExtractNamed(Conn, extractor.Extractors)
+	require.NoError(t, err)
+	require.Equal(t, []NftMarketplaceItemStatus{MARKETPLACE_STATUS_EXPIRED, MARKETPLACE_STATUS_ACTIVE}, queryStatuses())
+
+	res, err := GetNftMarketplaceItems(Conn, QueryNftMarketplaceItemsRequest{Type: "listing"}, PageRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Empty(t, res.Items)
+
+	floorRes, err := GetNftMarketplaceFloorPriceHistory(Conn, QueryNftMarketplaceFloorPriceHistoryRequest{
+		ClassId: classId,
+	}, PageRequest{Limit: 10})
+	require.NoError(t, err)
+	// recorded at the block of the listing, and at the block reached by the extractor after the listing expired
+	require.Len(t, floorRes.Snapshots, 2)
+	require.Equal(t, int64(5), floorRes.Snapshots[0].Height)
+	require.Equal(t, listingPrice, *floorRes.Snapshots[0].FloorPrice)
+	require.Equal(t, int64(6), floorRes.Snapshots[1].Height)
+	require.Nil(t, floorRes.Snapshots[1].FloorPrice)
+}
+
+func TestMarketplaceItemsOfOtherCreators(t *testing.T) {
+	defer CleanupTestData(Conn)
+	classId := "nftlike1aaaaa1"
+	nftId := "testing-nft-1"
+	expiration := time.Unix(1700000000, 0).UTC()
+	items := []NftMarketplaceItem{}
+	for i, creator := range []string{ADDR_01_LIKE, ADDR_03_LIKE} {
+		items = append(items, NftMarketplaceItem{
+			Type:       "listing",
+			ClassId:    classId,
+			NftId:      nftId,
+			Creator:    creator,
+			Price:      uint64(100 + i),
+			Expiration: expiration,
+		})
+	}
+	for i, creator := range []string{ADDR_02_LIKE, ADDR_03_LIKE} {
+		items = append(items, NftMarketplaceItem{
+			Type:       "offer",
+			ClassId:    classId,
+			NftId:      nftId,
+			Creator:    creator,
+			Price:      uint64(200 + i),
+			Expiration: expiration,
+		})
+	}
+	txs := []string{
+		fmt.Sprintf(
+			`{"txhash":"AAAAAA","height":"1234","tx":{"body":{"messages":[{"@type":"/likechain.likenft.v1.MsgDeleteOffer","creator":"%[1]s","class_id":"%[2]s","nft_id":"%[3]s"}],"memo":"AAAAAA"}},"logs":[{"msg_index":0,"log":"","events":[{"type":"message","attributes":[{"key":"action","value":"delete_offer"}]},{"type":"likechain.likenft.v1.EventDeleteOffer","attributes":[{"key":"class_id","value":"\"%[2]s\""},{"key":"nft_id","value":"\"%[3]s\""},{"key":"buyer","value":"\"%[1]s\""}]}]}]}`,
+			ADDR_03_LIKE, classId, nftId,
+		),
+		fmt.Sprintf(
+			`{"txhash":"AAAAAB","height":"1235","tx":{"body":{"messages":[{"@type":"/likechain.likenft.v1.MsgDeleteListing","creator":"%[1]s","class_id":"%[2]s","nft_id":"%[3]s"}],"memo":"AAAAAB"}},"logs":[{"msg_index":0,"log":"","events":[{"type":"message","attributes":[{"key":"action","value":"delete_listing"}]},{"type":"likechain.likenft.v1.EventDeleteListing","attributes":[{"key":"class_id","value":"\"%[2]s\""},{"key":"nft_id","value":"\"%[3]s\""},{"key":"seller","value":"\"%[1]s\""}]}]}]}`,
+			ADDR_01_LIKE, classId, nftId,
+		),
+		fmt.Sprintf(
+			`{"txhash":"AAAAAC","height":"1236","tx":{"body":{"messages":[{"@type":"/likechain.likenft.v1.MsgCreateListing","creator":"%[1]s","class_id":"%[2]s","nft_id":"%[3]s","price":"%[4]d","expiration":"%[5]s"}],"memo":"AAAAAC"}},"logs":[{"msg_index":0,"log":"","events":[{"type":"message","attributes":[{"key":"action","value":"create_listing"}]},{"type":"likechain.likenft.v1.EventCreateListing","attributes":[{"key":"class_id","value":"\"%[2]s\""},{"key":"nft_id","value":"\"%[3]s\""},{"key":"seller","value":"\"%[1]s\""}]}]}]}`,
+			ADDR_02_LIKE, classId, nftId, 300, expiration.Format(time.RFC3339),
+		),
+	}
+	blockTime := expiration.Add(-10000 * time.Second)
+	InsertTestData(DBTestData{
+		NftMarketplaceItems: items,
+		Txs:                 txs,
+		LatestBlockTime:     &blockTime,
+	})
+
+	finished, err := ExtractNamed(Conn, extractor.Extractors)
+	require.NoError(t, err)
+	require.True(t, finished)
+
+	activeOnly := false
+	statuses := func(itemType string) map[string]NftMarketplaceItemStatus {
+		res, err := GetNftMarketplaceItems(Conn, QueryNftMarketplaceItemsRequest{Type: itemType, ActiveOnly: &activeOnly}, PageRequest{Limit: 10})
+		require.NoError(t, err)
+		statuses := map[string]NftMarketplaceItemStatus{}
+		for _, item := range res.Items {
+			statuses[item.Creator] = item.Status
+		}
+		return statuses
+	}
+	// only the offer of the buyer is cancelled
+	require.Equal(t, map[string]NftMarketplaceItemStatus{
+		ADDR_02_LIKE: MARKETPLACE_STATUS_ACTIVE,
+		ADDR_03_LIKE: MARKETPLACE_STATUS_CANCELLED,
+	}, statuses("offer"))
+	// the new listing cancels the active listing of the other seller, and keeps the cancelled one
+	require.Equal(t, map[string]NftMarketplaceItemStatus{
+		ADDR_01_LIKE: MARKETPLACE_STATUS_CANCELLED,
+		ADDR_02_LIKE: MARKETPLACE_STATUS_ACTIVE,
+		ADDR_03_LIKE: MARKETPLACE_STATUS_CANCELLED,
+	}, statuses("listing"))
+	historyRes, err := GetNftMarketplaceHistory(Conn, QueryNftMarketplaceHistoryRequest{
+		ClassId: classId,
+		Type:    "listing",
+		Creator: ADDR_03_LIKE,
+	}, PageRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, historyRes.History, 1)
+	require.Equal(t, MARKETPLACE_ACTION_DELETE, historyRes.History[0].Action)
+	require.Equal(t, uint64(101), historyRes.History[0].Price)
+	require.Equal(t, "AAAAAC", historyRes.History[0].TxHash)
+}
@@ -16,6 +16,7 @@ func attachNftEvent(e *db.NftEvent, payload *Payload) {
 	e.Timestamp = payload.Timestamp
 	e.TxHash = payload.TxHash
 	e.Memo = payload.Memo
+	e.Executor, e.Granter = payload.GetAuthzActors()
 }
 
 type nftClassMessage struct {
@@ -33,6 +34,7 @@ func createNftClass(payload *Payload, event *types.StringEvent) error {
 	c.Parent = getNftParent(event)
 	c.CreatedAt = payload.Timestamp
 	payload.Batch.InsertNftClass(c)
+	extractNftClassConfig(payload, c.Id, c.Config)
 
 	e := db.NftEvent{
 		ClassId: c.Id,
@@ -52,6 +54,7 @@ func updateNftClass(payload *Payload, event *types.StringEvent) error {
 	c := message.Input
 	c.Id = utils.GetEventValue(event, "class_id")
 	payload.Batch.UpdateNftClass(c)
+	extractNftClassConfig(payload, c.Id, c.Config)
 
 	e := db.NftEvent{
 		ClassId: c.Id,
@@ -63,6 +66,64 @@ func updateNftClass(payload *Payload, event *types.StringEvent) error {
 	return nil
 }
 
+// extractNftClassConfig indexes the structured class config. The class is still extracted with the raw config if the
+// config cannot be parsed.
+func extractNftClassConfig(payload *Payload, classId string, rawConfig json.RawMessage) {
+	payload.Batch.InsertNftClassConfigHistory(db.NftClassConfigHistory{
+		ClassId:    classId,
+		ConfigType: db.NFT_CLASS_CONFIG_TYPE_CLASS,
+		Config:     rawConfig,
+		Height:     payload.Height,
+		TxHash:     payload.TxHash,
+		Timestamp:  payload.Timestamp,
+	})
+	var config db.NftClassConfig
+	if len(rawConfig) > 0 {
+		if err := json.Unmarshal(rawConfig, &config); err != nil {
+			logger.L.Warnw("Failed to parse NFT class config", "class_id", classId, "tx_hash", payload.TxHash, "error", err)
+			return
+		}
+	}
+	payload.Batch.UpdateNftClassConfig(classId, config)
+}
+
+func setNftRoyaltyConfig(payload *Payload, event *types.StringEvent) error {
+	var message struct {
+		RoyaltyConfig db.NftRoyaltyConfig `json:"royalty_config"`
+	}
+	if err := json.Unmarshal(payload.GetMessage(), &message); err != nil {
+		return fmt.Errorf("failed to unmarshal royalty config message: %w", err)
+	}
+	classId := utils.GetEventValue(event, "class_id")
+	config, err := json.Marshal(message.RoyaltyConfig)
+	if err != nil {
+		return fmt.Errorf("failed to marshal royalty config: %w", err)
+	}
+	payload.Batch.UpdateNftRoyaltyConfig(classId, &message.RoyaltyConfig)
+	payload.Batch.InsertNftClassConfigHistory(db.NftClassConfigHistory{
+		ClassId:    classId,
+		ConfigType: db.NFT_CLASS_CONFIG_TYPE_ROYALTY,
+		Config:     config,
+		Height:     payload.Height,
+		TxHash:     payload.TxHash,
+		Timestamp:  payload.Timestamp,
+	})
+	return nil
+}
+
+func deleteNftRoyaltyConfig(payload *Payload, event *types.StringEvent) error {
+	classId := utils.GetEventValue(event, "class_id")
+	payload.Batch.UpdateNftRoyaltyConfig(classId, nil)
+	payload.Batch.InsertNftClassConfigHistory(db.NftClassConfigHistory{
+		ClassId:    classId,
+		ConfigType: db.NFT_CLASS_CONFIG_TYPE_ROYALTY,
+		Height:     payload.Height,
+		TxHash:     payload.TxHash,
+		Timestamp:  payload.Timestamp,
+	})
+	return nil
+}
+
 func getNftParent(event *types.StringEvent) db.NftClassParent {
 	p := db.NftClassParent{
 		IscnIdPrefix: utils.GetEventValue(event, "parent_iscn_id_prefix"),
@@ -103,6 +164,26 @@ func mintNft(payload *Payload, event *types.StringEvent) error {
 	return nil
 }
 
+// a likenft burn emits both the burn events of likenft and the NFT module, where the duplicated NFT event is ignored by
+// the unique constraint on the action, NFT and tx
+func extractBurnNft(payload *Payload, event *types.StringEvent, nftIdField string) {
+	e := extractNftEvent(event, "class_id", nftIdField, "owner", "")
+	e.Action = db.ACTION_BURN
+	payload.Batch.BurnNft(e.ClassId, e.NftId, payload.Timestamp)
+	attachNftEvent(&e, payload)
+	payload.Batch.InsertNftEvent(e)
+}
+
+func burnNft(payload *Payload, event *types.StringEvent) error {
+	extractBurnNft(payload, event, "id")
+	return nil
+}
+
+func burnLikeNft(payload *Payload, event *types.StringEvent) error {
+	extractBurnNft(payload, event, "nft_id")
+	return nil
+}
+
 func extractPriceFromEvents(events types.StringEvents) uint64 {
 	priceStr := utils.GetEventsValue(events, "coin_received", "amount")
 	if priceStr == "" {
@@ -143,18 +224,20 @@ func sendNft(payload *Payload, event *types.StringEvent) error {
 	// transaction, so the NFT send and token send are atomic.
 	// We want to identify this case and extract the "price" from the transaction.
 
-	// We assume the first message is the authz message with token send
-	// TODO: also check if authz grantee is API address
+	// We assume the first message is the authz message with token send, executed by the API address, which is only
+	// counted if the buyer has granted the API address to send tokens at the time
 	sendNftMsgIndex := payload.MsgIndex
 	if sendNftMsgIndex > 0 {
 		prevMsgEvents := payload.EventsList[sendNftMsgIndex-1].Events
 		prevMsgAction := utils.GetEventsValue(prevMsgEvents, "message", "action")
 		if prevMsgAction == "/cosmos.authz.v1beta1.MsgExec" {
-			e.Price = extractPriceFromEvents(prevMsgEvents)
-
-			incomes := GetIncomesFromSendNftMsgs(payload.EventsList, sendNftMsgIndex, payload.TxHash)
-			for _, income := range incomes {
-				payload.Batch.InsertNftIncome(income)
+			grant, ok := getTokenSendGrant(payload.Messages[sendNftMsgIndex-1])
+			if ok && isApiAddress(grant.Grantee) {
+				e.Price = extractPriceFromEvents(prevMsgEvents)
+				incomes := GetIncomesFromSendNftMsgs(payload.EventsList, sendNftMsgIndex, payload.TxHash)
+				attachNftEvent(&e, payload)
+				payload.Batch.InsertNftSale(e, incomes, grant)
+				return nil
 			}
 		}
 	}
@@ -163,6 +246,28 @@ func sendNft(payload *Payload, event *types.StringEvent) error {
 	return nil
 }
 
+const tokenSendMsgTypeUrl = "/cosmos.bank.v1beta1.MsgSend"
+
+// getTokenSendGrant returns the grant used by the MsgExec executing a token send
+func getTokenSendGrant(execMsg json.RawMessage) (db.AuthzGrantRef, bool) {
+	msgs, err := extractAuthzMessages(execMsg)
+	if err != nil || len(msgs) == 0 {
+		return db.AuthzGrantRef{}, false
+	}
+	var msg struct {
+		Type        string `json:"@type"`
+		FromAddress string `json:"from_address"`
+	}
+	if err := json.Unmarshal(msgs[0], &msg); err != nil || msg.Type != tokenSendMsgTypeUrl {
+		return db.AuthzGrantRef{}, false
+	}
+	return db.AuthzGrantRef{
+		Granter:    msg.FromAddress,
+		Grantee:    getAuthzGrantee(execMsg),
+		MsgTypeUrl: tokenSendMsgTypeUrl,
+	}, true
+}
+
 func GetIncomesFromSendNftMsgs(eventsList db.EventsList, msgIndex int, txHash string) []db.NftIncome {
 	if msgIndex < 1 {
 		return []db.NftIncome{}
@@ -214,8 +319,13 @@ func GetIncomesFromSendNftMsgs(eventsList db.EventsList, msgIndex int, txHash st
 }
 
 func init() {
-	eventExtractor.RegisterType("likechain.likenft.v1.EventNewClass", createNftClass)
-	eventExtractor.RegisterType("likechain.likenft.v1.EventUpdateClass", updateNftClass)
-	eventExtractor.RegisterType("likechain.likenft.v1.EventMintNFT", mintNft)
-	eventExtractor.RegisterType("cosmos.nft.v1beta1.EventSend", sendNft)
+	nftExtractor.RegisterType("likechain.likenft.v1.EventNewClass", createNftClass)
+	nftExtractor.RegisterType("likechain.likenft.v1.EventUpdateClass", updateNftClass)
+	nftExtractor.RegisterType("likechain.likenft.v1.EventMintNFT", mintNft)
+	nftExtractor.RegisterType("likechain.likenft.v1.EventCreateRoyaltyConfig", setNftRoyaltyConfig)
+	nftExtractor.RegisterType("likechain.likenft.v1.EventUpdateRoyaltyConfig", setNftRoyaltyConfig)
+	nftExtractor.RegisterType("likechain.likenft.v1.EventDeleteRoyaltyConfig", deleteNftRoyaltyConfig)
+	nftExtractor.RegisterType("cosmos.nft.v1beta1.EventSend", sendNft)
+	nftExtractor.RegisterType("cosmos.nft.v1beta1.EventBurn", burnNft)
+	nftExtractor.RegisterType("likechain.likenft.v1.EventBurnNFT", burnLikeNft)
 }
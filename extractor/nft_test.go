@@ -120,6 +120,138 @@ func TestCreateAndUpdateNft(t *testing.T) {
 	require.Equal(t, "AAAAAB", eventRes.Events[0].Memo)
 }
 
+func TestNftClassConfig(t *testing.T) {
+	defer CleanupTestData(Conn)
+	prefixA := "iscn://testing/aaaaaa"
+	iscns := []IscnInsert{
+		{
+			Iscn:  "iscn://testing/aaaaaa/1",
+			Owner: ADDR_01_LIKE,
+		},
+	}
+	blindBoxClassId := "likenft1blindbox"
+	classId := "likenft1normal"
+	timestamp := time.Unix(1234567890, 0).UTC()
+	blindBoxConfig := fmt.Sprintf(`{"burnable":true,"max_supply":"5","blind_box_config":{"mint_periods":[{"start_time":"2020-01-01T00:00:00Z","allowed_addresses":["%[1]s"],"mint_price":"1000"},{"start_time":"2021-01-01T00:00:00Z","allowed_addresses":[],"mint_price":"2000"}],"reveal_time":"2100-01-01T00:00:00Z"}}`, ADDR_02_COSMOS)
+	config := `{"burnable":false,"max_supply":"10","blind_box_config":null}`
+	newClassTx := `{"txhash":"%[5]s","height":"1234","tx":{"body":{"memo":"","messages":[{"@type":"/likechain.likenft.v1.MsgNewClass","input":{"name":"","symbol":"","uri":"","uri_hash":"","config":%[4]s,"metadata":{},"description":""},"parent":{"type":"ISCN","iscn_id_prefix":"%[2]s"},"creator":"%[1]s"}]}},"logs":[{"log":"","events":[{"type":"likechain.likenft.v1.EventNewClass","attributes":[{"key":"parent_iscn_id_prefix","value":"\"%[2]s\""},{"key":"parent_account","value":"\"\""},{"key":"class_id","value":"\"%[3]s\""}]}],"msg_index":0}],"timestamp":"%[6]s"}`
+	txs := []string{
+		fmt.Sprintf(newClassTx, ADDR_01_LIKE, prefixA, blindBoxClassId, blindBoxConfig, "AAAAAA", timestamp.Format(time.RFC3339)),
+		fmt.Sprintf(newClassTx, ADDR_01_LIKE, prefixA, classId, config, "BBBBBB", timestamp.Format(time.RFC3339)),
+		fmt.Sprintf(`{"txhash":"CCCCCC","height":"1235","tx":{"body":{"memo":"","messages":[{"@type":"/likechain.likenft.v1.MsgCreateRoyaltyConfig","creator":"%[1]s","class_id":"%[2]s","royalty_config":{"rate_basis_points":"500","stakeholders":[{"account":"%[1]s","weight":"1"},{"account":"%[3]s","weight":"3"}]}}]}},"logs":[{"log":"","events":[{"type":"likechain.likenft.v1.EventCreateRoyaltyConfig","attributes":[{"key":"class_id","value":"\"%[2]s\""}]}],"msg_index":0}],"timestamp":"%[4]s"}`,
+			ADDR_01_LIKE, classId, ADDR_03_COSMOS, timestamp.Format(time.RFC3339)),
+	}
+	blockTime := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	InsertTestData(DBTestData{
+		Iscns:           iscns,
+		Txs:             txs,
+		LatestBlockTime: &blockTime,
+	})
+
+	finished, err := ExtractNamed(Conn, extractor.Extractors)
+	require.NoError(t, err)
+	require.True(t, finished)
+
+	pagination := PageRequest{Limit: 10}
+	hasBlindBox := true
+	res, err := GetClasses(Conn, QueryClassRequest{HasBlindBox: &hasBlindBox}, pagination)
+	require.NoError(t, err)
+	require.Len(t, res.Classes, 1)
+	require.Equal(t, blindBoxClassId, res.Classes[0].Id)
+
+	hasBlindBox = false
+	res, err = GetClasses(Conn, QueryClassRequest{HasBlindBox: &hasBlindBox}, pagination)
+	require.NoError(t, err)
+	require.Len(t, res.Classes, 1)
+	require.Equal(t, classId, res.Classes[0].Id)
+
+	res, err = GetClasses(Conn, QueryClassRequest{MintableNow: true}, pagination)
+	require.NoError(t, err)
+	require.Len(t, res.Classes, 1)
+	require.Equal(t, blindBoxClassId, res.Classes[0].Id)
+
+	// the mint periods are checked at the block time, and the reveal time does not matter
+	blockTime = time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	InsertTestData(DBTestData{LatestBlockTime: &blockTime})
+	res, err = GetClasses(Conn, QueryClassRequest{MintableNow: true}, pagination)
+	require.NoError(t, err)
+	require.Empty(t, res.Classes)
+	blockTime = time.Date(2101, 1, 1, 0, 0, 0, 0, time.UTC)
+	InsertTestData(DBTestData{LatestBlockTime: &blockTime})
+	res, err = GetClasses(Conn, QueryClassRequest{MintableNow: true}, pagination)
+	require.NoError(t, err)
+	require.Len(t, res.Classes, 1)
+	require.Equal(t, blindBoxClassId, res.Classes[0].Id)
+
+	res, err = GetClasses(Conn, QueryClassRequest{MaxSupplyLte: 5}, pagination)
+	require.NoError(t, err)
+	require.Len(t, res.Classes, 1)
+	require.Equal(t, blindBoxClassId, res.Classes[0].Id)
+
+	res, err = GetClasses(Conn, QueryClassRequest{MaxSupplyLte: 10}, pagination)
+	require.NoError(t, err)
+	require.Len(t, res.Classes, 2)
+
+	var allowedAddresses []string
+	var mintPrice uint64
+	err = Conn.QueryRow(context.Background(), `
+		SELECT allowed_addresses, mint_price FROM nft_class_mint_period WHERE class_id = $1 ORDER BY start_time LIMIT 1
+	`, blindBoxClassId).Scan(&allowedAddresses, &mintPrice)
+	require.NoError(t, err)
+	require.Equal(t, []string{ADDR_02_LIKE}, allowedAddresses)
+	require.Equal(t, uint64(1000), mintPrice)
+
+	var rateBasisPoints uint64
+	err = Conn.QueryRow(context.Background(), `SELECT royalty_rate_basis_points FROM nft_class WHERE class_id = $1`, classId).Scan(&rateBasisPoints)
+	require.NoError(t, err)
+	require.Equal(t, uint64(500), rateBasisPoints)
+	var stakeholderAccounts []string
+	err = Conn.QueryRow(context.Background(), `
+		SELECT array_agg(account ORDER BY weight) FROM nft_class_royalty_stakeholder WHERE class_id = $1
+	`, classId).Scan(&stakeholderAccounts)
+	require.NoError(t, err)
+	require.Equal(t, []string{ADDR_01_LIKE, ADDR_03_LIKE}, stakeholderAccounts)
+
+	// the blind box class revealed by removing the blind box config, and the royalty config deleted
+	txs = []string{
+		fmt.Sprintf(`{"txhash":"DDDDDD","height":"1236","tx":{"body":{"memo":"","messages":[{"@type":"/likechain.likenft.v1.MsgUpdateClass","class_id":"%[2]s","input":{"name":"","symbol":"","uri":"","uri_hash":"","config":{"burnable":true,"max_supply":"5","blind_box_config":null},"metadata":{},"description":""},"creator":"%[1]s"}]}},"logs":[{"log":"","events":[{"type":"likechain.likenft.v1.EventUpdateClass","attributes":[{"key":"class_id","value":"\"%[2]s\""}]}],"msg_index":0}],"timestamp":"%[3]s"}`,
+			ADDR_01_LIKE, blindBoxClassId, timestamp.Format(time.RFC3339)),
+		fmt.Sprintf(`{"txhash":"EEEEEE","height":"1236","tx":{"body":{"memo":"","messages":[{"@type":"/likechain.likenft.v1.MsgDeleteRoyaltyConfig","creator":"%[1]s","class_id":"%[2]s"}]}},"logs":[{"log":"","events":[{"type":"likechain.likenft.v1.EventDeleteRoyaltyConfig","attributes":[{"key":"class_id","value":"\"%[2]s\""}]}],"msg_index":0}],"timestamp":"%[3]s"}`,
+			ADDR_01_LIKE, classId, timestamp.Format(time.RFC3339)),
+	}
+	InsertTestData(DBTestData{Txs: txs})
+
+	finished, err = ExtractNamed(Conn, extractor.Extractors)
+	require.NoError(t, err)
+	require.True(t, finished)
+
+	res, err = GetClasses(Conn, QueryClassRequest{MintableNow: true}, pagination)
+	require.NoError(t, err)
+	require.Empty(t, res.Classes)
+
+	historyRes, err := GetNftClassConfigHistory(Conn, QueryNftClassConfigHistoryRequest{ClassId: blindBoxClassId}, pagination)
+	require.NoError(t, err)
+	require.Len(t, historyRes.History, 2)
+	require.Equal(t, "AAAAAA", historyRes.History[0].TxHash)
+	require.Equal(t, "DDDDDD", historyRes.History[1].TxHash)
+	require.Equal(t, NFT_CLASS_CONFIG_TYPE_CLASS, historyRes.History[1].ConfigType)
+
+	historyRes, err = GetNftClassConfigHistory(Conn, QueryNftClassConfigHistoryRequest{
+		ClassId:    classId,
+		ConfigType: []NftClassConfigType{NFT_CLASS_CONFIG_TYPE_ROYALTY},
+	}, pagination)
+	require.NoError(t, err)
+	require.Len(t, historyRes.History, 2)
+	require.Equal(t, "CCCCCC", historyRes.History[0].TxHash)
+	require.Equal(t, "EEEEEE", historyRes.History[1].TxHash)
+	require.Empty(t, historyRes.History[1].Config)
+
+	var stakeholderCount int
+	err = Conn.QueryRow(context.Background(), `SELECT COUNT(*) FROM nft_class_royalty_stakeholder WHERE class_id = $1`, classId).Scan(&stakeholderCount)
+	require.NoError(t, err)
+	require.Zero(t, stakeholderCount)
+}
+
 func TestSendNft(t *testing.T) {
 	defer CleanupTestData(Conn)
 	prefixA := "iscn://testing/aaaaaa"
@@ -189,6 +321,191 @@ func TestSendNft(t *testing.T) {
 	require.Equal(t, "AAAAAA", eventRes.Events[0].Memo)
 }
 
+func TestBurnNft(t *testing.T) {
+	defer CleanupTestData(Conn)
+	prefixA := "iscn://testing/aaaaaa"
+	iscns := []IscnInsert{
+		{
+			Iscn:  "iscn://testing/aaaaaa/1",
+			Owner: ADDR_01_LIKE,
+		},
+	}
+	nftClasses := []NftClass{
+		{
+			Id:     "nftlike1aaaaa1",
+			Parent: NftClassParent{IscnIdPrefix: prefixA},
+		},
+	}
+	nfts := []Nft{
+		{
+			NftId:   "testing-nft-919775",
+			ClassId: nftClasses[0].Id,
+			Owner:   ADDR_02_LIKE,
+		},
+		{
+			NftId:   "testing-nft-919776",
+			ClassId: nftClasses[0].Id,
+			Owner:   ADDR_03_LIKE,
+		},
+	}
+	timestamp := time.Unix(1234567890, 0).UTC()
+	// a likenft burn emitting both burn events
+	txs := []string{
+		fmt.Sprintf(`{"txhash":"AAAAAA","height":"1234","tx":{"body":{"messages":[{"@type":"/likechain.likenft.v1.MsgBurnNFT","creator":"%[1]s","class_id":"%[2]s","nft_id":"%[3]s"}],"memo":""}},"logs":[{"msg_index":0,"log":"","events":[{"type":"cosmos.nft.v1beta1.EventBurn","attributes":[{"key":"class_id","value":"\"%[2]s\""},{"key":"id","value":"\"%[3]s\""},{"key":"owner","value":"\"%[1]s\""}]},{"type":"likechain.likenft.v1.EventBurnNFT","attributes":[{"key":"class_id","value":"\"%[2]s\""},{"key":"nft_id","value":"\"%[3]s\""},{"key":"owner","value":"\"%[1]s\""}]},{"type":"message","attributes":[{"key":"action","value":"burn_nft"}]}]}],"timestamp":"%[4]s"}`, ADDR_02_LIKE, nftClasses[0].Id, nfts[0].NftId, timestamp.Format(time.RFC3339)),
+	}
+	InsertTestData(DBTestData{
+		Iscns:      iscns,
+		NftClasses: nftClasses,
+		Nfts:       nfts,
+		Txs:        txs,
+	})
+
+	finished, err := ExtractNamed(Conn, extractor.Extractors)
+	require.NoError(t, err)
+	require.True(t, finished)
+
+	eventRes, err := GetNftEvents(Conn, QueryEventsRequest{
+		ClassId: nftClasses[0].Id,
+	}, PageRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, eventRes.Events, 1)
+	require.Equal(t, ACTION_BURN, eventRes.Events[0].Action)
+	require.Equal(t, nfts[0].NftId, eventRes.Events[0].NftId)
+	require.Equal(t, ADDR_02_LIKE, eventRes.Events[0].Sender)
+	require.Empty(t, eventRes.Events[0].Receiver)
+
+	ownersRes, err := GetOwners(Conn, QueryOwnerRequest{
+		ClassId: nftClasses[0].Id,
+	})
+	require.NoError(t, err)
+	require.Len(t, ownersRes.Owners, 1)
+	require.Equal(t, ADDR_03_LIKE, ownersRes.Owners[0].Owner)
+
+	ownersRes, err = GetOwners(Conn, QueryOwnerRequest{
+		ClassId:       nftClasses[0].Id,
+		IncludeBurned: true,
+	})
+	require.NoError(t, err)
+	require.Len(t, ownersRes.Owners, 2)
+
+	classesOwnersRes, err := GetClassesOwners(Conn, QueryClassesOwnersRequest{
+		ClassIds: []string{nftClasses[0].Id},
+	})
+	require.NoError(t, err)
+	require.Len(t, classesOwnersRes.Owners, 1)
+	require.Contains(t, classesOwnersRes.Owners, ADDR_03_LIKE)
+
+	countRes, err := GetNftCount(Conn, QueryNftCountRequest{IncludeOwner: true})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), countRes.Count)
+
+	countRes, err = GetNftCount(Conn, QueryNftCountRequest{IncludeOwner: true, IncludeBurned: true})
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), countRes.Count)
+}
+
+func TestSendNftThroughAuthz(t *testing.T) {
+	defer CleanupTestData(Conn)
+	prefixA := "iscn://testing/aaaaaa"
+	iscns := []IscnInsert{
+		{
+			Iscn:  "iscn://testing/aaaaaa/1",
+			Owner: ADDR_01_LIKE,
+		},
+	}
+	nftClasses := []NftClass{
+		{
+			Id:     "nftlike1aaaaa1",
+			Parent: NftClassParent{IscnIdPrefix: prefixA},
+		},
+	}
+	nfts := []Nft{
+		{
+			NftId:   "testing-nft-919775",
+			ClassId: nftClasses[0].Id,
+			Owner:   ADDR_01_LIKE,
+		},
+		{
+			NftId:   "testing-nft-919776",
+			ClassId: nftClasses[0].Id,
+			Owner:   ADDR_01_LIKE,
+		},
+	}
+	granter := ADDR_01_LIKE
+	grantee := ADDR_03_LIKE
+	// the first NFT is sent by the grantee on behalf of the owner, the second one is sent by the owner directly
+	txs := []string{
+		fmt.Sprintf(`{"txhash":"AAAAAA","height":"1234","tx":{"body":{"messages":[{"@type":"/cosmos.authz.v1beta1.MsgExec","grantee":"%[5]s","msgs":[{"@type":"/cosmos.nft.v1beta1.MsgSend","sender":"%[4]s","class_id":"%[2]s","id":"%[3]s","receiver":"%[1]s"}]}],"memo":"AAAAAA"}},"logs":[{"msg_index":0,"log":"","events":[{"type":"cosmos.nft.v1beta1.EventSend","attributes":[{"key":"class_id","value":"\"%[2]s\""},{"key":"id","value":"\"%[3]s\""},{"key":"sender","value":"\"%[4]s\""},{"key":"receiver","value":"\"%[1]s\""},{"key":"authz_msg_index","value":"0"}]},{"type":"message","attributes":[{"key":"action","value":"/cosmos.authz.v1beta1.MsgExec"},{"key":"authz_msg_index","value":"0"}]}]}]}`, ADDR_02_LIKE, nftClasses[0].Id, nfts[0].NftId, granter, grantee),
+		fmt.Sprintf(`{"txhash":"BBBBBB","height":"1235","tx":{"body":{"messages":[{"@type":"/cosmos.nft.v1beta1.MsgSend","sender":"%[4]s","class_id":"%[2]s","id":"%[3]s","receiver":"%[1]s"}],"memo":"BBBBBB"}},"logs":[{"msg_index":0,"log":"","events":[{"type":"cosmos.nft.v1beta1.EventSend","attributes":[{"key":"class_id","value":"\"%[2]s\""},{"key":"id","value":"\"%[3]s\""},{"key":"sender","value":"\"%[4]s\""},{"key":"receiver","value":"\"%[1]s\""}]},{"type":"message","attributes":[{"key":"action","value":"/cosmos.nft.v1beta1.MsgSend"}]}]}]}`, ADDR_02_LIKE, nftClasses[0].Id, nfts[1].NftId, granter),
+	}
+	InsertTestData(DBTestData{
+		Iscns:      iscns,
+		NftClasses: nftClasses,
+		Nfts:       nfts,
+		Txs:        txs,
+	})
+
+	finished, err := ExtractNamed(Conn, extractor.Extractors)
+	require.NoError(t, err)
+	require.True(t, finished)
+
+	eventRes, err := GetNftEvents(Conn, QueryEventsRequest{
+		ClassId: nftClasses[0].Id,
+	}, PageRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, eventRes.Events, 2)
+
+	eventRes, err = GetNftEvents(Conn, QueryEventsRequest{
+		Executor: []string{grantee},
+	}, PageRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, eventRes.Events, 1)
+	require.Equal(t, ACTION_SEND, eventRes.Events[0].Action)
+	require.Equal(t, nfts[0].NftId, eventRes.Events[0].NftId)
+	require.Equal(t, granter, eventRes.Events[0].Sender)
+	require.Equal(t, grantee, eventRes.Events[0].Executor)
+	require.Equal(t, granter, eventRes.Events[0].Granter)
+	require.Equal(t, "AAAAAA", eventRes.Events[0].TxHash)
+
+	viaAuthz := false
+	eventRes, err = GetNftEvents(Conn, QueryEventsRequest{
+		ClassId:  nftClasses[0].Id,
+		ViaAuthz: &viaAuthz,
+	}, PageRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, eventRes.Events, 1)
+	require.Equal(t, nfts[1].NftId, eventRes.Events[0].NftId)
+	require.Empty(t, eventRes.Events[0].Executor)
+	require.Empty(t, eventRes.Events[0].Granter)
+
+	viaAuthz = true
+	eventRes, err = GetNftEvents(Conn, QueryEventsRequest{
+		ClassId:  nftClasses[0].Id,
+		ViaAuthz: &viaAuthz,
+	}, PageRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, eventRes.Events, 1)
+	require.Equal(t, nfts[0].NftId, eventRes.Events[0].NftId)
+}
+
+// grantApiAddress sets the API address, and inserts the grant of the buyer to it for the token sends of the sales
+func grantApiAddress(t *testing.T, buyer string, apiAddress string, grantedAt time.Time) (reset func()) {
+	extractor.ApiAddresses = []string{apiAddress}
+	batch := NewBatch(Conn, 10)
+	batch.InsertAuthzGrant(AuthzGrant{
+		Granter:           buyer,
+		Grantee:           apiAddress,
+		MsgTypeUrl:        "/cosmos.bank.v1beta1.MsgSend",
+		AuthorizationType: "/cosmos.bank.v1beta1.SendAuthorization",
+		Authorization:     []byte(`{}`),
+		Height:            1,
+		TxHash:            "GRANT",
+		Timestamp:         grantedAt,
+	})
+	require.NoError(t, batch.Flush())
+	return func() { extractor.ApiAddresses = extractor.DefaultApiAddresses }
+}
+
 func TestSendNftWithPrice(t *testing.T) {
 	defer CleanupTestData(Conn)
 	buyer := ADDR_02_LIKE
@@ -232,10 +549,24 @@ func TestSendNftWithPrice(t *testing.T) {
 		Txs:        txs,
 	})
 
-	finished, err := Extract(Conn, extractor.ExtractFunc)
+	finished, err := ExtractNamed(Conn, extractor.Extractors)
 	require.NoError(t, err)
 	require.True(t, finished)
 
+	// not a sale without the grant of the buyer to the API address
+	var eventPrice uint64
+	err = Conn.QueryRow(context.Background(), `SELECT price FROM nft_event WHERE tx_hash = 'AAAAAA'`).Scan(&eventPrice)
+	require.NoError(t, err)
+	require.Zero(t, eventPrice)
+	var incomeCount int
+	err = Conn.QueryRow(context.Background(), `SELECT count(*) FROM nft_income WHERE tx_hash = 'AAAAAA'`).Scan(&incomeCount)
+	require.NoError(t, err)
+	require.Zero(t, incomeCount)
+
+	defer grantApiAddress(t, buyer, apiWallet, timestamp.Add(-time.Hour))()
+	err = Reextract(Conn, extractor.Extractors, 1233)
+	require.NoError(t, err)
+
 	ownersRes, err := GetOwners(Conn, QueryOwnerRequest{
 		ClassId: nftClasses[0].Id,
 	})
@@ -352,6 +683,7 @@ func TestSendMultipleNftsWithPrice(t *testing.T) {
 		Txs:        txs,
 	})
 
+	defer grantApiAddress(t, buyer, apiWallet, timestamp.Add(-time.Hour))()
 	finished, err := Extract(Conn, extractor.ExtractFunc)
 	require.NoError(t, err)
 	require.True(t, finished)
@@ -0,0 +1,111 @@
+package extractor
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/likecoin/likecoin-chain-tx-indexer/db"
+)
+
+// stakingMessage has the common fields of MsgCreateValidator, MsgDelegate, MsgUndelegate, MsgBeginRedelegate and
+// MsgWithdrawDelegatorReward, since the events do not include the delegator
+type stakingMessage struct {
+	DelegatorAddress string `json:"delegator_address"`
+}
+
+func getDelegator(payload *Payload) (string, error) {
+	var message stakingMessage
+	if err := json.Unmarshal(payload.GetMessage(), &message); err != nil {
+		return "", fmt.Errorf("failed to unmarshal staking message: %w", err)
+	}
+	if message.DelegatorAddress == "" {
+		return "", fmt.Errorf("no delegator address in staking message")
+	}
+	return message.DelegatorAddress, nil
+}
+
+func parseCompletionTime(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse completion time %s: %w", s, err)
+	}
+	return &t, nil
+}
+
+// insertStakingEvents inserts a staking event for each coin in each record of the event, where the records are
+// separated by firstKey
+func insertStakingEvents(payload *Payload, event *types.StringEvent, action db.StakingAction, firstKey string) error {
+	delegator, err := getDelegator(payload)
+	if err != nil {
+		return err
+	}
+	for _, record := range eventRecords(event, firstKey) {
+		coins, err := types.ParseCoinsNormalized(record["amount"])
+		if err != nil {
+			return fmt.Errorf("failed to parse staking amount %s: %w", record["amount"], err)
+		}
+		completionTime, err := parseCompletionTime(record["completion_time"])
+		if err != nil {
+			return err
+		}
+		validator := record["validator"]
+		destinationValidator := ""
+		if action == db.STAKING_ACTION_REDELEGATE {
+			validator = record["source_validator"]
+			destinationValidator = record["destination_validator"]
+		}
+		for _, coin := range coins {
+			payload.Batch.InsertStakingEvent(db.StakingEvent{
+				Height:               payload.Height,
+				TxHash:               payload.TxHash,
+				MessageIndex:         payload.MsgIndex,
+				Action:               action,
+				Delegator:            delegator,
+				Validator:            validator,
+				DestinationValidator: destinationValidator,
+				Denom:                coin.Denom,
+				Amount:               coin.Amount.String(),
+				CompletionTime:       completionTime,
+				Timestamp:            payload.Timestamp,
+			})
+		}
+	}
+	return nil
+}
+
+func delegate(payload *Payload, event *types.StringEvent) error {
+	return insertStakingEvents(payload, event, db.STAKING_ACTION_DELEGATE, "validator")
+}
+
+// createValidator records the self delegation of the new validator
+func createValidator(payload *Payload, event *types.StringEvent) error {
+	return insertStakingEvents(payload, event, db.STAKING_ACTION_DELEGATE, "validator")
+}
+
+func undelegate(payload *Payload, event *types.StringEvent) error {
+	return insertStakingEvents(payload, event, db.STAKING_ACTION_UNDELEGATE, "validator")
+}
+
+func redelegate(payload *Payload, event *types.StringEvent) error {
+	return insertStakingEvents(payload, event, db.STAKING_ACTION_REDELEGATE, "source_validator")
+}
+
+// withdrawRewards records the rewards withdrawn explicitly, and also those withdrawn automatically on delegation
+// changes
+func withdrawRewards(payload *Payload, event *types.StringEvent) error {
+	return insertStakingEvents(payload, event, db.STAKING_ACTION_WITHDRAW_REWARDS, "amount")
+}
+
+func init() {
+	stakingExtractor.RegisterType("create_validator", createValidator)
+	stakingExtractor.RegisterType("delegate", delegate)
+	stakingExtractor.RegisterType("unbond", undelegate)
+	stakingExtractor.RegisterType("redelegate", redelegate)
+	stakingExtractor.RegisterType("withdraw_rewards", withdrawRewards)
+}
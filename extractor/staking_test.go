@@ -0,0 +1,80 @@
+package extractor_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	. "github.com/likecoin/likecoin-chain-tx-indexer/db"
+	"github.com/likecoin/likecoin-chain-tx-indexer/extractor"
+	. "github.com/likecoin/likecoin-chain-tx-indexer/test"
+	"github.com/likecoin/likecoin-chain-tx-indexer/utils"
+)
+
+func TestStaking(t *testing.T) {
+	defer CleanupTestData(Conn)
+	delegator := ADDR_01_LIKE
+	validatorA, err := utils.ConvertAddressPrefix(ADDR_02_LIKE, MainValidatorAddressPrefix)
+	require.NoError(t, err)
+	validatorB, err := utils.ConvertAddressPrefix(ADDR_03_LIKE, MainValidatorAddressPrefix)
+	require.NoError(t, err)
+	day1 := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+	completionTime := day2.Add(21 * 24 * time.Hour)
+	txs := []string{
+		fmt.Sprintf(
+			`{"height":"10","txhash":"AAAAAA","tx":{"body":{"messages":[{"@type":"/cosmos.staking.v1beta1.MsgDelegate","delegator_address":"%[1]s","validator_address":"%[2]s","amount":{"denom":"nanolike","amount":"1000"}}],"memo":""}},"logs":[{"msg_index":0,"log":"","events":[{"type":"delegate","attributes":[{"key":"validator","value":"%[2]s"},{"key":"amount","value":"1000nanolike"},{"key":"new_shares","value":"1000.000000000000000000"}]},{"type":"message","attributes":[{"key":"action","value":"/cosmos.staking.v1beta1.MsgDelegate"},{"key":"module","value":"staking"},{"key":"sender","value":"%[1]s"}]}]}],"timestamp":"%[3]s"}`,
+			delegator, validatorA, day1.Format(time.RFC3339),
+		),
+		fmt.Sprintf(
+			`{"height":"20","txhash":"BBBBBB","tx":{"body":{"messages":[{"@type":"/cosmos.staking.v1beta1.MsgBeginRedelegate","delegator_address":"%[1]s","validator_src_address":"%[2]s","validator_dst_address":"%[3]s","amount":{"denom":"nanolike","amount":"400"}},{"@type":"/cosmos.staking.v1beta1.MsgUndelegate","delegator_address":"%[1]s","validator_address":"%[2]s","amount":{"denom":"nanolike","amount":"100"}}],"memo":""}},"logs":[{"msg_index":0,"log":"","events":[{"type":"redelegate","attributes":[{"key":"source_validator","value":"%[2]s"},{"key":"destination_validator","value":"%[3]s"},{"key":"amount","value":"400nanolike"},{"key":"completion_time","value":"%[5]s"}]},{"type":"withdraw_rewards","attributes":[{"key":"amount","value":"5nanolike"},{"key":"validator","value":"%[2]s"},{"key":"amount","value":""},{"key":"validator","value":"%[3]s"}]}]},{"msg_index":1,"log":"","events":[{"type":"unbond","attributes":[{"key":"validator","value":"%[2]s"},{"key":"amount","value":"100nanolike"},{"key":"completion_time","value":"%[5]s"}]}]}],"timestamp":"%[4]s"}`,
+			delegator, validatorA, validatorB, day2.Format(time.RFC3339), completionTime.Format(time.RFC3339),
+		),
+	}
+	InsertTestData(DBTestData{Txs: txs})
+
+	finished, err := ExtractNamed(Conn, extractor.Extractors)
+	require.NoError(t, err)
+	require.True(t, finished)
+
+	p := PageRequest{Limit: 10}
+	res, err := GetStakingEvents(Conn, QueryStakingEventsRequest{Delegator: delegator}, p)
+	require.NoError(t, err)
+	require.Len(t, res.Events, 4)
+	require.Equal(t, STAKING_ACTION_DELEGATE, res.Events[0].Action)
+	require.Equal(t, validatorA, res.Events[0].Validator)
+	require.Equal(t, "1000", res.Events[0].Amount)
+	require.Equal(t, STAKING_ACTION_REDELEGATE, res.Events[1].Action)
+	require.Equal(t, validatorB, res.Events[1].DestinationValidator)
+	require.Equal(t, completionTime, res.Events[1].CompletionTime.UTC())
+	require.Equal(t, STAKING_ACTION_WITHDRAW_REWARDS, res.Events[2].Action)
+	require.Equal(t, "5", res.Events[2].Amount)
+	require.Equal(t, STAKING_ACTION_UNDELEGATE, res.Events[3].Action)
+	require.Equal(t, 1, res.Events[3].MessageIndex)
+
+	res, err = GetStakingEvents(Conn, QueryStakingEventsRequest{Validator: validatorB}, p)
+	require.NoError(t, err)
+	require.Len(t, res.Events, 1)
+	require.Equal(t, STAKING_ACTION_REDELEGATE, res.Events[0].Action)
+
+	delegations, err := GetDelegations(Conn, QueryDelegationsRequest{Delegator: delegator, Denom: "nanolike", Interval: "day"})
+	require.NoError(t, err)
+	require.Equal(t, []DelegationBucket{
+		{
+			Time: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), Delegated: "1000", Undelegated: "0",
+			RedelegatedIn: "0", RedelegatedOut: "0", Rewards: "0",
+		},
+		{
+			Time: time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC), Delegated: "0", Undelegated: "100",
+			RedelegatedIn: "400", RedelegatedOut: "400", Rewards: "5",
+		},
+	}, delegations.Buckets)
+
+	delegations, err = GetDelegations(Conn, QueryDelegationsRequest{Validator: validatorB, Denom: "nanolike", Interval: "month"})
+	require.NoError(t, err)
+	require.Len(t, delegations.Buckets, 1)
+	require.Equal(t, "400", delegations.Buckets[0].RedelegatedIn)
+	require.Equal(t, "0", delegations.Buckets[0].RedelegatedOut)
+}
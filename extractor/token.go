@@ -0,0 +1,191 @@
+package extractor
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	"github.com/cosmos/cosmos-sdk/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+
+	"github.com/likecoin/likecoin-chain-tx-indexer/db"
+	"github.com/likecoin/likecoin-chain-tx-indexer/utils"
+)
+
+var feeCollectorAddress = types.MustBech32ifyAddressBytes(db.MainAddressPrefix, authtypes.NewModuleAddress(authtypes.FeeCollectorName))
+
+type txAuthInfo struct {
+	SignerInfos []struct {
+		PublicKey *struct {
+			Type string `json:"@type"`
+			Key  []byte `json:"key"`
+		} `json:"public_key"`
+	} `json:"signer_infos"`
+	Fee struct {
+		Amount []struct {
+			Denom  string `json:"denom"`
+			Amount string `json:"amount"`
+		} `json:"amount"`
+		Payer   string `json:"payer"`
+		Granter string `json:"granter"`
+	} `json:"fee"`
+}
+
+// getFeePayer returns the account deducted for the fee, which is the fee granter if any, or the fee payer, which
+// defaults to the first signer
+func getFeePayer(authInfo txAuthInfo) (string, error) {
+	if authInfo.Fee.Granter != "" {
+		return authInfo.Fee.Granter, nil
+	}
+	if authInfo.Fee.Payer != "" {
+		return authInfo.Fee.Payer, nil
+	}
+	if len(authInfo.SignerInfos) == 0 || authInfo.SignerInfos[0].PublicKey == nil {
+		return "", fmt.Errorf("no signer for the fee")
+	}
+	pubKey := authInfo.SignerInfos[0].PublicKey
+	if pubKey.Type != "/cosmos.crypto.secp256k1.PubKey" {
+		return "", fmt.Errorf("unsupported public key type %s of the fee payer", pubKey.Type)
+	}
+	return types.Bech32ifyAddressBytes(db.MainAddressPrefix, (&secp256k1.PubKey{Key: pubKey.Key}).Address())
+}
+
+func extractFee(ctx db.EventContext) error {
+	if len(ctx.AuthInfo) == 0 || string(ctx.AuthInfo) == "null" {
+		return nil
+	}
+	var authInfo txAuthInfo
+	if err := json.Unmarshal(ctx.AuthInfo, &authInfo); err != nil {
+		return fmt.Errorf("failed to unmarshal auth info: %w", err)
+	}
+	if len(authInfo.Fee.Amount) == 0 {
+		return nil
+	}
+	payer, err := getFeePayer(authInfo)
+	if err != nil {
+		return err
+	}
+	for _, coin := range authInfo.Fee.Amount {
+		amount, ok := types.NewIntFromString(coin.Amount)
+		if !ok {
+			return fmt.Errorf("failed to parse fee amount %s", coin.Amount)
+		}
+		if amount.IsZero() {
+			continue
+		}
+		ctx.Batch.InsertTokenTransfer(db.TokenTransfer{
+			Height:    ctx.Height,
+			TxHash:    ctx.TxHash,
+			Type:      db.TOKEN_TRANSFER_TYPE_FEE,
+			Sender:    payer,
+			Recipient: feeCollectorAddress,
+			Denom:     coin.Denom,
+			Amount:    amount.String(),
+			Timestamp: ctx.Timestamp,
+		})
+		ctx.Batch.InsertTokenBalanceChange(db.TokenBalanceChange{
+			Height:  ctx.Height,
+			TxHash:  ctx.TxHash,
+			Address: payer,
+			Denom:   coin.Denom,
+			Amount:  amount.Neg().String(),
+		})
+	}
+	return nil
+}
+
+// eventRecords splits the attributes of the events of the same type, which are merged into one event in the logs,
+// into records each starting from the attribute with firstKey
+func eventRecords(event *types.StringEvent, firstKey string) []map[string]string {
+	records := []map[string]string{}
+	for _, attr := range event.Attributes {
+		if attr.Key == firstKey || len(records) == 0 {
+			records = append(records, map[string]string{})
+		}
+		records[len(records)-1][attr.Key] = attr.Value
+	}
+	return records
+}
+
+func insertBalanceChanges(payload *Payload, address string, coins types.Coins, spent bool) {
+	for _, coin := range coins {
+		amount := coin.Amount
+		if spent {
+			amount = amount.Neg()
+		}
+		payload.Batch.InsertTokenBalanceChange(db.TokenBalanceChange{
+			Height:  payload.Height,
+			TxHash:  payload.TxHash,
+			Address: address,
+			Denom:   coin.Denom,
+			Amount:  amount.String(),
+		})
+	}
+}
+
+func insertTokenTransfers(payload *Payload, event *types.StringEvent) error {
+	events := payload.GetEvents()
+	// txs before coin_spent and coin_received events were introduced only have transfer events
+	hasCoinEvents := utils.GetEventsValue(events, "coin_spent", "spender") != "" ||
+		utils.GetEventsValue(events, "coin_received", "receiver") != ""
+	// transfer events of MsgMultiSend have no sender
+	messageSender := utils.GetEventsValue(events, "message", "sender")
+	for _, record := range eventRecords(event, "recipient") {
+		sender := record["sender"]
+		if sender == "" {
+			sender = messageSender
+		}
+		coins, err := types.ParseCoinsNormalized(record["amount"])
+		if err != nil {
+			return fmt.Errorf("failed to parse transfer amount %s: %w", record["amount"], err)
+		}
+		for _, coin := range coins {
+			messageIndex := payload.MsgIndex
+			payload.Batch.InsertTokenTransfer(db.TokenTransfer{
+				Height:       payload.Height,
+				TxHash:       payload.TxHash,
+				MessageIndex: &messageIndex,
+				Type:         db.TOKEN_TRANSFER_TYPE_TRANSFER,
+				Sender:       sender,
+				Recipient:    record["recipient"],
+				Denom:        coin.Denom,
+				Amount:       coin.Amount.String(),
+				Timestamp:    payload.Timestamp,
+			})
+		}
+		if !hasCoinEvents {
+			insertBalanceChanges(payload, sender, coins, true)
+			insertBalanceChanges(payload, record["recipient"], coins, false)
+		}
+	}
+	return nil
+}
+
+func insertCoinSpent(payload *Payload, event *types.StringEvent) error {
+	for _, record := range eventRecords(event, "spender") {
+		coins, err := types.ParseCoinsNormalized(record["amount"])
+		if err != nil {
+			return fmt.Errorf("failed to parse spent amount %s: %w", record["amount"], err)
+		}
+		insertBalanceChanges(payload, record["spender"], coins, true)
+	}
+	return nil
+}
+
+func insertCoinReceived(payload *Payload, event *types.StringEvent) error {
+	for _, record := range eventRecords(event, "receiver") {
+		coins, err := types.ParseCoinsNormalized(record["amount"])
+		if err != nil {
+			return fmt.Errorf("failed to parse received amount %s: %w", record["amount"], err)
+		}
+		insertBalanceChanges(payload, record["receiver"], coins, false)
+	}
+	return nil
+}
+
+func init() {
+	tokenExtractor.RegisterTx(extractFee)
+	tokenExtractor.RegisterType("transfer", insertTokenTransfers)
+	tokenExtractor.RegisterType("coin_spent", insertCoinSpent)
+	tokenExtractor.RegisterType("coin_received", insertCoinReceived)
+}
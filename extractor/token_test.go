@@ -0,0 +1,79 @@
+package extractor_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	. "github.com/likecoin/likecoin-chain-tx-indexer/db"
+	"github.com/likecoin/likecoin-chain-tx-indexer/extractor"
+	. "github.com/likecoin/likecoin-chain-tx-indexer/test"
+)
+
+func TestTokenTransfer(t *testing.T) {
+	defer CleanupTestData(Conn)
+	timestamp := time.Unix(1234567890, 0).UTC().Format(time.RFC3339)
+	txs := []string{
+		// MsgSend with coin events and fee paid by the fee payer
+		fmt.Sprintf(
+			`{"height":"10","txhash":"AAAAAA","tx":{"body":{"messages":[{"@type":"/cosmos.bank.v1beta1.MsgSend","from_address":"%[1]s","to_address":"%[2]s","amount":[{"denom":"nanolike","amount":"1000"}]}],"memo":""},"auth_info":{"signer_infos":[],"fee":{"amount":[{"denom":"nanolike","amount":"10"}],"payer":"%[1]s","granter":""}}},"logs":[{"msg_index":0,"log":"","events":[{"type":"coin_received","attributes":[{"key":"receiver","value":"%[2]s"},{"key":"amount","value":"1000nanolike"}]},{"type":"coin_spent","attributes":[{"key":"spender","value":"%[1]s"},{"key":"amount","value":"1000nanolike"}]},{"type":"message","attributes":[{"key":"action","value":"/cosmos.bank.v1beta1.MsgSend"},{"key":"sender","value":"%[1]s"},{"key":"module","value":"bank"}]},{"type":"transfer","attributes":[{"key":"recipient","value":"%[2]s"},{"key":"sender","value":"%[1]s"},{"key":"amount","value":"1000nanolike"}]}]}],"timestamp":"%[3]s"}`,
+			ADDR_01_LIKE, ADDR_02_LIKE, timestamp,
+		),
+		// MsgMultiSend without coin events, and the sender of transfers is in the message event
+		fmt.Sprintf(
+			`{"height":"20","txhash":"BBBBBB","tx":{"body":{"messages":[{"@type":"/cosmos.bank.v1beta1.MsgMultiSend"}],"memo":""}},"logs":[{"msg_index":0,"log":"","events":[{"type":"message","attributes":[{"key":"action","value":"multisend"},{"key":"sender","value":"%[2]s"},{"key":"module","value":"bank"}]},{"type":"transfer","attributes":[{"key":"recipient","value":"%[1]s"},{"key":"amount","value":"100nanolike"},{"key":"recipient","value":"%[3]s"},{"key":"amount","value":"200nanolike"}]}]}],"timestamp":"%[4]s"}`,
+			ADDR_01_LIKE, ADDR_02_LIKE, ADDR_03_LIKE, timestamp,
+		),
+	}
+	InsertTestData(DBTestData{Txs: txs})
+
+	finished, err := ExtractNamed(Conn, extractor.Extractors)
+	require.NoError(t, err)
+	require.True(t, finished)
+
+	p := PageRequest{Limit: 10}
+	res, err := GetTokenTransfers(Conn, QueryTokenTransfersRequest{Address: ADDR_01_LIKE}, p)
+	require.NoError(t, err)
+	require.Len(t, res.Transfers, 3)
+	require.Equal(t, TOKEN_TRANSFER_TYPE_FEE, res.Transfers[0].Type)
+	require.Equal(t, ADDR_01_LIKE, res.Transfers[0].Sender)
+	require.Equal(t, "10", res.Transfers[0].Amount)
+	require.Nil(t, res.Transfers[0].MessageIndex)
+	require.Equal(t, TOKEN_TRANSFER_TYPE_TRANSFER, res.Transfers[1].Type)
+	require.Equal(t, ADDR_02_LIKE, res.Transfers[1].Recipient)
+	require.Equal(t, "1000", res.Transfers[1].Amount)
+	require.Equal(t, 0, *res.Transfers[1].MessageIndex)
+	require.Equal(t, ADDR_02_LIKE, res.Transfers[2].Sender)
+	require.Equal(t, ADDR_01_LIKE, res.Transfers[2].Recipient)
+	require.Equal(t, "100", res.Transfers[2].Amount)
+
+	res, err = GetTokenTransfers(Conn, QueryTokenTransfersRequest{Sender: ADDR_02_LIKE}, p)
+	require.NoError(t, err)
+	require.Len(t, res.Transfers, 2)
+	require.Equal(t, ADDR_03_LIKE, res.Transfers[1].Recipient)
+	require.Equal(t, "200", res.Transfers[1].Amount)
+
+	table := []struct {
+		address string
+		height  int64
+		amount  string
+	}{
+		{ADDR_01_LIKE, 10, "-1010"},
+		{ADDR_01_LIKE, 0, "-910"},
+		{ADDR_02_LIKE, 10, "1000"},
+		{ADDR_02_LIKE, 20, "700"},
+		{ADDR_03_LIKE, 0, "200"},
+	}
+	for _, test := range table {
+		balance, err := GetTokenBalance(Conn, QueryTokenBalanceRequest{Address: test.address, Height: test.height})
+		require.NoError(t, err)
+		require.Len(t, balance.Balances, 1)
+		require.Equal(t, "nanolike", balance.Balances[0].Denom)
+		require.Equal(t, test.amount, balance.Balances[0].Amount, "address = %s, height = %d", test.address, test.height)
+	}
+	balance, err := GetTokenBalance(Conn, QueryTokenBalanceRequest{Address: ADDR_01_LIKE, Height: 9})
+	require.NoError(t, err)
+	require.Empty(t, balance.Balances)
+}
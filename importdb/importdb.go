@@ -48,7 +48,16 @@ func Run(pool *pgxpool.Pool, likedPath string) {
 	batch := db.NewBatch(conn, batchSize)
 	for height := startHeight; height < maxHeight; height++ {
 		block := blockStore.LoadBlock(height)
+		blockMeta := blockStore.LoadBlockMeta(height)
 		txs := block.Data.Txs
+		batch.InsertBlock(db.Block{
+			Height:   height,
+			Hash:     blockMeta.BlockID.Hash.String(),
+			Time:     block.Header.Time,
+			Proposer: block.Header.ProposerAddress.String(),
+			NumTxs:   len(txs),
+			AppHash:  block.Header.AppHash.String(),
+		})
 		for txIndex, tx := range txs {
 			txHash := bytes.HexBytes(tx.Hash())
 			txResult, err := txIndexer.Get(txHash)
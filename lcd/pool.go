@@ -0,0 +1,325 @@
+package lcd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/likecoin/likecoin-chain-tx-indexer/logger"
+	"github.com/likecoin/likecoin-chain-tx-indexer/utils"
+)
+
+var defaultMaxAttempts = utils.EnvInt("LCD_MAX_ATTEMPTS", 3)
+var defaultRetryDelay = time.Duration(utils.EnvInt("LCD_RETRY_DELAY_MS", 200)) * time.Millisecond
+var defaultFailureThreshold = utils.EnvInt("LCD_FAILURE_THRESHOLD", 5)
+var defaultCooldown = time.Duration(utils.EnvInt("LCD_COOLDOWN", 30)) * time.Second
+var defaultMaxHeightLag = int64(utils.EnvInt("LCD_MAX_HEIGHT_LAG", 10))
+var defaultHealthCheckInterval = time.Duration(utils.EnvInt("LCD_HEALTH_CHECK_INTERVAL", 10)) * time.Second
+
+const latestBlockPath = "/cosmos/base/tendermint/v1beta1/blocks/latest"
+
+// weight of the latest sample in the moving average of latency
+const latencyAlpha = 0.2
+
+var ErrNoEndpoint = fmt.Errorf("no available lcd endpoint")
+
+// StatusError is returned when the endpoint responds with non-200 status code
+type StatusError struct {
+	StatusCode int
+}
+
+func (err *StatusError) Error() string {
+	return fmt.Sprintf("non-200 code returned: %d", err.StatusCode)
+}
+
+type Endpoint struct {
+	URL   string
+	proxy *httputil.ReverseProxy
+
+	lock                sync.Mutex
+	successCount        uint64
+	failureCount        uint64
+	consecutiveFailures int
+	openUntil           time.Time
+	latency             time.Duration
+	latestHeight        int64
+	lastError           string
+}
+
+type EndpointStatus struct {
+	URL                 string  `json:"url"`
+	Available           bool    `json:"available"`
+	CircuitOpen         bool    `json:"circuit_open"`
+	Lagging             bool    `json:"lagging"`
+	LatestHeight        int64   `json:"latest_height"`
+	SuccessCount        uint64  `json:"success_count"`
+	FailureCount        uint64  `json:"failure_count"`
+	ConsecutiveFailures int     `json:"consecutive_failures"`
+	LatencyMs           float64 `json:"latency_ms"`
+	LastError           string  `json:"last_error,omitempty"`
+}
+
+// Pool is a set of LCD endpoints serving the same chain.
+// Requests are sent to the healthiest endpoint, and retried on the other endpoints with jittered back-off on failure.
+// An endpoint is skipped when its circuit breaker is open (after FailureThreshold consecutive failures, for Cooldown),
+// or when it lags behind the highest height known among all endpoints by more than MaxHeightLag.
+type Pool struct {
+	Client           *http.Client
+	MaxAttempts      int
+	RetryDelay       time.Duration
+	FailureThreshold int
+	Cooldown         time.Duration
+	MaxHeightLag     int64
+
+	endpoints []*Endpoint
+}
+
+func NewPool(endpointURLs []string, client *http.Client) (*Pool, error) {
+	if len(endpointURLs) == 0 {
+		return nil, ErrNoEndpoint
+	}
+	pool := &Pool{
+		Client:           client,
+		MaxAttempts:      defaultMaxAttempts,
+		RetryDelay:       defaultRetryDelay,
+		FailureThreshold: defaultFailureThreshold,
+		Cooldown:         defaultCooldown,
+		MaxHeightLag:     defaultMaxHeightLag,
+	}
+	for _, endpointURL := range endpointURLs {
+		endpointURL = strings.TrimSuffix(endpointURL, "/")
+		u, err := url.Parse(endpointURL)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse lcd URL, error = %w, lcd_endpoint = %s", err, endpointURL)
+		}
+		endpoint := &Endpoint{URL: endpointURL}
+		endpoint.proxy = httputil.NewSingleHostReverseProxy(u)
+		endpoint.proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+			pool.reportFailure(endpoint, err)
+			w.WriteHeader(http.StatusBadGateway)
+		}
+		pool.endpoints = append(pool.endpoints, endpoint)
+	}
+	return pool, nil
+}
+
+func (pool *Pool) maxKnownHeight() int64 {
+	maxHeight := int64(0)
+	for _, endpoint := range pool.endpoints {
+		endpoint.lock.Lock()
+		if endpoint.latestHeight > maxHeight {
+			maxHeight = endpoint.latestHeight
+		}
+		endpoint.lock.Unlock()
+	}
+	return maxHeight
+}
+
+func (pool *Pool) status(endpoint *Endpoint, maxHeight int64, now time.Time) EndpointStatus {
+	endpoint.lock.Lock()
+	defer endpoint.lock.Unlock()
+	status := EndpointStatus{
+		URL:                 endpoint.URL,
+		CircuitOpen:         now.Before(endpoint.openUntil),
+		LatestHeight:        endpoint.latestHeight,
+		SuccessCount:        endpoint.successCount,
+		FailureCount:        endpoint.failureCount,
+		ConsecutiveFailures: endpoint.consecutiveFailures,
+		LatencyMs:           float64(endpoint.latency) / float64(time.Millisecond),
+		LastError:           endpoint.lastError,
+	}
+	// endpoints with unknown height are not considered as lagging
+	status.Lagging = endpoint.latestHeight > 0 && maxHeight-endpoint.latestHeight > pool.MaxHeightLag
+	status.Available = !status.CircuitOpen && !status.Lagging
+	return status
+}
+
+// Status returns the health of all endpoints
+func (pool *Pool) Status() []EndpointStatus {
+	maxHeight := pool.maxKnownHeight()
+	now := time.Now()
+	statuses := make([]EndpointStatus, 0, len(pool.endpoints))
+	for _, endpoint := range pool.endpoints {
+		statuses = append(statuses, pool.status(endpoint, maxHeight, now))
+	}
+	return statuses
+}
+
+// pick returns the healthiest available endpoint which is not excluded.
+// Endpoints with fewer consecutive failures are preferred, then the ones with lower latency.
+func (pool *Pool) pick(excluded map[*Endpoint]bool) *Endpoint {
+	maxHeight := pool.maxKnownHeight()
+	now := time.Now()
+	var best *Endpoint
+	var bestStatus EndpointStatus
+	for _, endpoint := range pool.endpoints {
+		if excluded[endpoint] {
+			continue
+		}
+		status := pool.status(endpoint, maxHeight, now)
+		if !status.Available {
+			continue
+		}
+		if best == nil ||
+			status.ConsecutiveFailures < bestStatus.ConsecutiveFailures ||
+			(status.ConsecutiveFailures == bestStatus.ConsecutiveFailures && status.LatencyMs < bestStatus.LatencyMs) {
+			best = endpoint
+			bestStatus = status
+		}
+	}
+	return best
+}
+
+func (pool *Pool) reportSuccess(endpoint *Endpoint, latency time.Duration) {
+	endpoint.lock.Lock()
+	defer endpoint.lock.Unlock()
+	endpoint.successCount++
+	endpoint.consecutiveFailures = 0
+	if endpoint.latency == 0 {
+		endpoint.latency = latency
+	} else {
+		endpoint.latency = time.Duration(latencyAlpha*float64(latency) + (1-latencyAlpha)*float64(endpoint.latency))
+	}
+}
+
+func (pool *Pool) reportFailure(endpoint *Endpoint, err error) {
+	endpoint.lock.Lock()
+	defer endpoint.lock.Unlock()
+	endpoint.failureCount++
+	endpoint.consecutiveFailures++
+	endpoint.lastError = err.Error()
+	if endpoint.consecutiveFailures >= pool.FailureThreshold {
+		// half-open after cooldown: one more failure opens the circuit again
+		endpoint.openUntil = time.Now().Add(pool.Cooldown)
+		logger.L.Warnw("LCD endpoint circuit opened", "lcd_endpoint", endpoint.URL, "error", err, "cooldown", pool.Cooldown)
+	}
+}
+
+func (pool *Pool) updateHeight(endpoint *Endpoint, height int64) {
+	endpoint.lock.Lock()
+	defer endpoint.lock.Unlock()
+	endpoint.latestHeight = height
+}
+
+func (pool *Pool) get(endpoint *Endpoint, path string) ([]byte, error) {
+	start := time.Now()
+	resp, err := pool.Client.Get(endpoint.URL + path)
+	if err != nil {
+		pool.reportFailure(endpoint, err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		pool.reportFailure(endpoint, err)
+		return nil, err
+	}
+	if resp.StatusCode != 200 {
+		err = &StatusError{StatusCode: resp.StatusCode}
+		if resp.StatusCode >= 500 {
+			pool.reportFailure(endpoint, err)
+		} else {
+			// client errors (e.g. tx not found) do not mean the endpoint is unhealthy
+			pool.reportSuccess(endpoint, time.Since(start))
+		}
+		return nil, err
+	}
+	pool.reportSuccess(endpoint, time.Since(start))
+	return body, nil
+}
+
+// Get queries the path (e.g. /cosmos/tx/v1beta1/txs/{hash}) and returns the response body.
+// On failure, it retries on the other endpoints with jittered exponential back-off, up to MaxAttempts in total.
+func (pool *Pool) Get(path string) ([]byte, error) {
+	excluded := map[*Endpoint]bool{}
+	delay := pool.RetryDelay
+	var lastErr error = ErrNoEndpoint
+	for attempt := 0; attempt < pool.MaxAttempts; attempt++ {
+		if attempt > 0 {
+			// full jitter, so retries from concurrent workers do not hit the endpoints at the same time
+			time.Sleep(time.Duration(rand.Int63n(int64(delay) + 1)))
+			delay *= 2
+		}
+		endpoint := pool.pick(excluded)
+		if endpoint == nil {
+			// all endpoints are tried, allow retrying them
+			excluded = map[*Endpoint]bool{}
+			endpoint = pool.pick(excluded)
+			if endpoint == nil {
+				return nil, lastErr
+			}
+		}
+		excluded[endpoint] = true
+		body, err := pool.get(endpoint, path)
+		if err == nil {
+			return body, nil
+		}
+		logger.L.Debugw("LCD request failed", "lcd_endpoint", endpoint.URL, "path", path, "attempt", attempt, "error", err)
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+type latestBlockResponse struct {
+	Block struct {
+		Header struct {
+			Height string `json:"height"`
+		} `json:"header"`
+	} `json:"block"`
+}
+
+// CheckHealth queries the latest height of every endpoint, which is used to reject lagging endpoints
+func (pool *Pool) CheckHealth() {
+	wg := sync.WaitGroup{}
+	for _, endpoint := range pool.endpoints {
+		wg.Add(1)
+		go func(endpoint *Endpoint) {
+			defer wg.Done()
+			body, err := pool.get(endpoint, latestBlockPath)
+			if err != nil {
+				logger.L.Warnw("LCD endpoint health check failed", "lcd_endpoint", endpoint.URL, "error", err)
+				return
+			}
+			res := latestBlockResponse{}
+			err = json.Unmarshal(body, &res)
+			if err == nil {
+				var height int64
+				height, err = strconv.ParseInt(res.Block.Header.Height, 10, 64)
+				if err == nil {
+					pool.updateHeight(endpoint, height)
+					return
+				}
+			}
+			pool.reportFailure(endpoint, fmt.Errorf("cannot parse latest block: %w", err))
+		}(endpoint)
+	}
+	wg.Wait()
+}
+
+// StartHealthCheck runs CheckHealth periodically in background
+func (pool *Pool) StartHealthCheck() {
+	pool.CheckHealth()
+	go func() {
+		for range time.Tick(defaultHealthCheckInterval) {
+			pool.CheckHealth()
+		}
+	}()
+}
+
+// ServeHTTP reverse proxies the request to the healthiest endpoint
+func (pool *Pool) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	endpoint := pool.pick(nil)
+	if endpoint == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	endpoint.proxy.ServeHTTP(w, r)
+}
@@ -0,0 +1,196 @@
+package lcd_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+
+	. "github.com/likecoin/likecoin-chain-tx-indexer/lcd"
+	"github.com/likecoin/likecoin-chain-tx-indexer/logger"
+)
+
+type testEndpoint struct {
+	server   *httptest.Server
+	height   int64
+	failing  int32
+	requests int32
+}
+
+func newTestEndpoint(name string, height int64) *testEndpoint {
+	endpoint := &testEndpoint{height: height}
+	endpoint.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&endpoint.requests, 1)
+		if atomic.LoadInt32(&endpoint.failing) != 0 {
+			w.WriteHeader(500)
+			return
+		}
+		if r.URL.Path == "/cosmos/base/tendermint/v1beta1/blocks/latest" {
+			fmt.Fprintf(w, `{"block":{"header":{"height":"%d"}}}`, atomic.LoadInt64(&endpoint.height))
+			return
+		}
+		fmt.Fprint(w, name)
+	}))
+	return endpoint
+}
+
+func newTestPool(t *testing.T, endpoints ...*testEndpoint) *Pool {
+	urls := []string{}
+	for _, endpoint := range endpoints {
+		urls = append(urls, endpoint.server.URL+"/")
+	}
+	pool, err := NewPool(urls, &http.Client{})
+	require.NoError(t, err)
+	pool.MaxAttempts = 2
+	pool.RetryDelay = time.Millisecond
+	pool.FailureThreshold = 2
+	pool.Cooldown = time.Hour
+	pool.MaxHeightLag = 10
+	return pool
+}
+
+func TestPoolFailover(t *testing.T) {
+	a := newTestEndpoint("a", 100)
+	defer a.server.Close()
+	b := newTestEndpoint("b", 100)
+	defer b.server.Close()
+	pool := newTestPool(t, a, b)
+
+	atomic.StoreInt32(&a.failing, 1)
+	for i := 0; i < 5; i++ {
+		body, err := pool.Get("/test")
+		require.NoError(t, err)
+		require.Equal(t, "b", string(body))
+	}
+	// after the first failure, b is preferred since it has fewer consecutive failures
+	require.Equal(t, int32(1), atomic.LoadInt32(&a.requests))
+
+	statuses := pool.Status()
+	require.Len(t, statuses, 2)
+	require.Equal(t, 1, statuses[0].ConsecutiveFailures)
+	require.Equal(t, uint64(1), statuses[0].FailureCount)
+	require.True(t, statuses[1].Available)
+	require.Equal(t, uint64(5), statuses[1].SuccessCount)
+
+	// b fails too, so the circuit of a is opened after its second failure
+	atomic.StoreInt32(&b.failing, 1)
+	_, err := pool.Get("/test")
+	require.Error(t, err)
+	require.True(t, pool.Status()[0].CircuitOpen)
+	require.False(t, pool.Status()[0].Available)
+
+	// b recovers, and a is not requested anymore while its circuit is open
+	atomic.StoreInt32(&b.failing, 0)
+	atomic.StoreInt32(&a.requests, 0)
+	for i := 0; i < 5; i++ {
+		body, err := pool.Get("/test")
+		require.NoError(t, err)
+		require.Equal(t, "b", string(body))
+	}
+	require.Zero(t, atomic.LoadInt32(&a.requests))
+}
+
+func TestPoolAllFailing(t *testing.T) {
+	a := newTestEndpoint("a", 100)
+	defer a.server.Close()
+	pool := newTestPool(t, a)
+	pool.MaxAttempts = 3
+
+	atomic.StoreInt32(&a.failing, 1)
+	_, err := pool.Get("/test")
+	require.Error(t, err)
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	require.Equal(t, 500, statusErr.StatusCode)
+	// circuit is opened after 2 failures, so the third attempt is not sent
+	require.Equal(t, int32(2), atomic.LoadInt32(&a.requests))
+
+	_, err = pool.Get("/test")
+	require.ErrorIs(t, err, ErrNoEndpoint)
+}
+
+func TestPoolRejectLagging(t *testing.T) {
+	a := newTestEndpoint("a", 50)
+	defer a.server.Close()
+	b := newTestEndpoint("b", 100)
+	defer b.server.Close()
+	pool := newTestPool(t, a, b)
+
+	pool.CheckHealth()
+	statuses := pool.Status()
+	require.True(t, statuses[0].Lagging)
+	require.False(t, statuses[0].Available)
+	require.Equal(t, int64(50), statuses[0].LatestHeight)
+	require.False(t, statuses[1].Lagging)
+
+	atomic.StoreInt32(&a.requests, 0)
+	for i := 0; i < 5; i++ {
+		body, err := pool.Get("/test")
+		require.NoError(t, err)
+		require.Equal(t, "b", string(body))
+	}
+	require.Zero(t, atomic.LoadInt32(&a.requests))
+
+	// a catches up
+	atomic.StoreInt64(&a.height, 95)
+	pool.CheckHealth()
+	require.True(t, pool.Status()[0].Available)
+}
+
+func TestPoolClientErrorNotFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(404)
+	}))
+	defer server.Close()
+	pool, err := NewPool([]string{server.URL}, &http.Client{})
+	require.NoError(t, err)
+	pool.RetryDelay = time.Millisecond
+	pool.FailureThreshold = 1
+
+	for i := 0; i < 3; i++ {
+		_, err = pool.Get("/cosmos/tx/v1beta1/txs/ABCD")
+		require.Error(t, err)
+	}
+	require.True(t, pool.Status()[0].Available)
+	require.Zero(t, pool.Status()[0].FailureCount)
+}
+
+func TestPoolReverseProxy(t *testing.T) {
+	a := newTestEndpoint("a", 100)
+	defer a.server.Close()
+	b := newTestEndpoint("b", 100)
+	defer b.server.Close()
+	pool := newTestPool(t, a, b)
+
+	atomic.StoreInt32(&a.failing, 1)
+	// make b preferred after the failure of a
+	_, err := pool.Get("/test")
+	require.NoError(t, err)
+
+	proxy := httptest.NewServer(pool)
+	defer proxy.Close()
+	res, err := http.Get(proxy.URL + "/cosmos/bank/v1beta1/balances/like1xxx")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.Equal(t, 200, res.StatusCode)
+	require.Equal(t, "b", string(body))
+}
+
+func TestNewPoolEmpty(t *testing.T) {
+	_, err := NewPool(nil, &http.Client{})
+	require.ErrorIs(t, err, ErrNoEndpoint)
+}
+
+func TestMain(m *testing.M) {
+	logger.SetupLogger(zapcore.DebugLevel, []string{"stdout"}, "console")
+	os.Exit(m.Run())
+}
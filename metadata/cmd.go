@@ -0,0 +1,43 @@
+package metadata
+
+import (
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/spf13/cobra"
+)
+
+const (
+	CmdResolverEnabled = "metadata-resolver"
+	CmdIpfsGateway     = "metadata-ipfs-gateway"
+	CmdArweaveGateway  = "metadata-arweave-gateway"
+	CmdAllowHTTP       = "metadata-allow-http"
+)
+
+func ConfigCmd(cmd *cobra.Command) {
+	cmd.PersistentFlags().Bool(CmdResolverEnabled, false, "Resolve the off-chain metadata pointed by nft and nft class uri in background")
+	cmd.PersistentFlags().StringSlice(CmdIpfsGateway, DefaultIpfsGateways, "IPFS gateways for resolving `ipfs://` uri, tried in order")
+	cmd.PersistentFlags().StringSlice(CmdArweaveGateway, DefaultArweaveGateways, "Arweave gateways for resolving `ar://` uri, tried in order")
+	cmd.PersistentFlags().Bool(CmdAllowHTTP, false, "Also resolve plain `http://` uri and follow redirects to `http://`")
+}
+
+// NewResolverFromCmd returns nil if the resolver is not enabled
+func NewResolverFromCmd(cmd *cobra.Command, pool *pgxpool.Pool) (*Resolver, error) {
+	enabled, err := cmd.Flags().GetBool(CmdResolverEnabled)
+	if err != nil || !enabled {
+		return nil, err
+	}
+	ipfsGateways, err := cmd.Flags().GetStringSlice(CmdIpfsGateway)
+	if err != nil {
+		return nil, err
+	}
+	arweaveGateways, err := cmd.Flags().GetStringSlice(CmdArweaveGateway)
+	if err != nil {
+		return nil, err
+	}
+	allowHTTP, err := cmd.Flags().GetBool(CmdAllowHTTP)
+	if err != nil {
+		return nil, err
+	}
+	fetcher := NewHTTPFetcher(ipfsGateways, arweaveGateways, NewPublicHTTPClient(allowHTTP))
+	fetcher.AllowHTTP = allowHTTP
+	return NewResolver(pool, fetcher), nil
+}
@@ -0,0 +1,225 @@
+package metadata
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/likecoin/likecoin-chain-tx-indexer/utils"
+)
+
+var defaultMaxBodySize = int64(utils.EnvInt("METADATA_MAX_BODY_SIZE", 1024*1024))
+
+var DefaultIpfsGateways = []string{"https://ipfs.io/ipfs/", "https://dweb.link/ipfs/"}
+var DefaultArweaveGateways = []string{"https://arweave.net/"}
+
+var ErrUnsupportedUri = errors.New("unsupported uri scheme")
+var ErrHashMismatch = errors.New("content does not match uri_hash")
+var ErrBodyTooLarge = errors.New("response body too large")
+var ErrNonPublicAddress = errors.New("non-public address")
+
+// nonPublicNetworks are the special-purpose networks not covered by the net.IP methods in isPublicIP
+var nonPublicNetworks = parseCIDRs(
+	"0.0.0.0/8",     // this network
+	"100.64.0.0/10", // carrier-grade NAT
+	"192.0.0.0/24",  // IETF protocol assignments
+	"198.18.0.0/15", // benchmarking
+	"240.0.0.0/4",   // reserved
+	"64:ff9b::/96",  // NAT64, which may translate to private IPv4 addresses
+)
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := []*net.IPNet{}
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+func isPublicIP(ip net.IP) bool {
+	if ip.IsUnspecified() || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsMulticast() {
+		return false
+	}
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// dialPublicOnly is the Control of the dialer, called with the resolved IP of each connection, so hosts resolving to
+// internal services (e.g. the cloud metadata endpoint 169.254.169.254) cannot be reached
+func dialPublicOnly(network, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublicIP(ip) {
+		return fmt.Errorf("%w: %s", ErrNonPublicAddress, host)
+	}
+	return nil
+}
+
+// checkURL rejects the urls other than `https://`, and also `http://` unless allowHTTP
+func checkURL(url string, allowHTTP bool) error {
+	switch {
+	case strings.HasPrefix(url, "https://"):
+	case allowHTTP && strings.HasPrefix(url, "http://"):
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedUri, url)
+	}
+	return nil
+}
+
+// NewPublicHTTPClient returns the client for fetching the uris from the chain, which only connects to public IPs,
+// also on redirects, and only follows redirects to `https://` unless allowHTTP.
+// Proxies from the environment are not used, since the IPs could not be checked behind them.
+func NewPublicHTTPClient(allowHTTP bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   dialPublicOnly,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			if err := checkURL(req.URL.String(), allowHTTP); err != nil {
+				return err
+			}
+			if ip := net.ParseIP(req.URL.Hostname()); ip != nil && !isPublicIP(ip) {
+				return fmt.Errorf("%w: %s", ErrNonPublicAddress, ip)
+			}
+			return nil
+		},
+	}
+}
+
+// Fetcher fetches the raw content pointed by an uri
+type Fetcher interface {
+	Fetch(ctx context.Context, uri string) ([]byte, error)
+}
+
+// HTTPFetcher fetches `ipfs://` and `ar://` uris through the gateways and `https://` uris directly, and also `http://`
+// uris if AllowHTTP. Gateways are tried in order until one of them responds with the content.
+type HTTPFetcher struct {
+	Client          *http.Client
+	IpfsGateways    []string
+	ArweaveGateways []string
+	MaxBodySize     int64
+	AllowHTTP       bool
+}
+
+func NewHTTPFetcher(ipfsGateways, arweaveGateways []string, client *http.Client) *HTTPFetcher {
+	return &HTTPFetcher{
+		Client:          client,
+		IpfsGateways:    ipfsGateways,
+		ArweaveGateways: arweaveGateways,
+		MaxBodySize:     defaultMaxBodySize,
+	}
+}
+
+func gatewayURLs(gateways []string, path string) []string {
+	urls := []string{}
+	for _, gateway := range gateways {
+		urls = append(urls, strings.TrimSuffix(gateway, "/")+"/"+path)
+	}
+	return urls
+}
+
+// URLs returns the http urls to fetch the uri from, in the order to try
+func (f *HTTPFetcher) URLs(uri string) ([]string, error) {
+	switch {
+	case strings.HasPrefix(uri, "ipfs://"):
+		path := strings.TrimPrefix(strings.TrimPrefix(uri, "ipfs://"), "ipfs/")
+		return gatewayURLs(f.IpfsGateways, path), nil
+	case strings.HasPrefix(uri, "ar://"):
+		return gatewayURLs(f.ArweaveGateways, strings.TrimPrefix(uri, "ar://")), nil
+	case strings.HasPrefix(uri, "https://"), strings.HasPrefix(uri, "http://"):
+		if err := checkURL(uri, f.AllowHTTP); err != nil {
+			return nil, err
+		}
+		return []string{uri}, nil
+	}
+	return nil, ErrUnsupportedUri
+}
+
+func (f *HTTPFetcher) fetchURL(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := f.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("non-200 code returned: %d", res.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(res.Body, f.MaxBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > f.MaxBodySize {
+		return nil, ErrBodyTooLarge
+	}
+	return body, nil
+}
+
+func (f *HTTPFetcher) Fetch(ctx context.Context, uri string) ([]byte, error) {
+	urls, err := f.URLs(uri)
+	if err != nil {
+		return nil, err
+	}
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("no gateway configured for uri %s", uri)
+	}
+	for _, url := range urls {
+		var body []byte
+		body, err = f.fetchURL(ctx, url)
+		if err == nil {
+			return body, nil
+		}
+		if errors.Is(err, ErrBodyTooLarge) || ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, err
+}
+
+// VerifyUriHash checks the content against uri_hash when it is a hex-encoded SHA-256 digest.
+// Other formats of uri_hash cannot be verified, so verified is false without error.
+func VerifyUriHash(content []byte, uriHash string) (verified bool, err error) {
+	hash := strings.TrimPrefix(strings.ToLower(strings.TrimSpace(uriHash)), "sha256:")
+	if len(hash) != sha256.Size*2 {
+		return false, nil
+	}
+	if _, err := hex.DecodeString(hash); err != nil {
+		return false, nil
+	}
+	digest := sha256.Sum256(content)
+	if hex.EncodeToString(digest[:]) != hash {
+		return false, ErrHashMismatch
+	}
+	return true, nil
+}
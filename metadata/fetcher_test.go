@@ -0,0 +1,164 @@
+package metadata_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	. "github.com/likecoin/likecoin-chain-tx-indexer/metadata"
+)
+
+func TestHTTPFetcherURLs(t *testing.T) {
+	fetcher := NewHTTPFetcher(
+		[]string{"https://ipfs-a.example/ipfs/", "https://ipfs-b.example/ipfs"},
+		[]string{"https://arweave.example"},
+		&http.Client{},
+	)
+	table := []struct {
+		uri  string
+		urls []string
+		err  error
+	}{
+		{
+			uri:  "ipfs://bafybeigdyrzt/metadata.json",
+			urls: []string{"https://ipfs-a.example/ipfs/bafybeigdyrzt/metadata.json", "https://ipfs-b.example/ipfs/bafybeigdyrzt/metadata.json"},
+		},
+		{
+			uri:  "ipfs://ipfs/bafybeigdyrzt",
+			urls: []string{"https://ipfs-a.example/ipfs/bafybeigdyrzt", "https://ipfs-b.example/ipfs/bafybeigdyrzt"},
+		},
+		{
+			uri:  "ar://abcdef",
+			urls: []string{"https://arweave.example/abcdef"},
+		},
+		{
+			uri:  "https://like.co/api/metadata?id=1",
+			urls: []string{"https://like.co/api/metadata?id=1"},
+		},
+		{uri: "http://like.co/api/metadata?id=1", err: ErrUnsupportedUri},
+		{uri: "data:application/json,{}", err: ErrUnsupportedUri},
+		{uri: "", err: ErrUnsupportedUri},
+	}
+	for i, v := range table {
+		urls, err := fetcher.URLs(v.uri)
+		if v.err != nil {
+			require.ErrorIs(t, err, v.err, "Error in test case #%02d", i)
+			continue
+		}
+		require.NoError(t, err, "Error in test case #%02d", i)
+		require.Equal(t, v.urls, urls, "Error in test case #%02d", i)
+	}
+
+	fetcher.AllowHTTP = true
+	urls, err := fetcher.URLs("http://like.co/api/metadata?id=1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"http://like.co/api/metadata?id=1"}, urls)
+}
+
+func TestPublicHTTPClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	client := NewPublicHTTPClient(true)
+	for _, url := range []string{
+		server.URL,
+		"http://169.254.169.254/latest/meta-data/",
+		"http://10.0.0.1/",
+		"http://[::1]:" + server.URL[strings.LastIndex(server.URL, ":")+1:],
+		"http://[64:ff9b::a00:1]/",
+	} {
+		_, err := client.Get(url)
+		require.ErrorIs(t, err, ErrNonPublicAddress, url)
+	}
+
+	redirect := func(client *http.Client, url string) error {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		require.NoError(t, err)
+		return client.CheckRedirect(req, []*http.Request{req})
+	}
+	require.NoError(t, redirect(NewPublicHTTPClient(false), "https://like.co/api/metadata"))
+	require.ErrorIs(t, redirect(NewPublicHTTPClient(false), "http://like.co/api/metadata"), ErrUnsupportedUri)
+	require.NoError(t, redirect(NewPublicHTTPClient(true), "http://like.co/api/metadata"))
+	require.ErrorIs(t, redirect(NewPublicHTTPClient(true), "http://192.168.0.1/"), ErrNonPublicAddress)
+	require.ErrorIs(t, redirect(NewPublicHTTPClient(false), "https://127.0.0.1/"), ErrNonPublicAddress)
+}
+
+func TestHTTPFetcherGatewayFailover(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(504)
+	}))
+	defer failing.Close()
+	gatewayPath := ""
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gatewayPath = r.URL.Path
+		w.Write([]byte(`{"name":"test"}`))
+	}))
+	defer gateway.Close()
+
+	fetcher := NewHTTPFetcher([]string{failing.URL + "/ipfs/", gateway.URL + "/ipfs/"}, nil, &http.Client{})
+	body, err := fetcher.Fetch(context.Background(), "ipfs://bafybeigdyrzt")
+	require.NoError(t, err)
+	require.Equal(t, `{"name":"test"}`, string(body))
+	require.Equal(t, "/ipfs/bafybeigdyrzt", gatewayPath)
+
+	_, err = fetcher.Fetch(context.Background(), "ar://abcdef")
+	require.Error(t, err)
+
+	fetcher = NewHTTPFetcher([]string{failing.URL + "/ipfs/"}, nil, &http.Client{})
+	_, err = fetcher.Fetch(context.Background(), "ipfs://bafybeigdyrzt")
+	require.Error(t, err)
+}
+
+func TestHTTPFetcherMaxBodySize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`"` + strings.Repeat("a", 100) + `"`))
+	}))
+	defer server.Close()
+
+	fetcher := NewHTTPFetcher(nil, nil, &http.Client{})
+	fetcher.AllowHTTP = true
+	fetcher.MaxBodySize = 50
+	_, err := fetcher.Fetch(context.Background(), server.URL)
+	require.ErrorIs(t, err, ErrBodyTooLarge)
+
+	fetcher.MaxBodySize = 102
+	body, err := fetcher.Fetch(context.Background(), server.URL)
+	require.NoError(t, err)
+	require.Len(t, body, 102)
+}
+
+func TestVerifyUriHash(t *testing.T) {
+	content := []byte(`{"name":"test"}`)
+	digest := sha256.Sum256(content)
+	hash := hex.EncodeToString(digest[:])
+
+	table := []struct {
+		uriHash  string
+		verified bool
+		err      error
+	}{
+		{uriHash: hash, verified: true},
+		{uriHash: strings.ToUpper(hash), verified: true},
+		{uriHash: "sha256:" + hash, verified: true},
+		{uriHash: strings.Repeat("0", 64), err: ErrHashMismatch},
+		{uriHash: ""},
+		{uriHash: "bafybeigdyrzt"},
+		{uriHash: strings.Repeat("z", 64)},
+	}
+	for i, v := range table {
+		verified, err := VerifyUriHash(content, v.uriHash)
+		if v.err != nil {
+			require.ErrorIs(t, err, v.err, "Error in test case #%02d", i)
+			continue
+		}
+		require.NoError(t, err, "Error in test case #%02d", i)
+		require.Equal(t, v.verified, verified, "Error in test case #%02d", i)
+	}
+}
@@ -0,0 +1,164 @@
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"github.com/likecoin/likecoin-chain-tx-indexer/db"
+	"github.com/likecoin/likecoin-chain-tx-indexer/logger"
+	"github.com/likecoin/likecoin-chain-tx-indexer/utils"
+)
+
+var defaultBatchSize = utils.EnvInt("METADATA_RESOLVER_BATCH_SIZE", 100)
+var defaultConcurrency = utils.EnvInt("METADATA_RESOLVER_CONCURRENCY", 8)
+var defaultInterval = time.Duration(utils.EnvInt("METADATA_RESOLVER_INTERVAL", 10)) * time.Second
+var defaultFetchTimeout = time.Duration(utils.EnvInt("METADATA_RESOLVER_FETCH_TIMEOUT", 30)) * time.Second
+var defaultRetryBaseDelay = time.Duration(utils.EnvInt("METADATA_RESOLVER_RETRY_BASE_DELAY", 60)) * time.Second
+var defaultRetryMaxDelay = time.Duration(utils.EnvInt("METADATA_RESOLVER_RETRY_MAX_DELAY", 24*60*60)) * time.Second
+
+// Resolver fetches the uris queued in resolved_metadata and caches the content.
+// Failed fetches are retried with exponential back-off from RetryBaseDelay up to RetryMaxDelay,
+// while resolved uris and uris with unsupported scheme are never fetched again.
+type Resolver struct {
+	Pool           *pgxpool.Pool
+	Fetcher        Fetcher
+	BatchSize      int
+	Concurrency    int
+	Interval       time.Duration
+	FetchTimeout   time.Duration
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+}
+
+func NewResolver(pool *pgxpool.Pool, fetcher Fetcher) *Resolver {
+	return &Resolver{
+		Pool:           pool,
+		Fetcher:        fetcher,
+		BatchSize:      defaultBatchSize,
+		Concurrency:    defaultConcurrency,
+		Interval:       defaultInterval,
+		FetchTimeout:   defaultFetchTimeout,
+		RetryBaseDelay: defaultRetryBaseDelay,
+		RetryMaxDelay:  defaultRetryMaxDelay,
+	}
+}
+
+// RetryDelay returns the delay before the next fetch after the given number of failed attempts
+func (r *Resolver) RetryDelay(attempts int) time.Duration {
+	delay := r.RetryBaseDelay
+	for i := 1; i < attempts && delay < r.RetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > r.RetryMaxDelay {
+		delay = r.RetryMaxDelay
+	}
+	return delay
+}
+
+func (r *Resolver) resolve(m db.ResolvedMetadata, now time.Time) db.ResolvedMetadata {
+	ctx, cancel := context.WithTimeout(context.Background(), r.FetchTimeout)
+	defer cancel()
+
+	now = now.UTC()
+	m.Attempts++
+	m.FetchedAt = &now
+	m.Content = nil
+	m.HashVerified = false
+	m.Error = ""
+	m.NextAttemptAt = nil
+
+	content, err := r.Fetcher.Fetch(ctx, m.Uri)
+	if err == nil && !json.Valid(content) {
+		err = fmt.Errorf("content is not valid JSON")
+	}
+	if err == nil {
+		m.HashVerified, err = VerifyUriHash(content, m.UriHash)
+	}
+	switch {
+	case err == nil:
+		m.Status = db.RESOLVED_METADATA_STATUS_RESOLVED
+		m.Content = utils.SanitizeJSON(content)
+		return m
+	case errors.Is(err, ErrUnsupportedUri):
+		m.Status = db.RESOLVED_METADATA_STATUS_UNSUPPORTED
+	case errors.Is(err, ErrHashMismatch):
+		m.Status = db.RESOLVED_METADATA_STATUS_HASH_MISMATCH
+	default:
+		m.Status = db.RESOLVED_METADATA_STATUS_FAILED
+	}
+	m.Error = err.Error()
+	if m.Status != db.RESOLVED_METADATA_STATUS_UNSUPPORTED {
+		nextAttemptAt := now.Add(r.RetryDelay(m.Attempts))
+		m.NextAttemptAt = &nextAttemptAt
+	}
+	logger.L.Debugw("Failed to resolve uri metadata", "uri", m.Uri, "status", m.Status, "attempts", m.Attempts, "error", err)
+	return m
+}
+
+// ResolveDue fetches the uris due at the given time, and returns the number of uris processed
+func (r *Resolver) ResolveDue(now time.Time) (int, error) {
+	conn, err := db.AcquireFromPool(r.Pool)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+
+	due, err := db.GetDueResolvedMetadata(conn, now, r.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	results := make([]db.ResolvedMetadata, len(due))
+	concurrency := r.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+	wg := sync.WaitGroup{}
+	for i, m := range due {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, m db.ResolvedMetadata) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = r.resolve(m, now)
+		}(i, m)
+	}
+	wg.Wait()
+
+	for _, m := range results {
+		err = db.UpdateResolvedMetadata(conn, m)
+		if err != nil && m.Status == db.RESOLVED_METADATA_STATUS_RESOLVED {
+			// e.g. the content cannot be stored as JSONB, retry later instead of blocking the queue
+			m.Status = db.RESOLVED_METADATA_STATUS_FAILED
+			m.Content = nil
+			m.Error = err.Error()
+			nextAttemptAt := m.FetchedAt.Add(r.RetryDelay(m.Attempts))
+			m.NextAttemptAt = &nextAttemptAt
+			err = db.UpdateResolvedMetadata(conn, m)
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(results), nil
+}
+
+// Run resolves the due uris forever, and only sleeps for Interval when there is nothing left to resolve
+func (r *Resolver) Run() {
+	for {
+		count, err := r.ResolveDue(time.Now())
+		if err != nil {
+			logger.L.Errorw("Metadata resolver failed", "error", err)
+		}
+		if err != nil || count < r.BatchSize {
+			time.Sleep(r.Interval)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package metadata_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/likecoin/likecoin-chain-tx-indexer/db"
+	. "github.com/likecoin/likecoin-chain-tx-indexer/metadata"
+	. "github.com/likecoin/likecoin-chain-tx-indexer/test"
+)
+
+func newTestResolver(gatewayURL string) *Resolver {
+	fetcher := NewHTTPFetcher([]string{gatewayURL + "/ipfs/"}, nil, &http.Client{})
+	resolver := NewResolver(Pool, fetcher)
+	resolver.RetryBaseDelay = time.Hour
+	resolver.RetryMaxDelay = 3 * time.Hour
+	return resolver
+}
+
+func TestRetryDelay(t *testing.T) {
+	resolver := newTestResolver("")
+	require.Equal(t, time.Hour, resolver.RetryDelay(1))
+	require.Equal(t, 2*time.Hour, resolver.RetryDelay(2))
+	require.Equal(t, 3*time.Hour, resolver.RetryDelay(3))
+	require.Equal(t, 3*time.Hour, resolver.RetryDelay(100))
+}
+
+func TestResolver(t *testing.T) {
+	defer CleanupTestData(Conn)
+	classContent := `{"name": "class"}`
+	nftContent := `{"name": "nft", "image": "ipfs://image"}`
+	classDigest := sha256.Sum256([]byte(classContent))
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch strings.TrimPrefix(r.URL.Path, "/ipfs/") {
+		case "class":
+			w.Write([]byte(classContent))
+		case "nft":
+			w.Write([]byte(nftContent))
+		case "html":
+			w.Write([]byte("<html></html>"))
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer gateway.Close()
+
+	prefix := "iscn://testing/aaaaaa"
+	nftClasses := []db.NftClass{
+		{
+			Id:      "nftlike1aaaaa1",
+			Parent:  db.NftClassParent{IscnIdPrefix: prefix},
+			URI:     "ipfs://class",
+			URIHash: hex.EncodeToString(classDigest[:]),
+		},
+	}
+	nfts := []db.Nft{
+		{NftId: "nft-resolved", Uri: "ipfs://nft"},
+		{NftId: "nft-mismatch", Uri: "ipfs://nft", UriHash: strings.Repeat("0", 64)},
+		{NftId: "nft-missing", Uri: "ipfs://missing"},
+		{NftId: "nft-html", Uri: "ipfs://html"},
+		{NftId: "nft-unsupported", Uri: "data:application/json,{}"},
+		{NftId: "nft-no-uri"},
+	}
+	nftEvents := []db.NftEvent{}
+	for i := range nfts {
+		nfts[i].ClassId = nftClasses[0].Id
+		nfts[i].Owner = ADDR_01_LIKE
+		nftEvents = append(nftEvents, db.NftEvent{
+			Action:    db.ACTION_MINT,
+			ClassId:   nfts[i].ClassId,
+			NftId:     nfts[i].NftId,
+			Receiver:  ADDR_01_LIKE,
+			TxHash:    "TX_" + nfts[i].NftId,
+			Timestamp: time.Unix(1, 0),
+		})
+	}
+	InsertTestData(DBTestData{
+		Iscns:      []db.IscnInsert{{Iscn: prefix + "/1", Owner: ADDR_01_LIKE}},
+		NftClasses: nftClasses,
+		Nfts:       nfts,
+		NftEvents:  nftEvents,
+	})
+
+	resolver := newTestResolver(gateway.URL)
+	now := time.Now().Add(time.Minute).UTC().Truncate(time.Microsecond)
+	count, err := resolver.ResolveDue(now)
+	require.NoError(t, err)
+	require.Equal(t, 6, count)
+
+	table := []struct {
+		uri          string
+		uriHash      string
+		status       db.ResolvedMetadataStatus
+		content      string
+		hashVerified bool
+		retry        bool
+	}{
+		{uri: "ipfs://class", uriHash: nftClasses[0].URIHash, status: db.RESOLVED_METADATA_STATUS_RESOLVED, content: classContent, hashVerified: true},
+		{uri: "ipfs://nft", status: db.RESOLVED_METADATA_STATUS_RESOLVED, content: nftContent},
+		{uri: "ipfs://nft", uriHash: strings.Repeat("0", 64), status: db.RESOLVED_METADATA_STATUS_HASH_MISMATCH, retry: true},
+		{uri: "ipfs://missing", status: db.RESOLVED_METADATA_STATUS_FAILED, retry: true},
+		{uri: "ipfs://html", status: db.RESOLVED_METADATA_STATUS_FAILED, retry: true},
+		{uri: "data:application/json,{}", status: db.RESOLVED_METADATA_STATUS_UNSUPPORTED},
+	}
+	for i, v := range table {
+		m, err := db.GetResolvedMetadata(Conn, v.uri, v.uriHash)
+		require.NoError(t, err, "Error in test case #%02d", i)
+		require.NotNil(t, m, "Error in test case #%02d", i)
+		require.Equal(t, v.status, m.Status, "Error in test case #%02d", i)
+		require.Equal(t, 1, m.Attempts, "Error in test case #%02d", i)
+		require.Equal(t, v.hashVerified, m.HashVerified, "Error in test case #%02d", i)
+		if v.content != "" {
+			require.JSONEq(t, v.content, string(m.Content), "Error in test case #%02d", i)
+			require.Empty(t, m.Error, "Error in test case #%02d", i)
+		} else {
+			require.Empty(t, m.Content, "Error in test case #%02d", i)
+			require.NotEmpty(t, m.Error, "Error in test case #%02d", i)
+		}
+		if v.retry {
+			require.NotNil(t, m.NextAttemptAt, "Error in test case #%02d", i)
+			require.Equal(t, now.Add(time.Hour), *m.NextAttemptAt, "Error in test case #%02d", i)
+		} else {
+			require.Nil(t, m.NextAttemptAt, "Error in test case #%02d", i)
+		}
+	}
+
+	// nothing is due before the back-off delay
+	count, err = resolver.ResolveDue(now)
+	require.NoError(t, err)
+	require.Equal(t, 0, count)
+
+	count, err = resolver.ResolveDue(now.Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 3, count)
+	m, err := db.GetResolvedMetadata(Conn, "ipfs://missing", "")
+	require.NoError(t, err)
+	require.Equal(t, 2, m.Attempts)
+	require.Equal(t, now.Add(3*time.Hour), *m.NextAttemptAt)
+
+	classRes, err := db.GetClasses(Conn, db.QueryClassRequest{IscnIdPrefix: prefix}, db.PageRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, classRes.Classes, 1)
+	require.Empty(t, classRes.Classes[0].UriMetadata)
+
+	classRes, err = db.GetClasses(Conn, db.QueryClassRequest{IscnIdPrefix: prefix, ExpandUriMetadata: true}, db.PageRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, classRes.Classes, 1)
+	require.JSONEq(t, classContent, string(classRes.Classes[0].UriMetadata))
+
+	nftRes, err := db.GetNfts(Conn, db.QueryNftRequest{
+		Owner:             ADDR_01_LIKE,
+		ExpandClasses:     true,
+		ExpandUriMetadata: true,
+	}, db.PageRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, nftRes.Nfts, len(nfts))
+	for _, n := range nftRes.Nfts {
+		if n.NftId == "nft-resolved" {
+			require.JSONEq(t, nftContent, string(n.UriMetadata), "nft_id = %s", n.NftId)
+		} else {
+			require.Empty(t, n.UriMetadata, "nft_id = %s", n.NftId)
+		}
+		require.NotNil(t, n.ClassData)
+		require.JSONEq(t, classContent, string(n.ClassData.UriMetadata))
+	}
+}
+
+func TestMain(m *testing.M) {
+	SetupDbAndRunTest(m, nil)
+}
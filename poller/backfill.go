@@ -0,0 +1,152 @@
+package poller
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/likecoin/likecoin-chain-tx-indexer/db"
+	"github.com/likecoin/likecoin-chain-tx-indexer/logger"
+)
+
+// Backfill re-fetches the blocks from `from` to `to` (inclusive), and overwrites the indexed txs and block headers,
+// e.g. to replace the empty placeholder txs written by import, or the txs indexed from partial LCD responses.
+// If onlyMissing is set, only the heights reported by db.GetMissingHeights are re-fetched.
+// The extractors which have already extracted the backfilled heights are rewound to the lowest backfilled height and
+// replayed forward, so the txs are extracted again in order.
+// The latest block height is not changed, so backfilling does not interfere with the running poller.
+// It returns the number of backfilled heights.
+func Backfill(pool *pgxpool.Pool, source ChainSource, from, to int64, onlyMissing bool, extractors []db.NamedExtractor) (int, error) {
+	conn, err := db.AcquireFromPool(pool)
+	if err != nil {
+		return 0, fmt.Errorf("cannot acquire connection from database connection pool: %w", err)
+	}
+	defer conn.Release()
+	count := 0
+	lowest := int64(-1)
+	for chunkFrom := from; chunkFrom <= to; chunkFrom += batchMaxHeightDiff {
+		chunkTo := chunkFrom + batchMaxHeightDiff - 1
+		if chunkTo > to {
+			chunkTo = to
+		}
+		heights := []int64{}
+		if onlyMissing {
+			heights, err = db.GetMissingHeights(conn, chunkFrom, chunkTo)
+			if err != nil {
+				return count, err
+			}
+		} else {
+			for height := chunkFrom; height <= chunkTo; height++ {
+				heights = append(heights, height)
+			}
+		}
+		if len(heights) == 0 {
+			continue
+		}
+		err = backfillHeights(conn, source, heights)
+		if err != nil {
+			return count, err
+		}
+		if lowest < 0 {
+			lowest = heights[0]
+		}
+		count += len(heights)
+		logger.L.Infow("Backfilled blocks", "from", chunkFrom, "to", chunkTo, "backfilled", len(heights))
+	}
+	return count, reextract(conn, extractors, lowest)
+}
+
+// BackfillHeights is the same as Backfill, but re-fetches the listed heights, e.g. the heights in VerifyReport
+func BackfillHeights(pool *pgxpool.Pool, source ChainSource, heights []int64, extractors []db.NamedExtractor) (int, error) {
+	heights = append([]int64{}, heights...)
+	sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+	conn, err := db.AcquireFromPool(pool)
+	if err != nil {
+		return 0, fmt.Errorf("cannot acquire connection from database connection pool: %w", err)
+	}
+	defer conn.Release()
+	lowest := int64(-1)
+	if len(heights) > 0 {
+		lowest = heights[0]
+	}
+	count := 0
+	for len(heights) > 0 {
+		chunkSize := int(batchMaxHeightDiff)
+		if chunkSize > len(heights) {
+			chunkSize = len(heights)
+		}
+		chunk := heights[:chunkSize]
+		heights = heights[chunkSize:]
+		err = backfillHeights(conn, source, chunk)
+		if err != nil {
+			return count, err
+		}
+		count += len(chunk)
+		logger.L.Infow("Backfilled blocks", "from", chunk[0], "to", chunk[len(chunk)-1], "backfilled", len(chunk))
+	}
+	return count, reextract(conn, extractors, lowest)
+}
+
+// backfillHeights re-fetches and overwrites the sorted heights in one batch
+func backfillHeights(conn *pgxpool.Conn, source ChainSource, heights []int64) error {
+	batch := db.NewBatch(conn, batchSize)
+	for _, r := range contiguousRanges(heights) {
+		err := backfillRange(&batch, source, r[0], r[1])
+		if err != nil {
+			return err
+		}
+	}
+	err := batch.Flush()
+	if err != nil {
+		return fmt.Errorf("cannot flush transaction batch, error = %w, from = %d, to = %d", err, heights[0], heights[len(heights)-1])
+	}
+	return nil
+}
+
+// reextract extracts the txs from the lowest backfilled height again, where lowest is -1 if nothing is backfilled
+func reextract(conn *pgxpool.Conn, extractors []db.NamedExtractor, lowest int64) error {
+	if len(extractors) == 0 || lowest < 0 {
+		return nil
+	}
+	err := db.Reextract(conn, extractors, lowest-1)
+	if err != nil {
+		return fmt.Errorf("cannot extract backfilled heights, error = %w, from = %d", err, lowest)
+	}
+	return nil
+}
+
+func backfillRange(batch *db.Batch, source ChainSource, from, to int64) error {
+	done := make(chan struct{})
+	defer close(done)
+	for fetched := range FetchBlocks(source, from, to, fetchWorkers, fetchWindow, done) {
+		if fetched.Err != nil {
+			return fetched.Err
+		}
+		height := fetched.Height
+		for txIndex, txRes := range fetched.TxResponses {
+			err := batch.UpsertTx(*txRes, height, txIndex)
+			if err != nil {
+				return fmt.Errorf("cannot upsert transaction, error = %w, txhash = %s, height = %d, index = %d", err, txRes.TxHash, height, txIndex)
+			}
+		}
+		dbBlock, err := fetched.Block.ToDBBlock()
+		if err != nil {
+			return err
+		}
+		batch.InsertBlock(dbBlock)
+	}
+	return nil
+}
+
+// contiguousRanges groups the sorted heights into [from, to] ranges of consecutive heights
+func contiguousRanges(heights []int64) [][2]int64 {
+	ranges := [][2]int64{}
+	for _, height := range heights {
+		if len(ranges) > 0 && ranges[len(ranges)-1][1]+1 == height {
+			ranges[len(ranges)-1][1] = height
+			continue
+		}
+		ranges = append(ranges, [2]int64{height, height})
+	}
+	return ranges
+}
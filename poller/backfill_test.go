@@ -0,0 +1,126 @@
+package poller_test
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/bytes"
+
+	"github.com/likecoin/likecoin-chain/v4/app"
+
+	"github.com/likecoin/likecoin-chain-tx-indexer/db"
+	. "github.com/likecoin/likecoin-chain-tx-indexer/poller"
+	. "github.com/likecoin/likecoin-chain-tx-indexer/test"
+)
+
+// fakeChainSource serves blocks with `height % 3` txs
+type fakeChainSource struct {
+	lock    sync.Mutex
+	fetched []int64
+}
+
+func (s *fakeChainSource) GetBlock(height int64) (*BlockResult, error) {
+	s.lock.Lock()
+	s.fetched = append(s.fetched, height)
+	s.lock.Unlock()
+	txs := []string{}
+	for i := int64(0); i < height%3; i++ {
+		txs = append(txs, fmt.Sprintf("tx-%d-%d", height, i))
+	}
+	block := newTestBlock(height, txs...)
+	block.BlockId.Hash = []byte(fmt.Sprintf("block-hash-%d", height))
+	block.Block.Header.Time = "2022-01-01T00:00:00Z"
+	return block, nil
+}
+
+func (s *fakeChainSource) GetBlockTxResponses(block *BlockResult) ([]*types.TxResponse, error) {
+	txResponses := []*types.TxResponse{}
+	for _, tx := range block.Block.Data.Txs {
+		txRes := types.TxResponse{}
+		err := app.MakeEncodingConfig().Marshaler.UnmarshalJSON([]byte(fmt.Sprintf(
+			`{"height":"%d","txhash":"%s","raw_log":"[]","timestamp":"2022-01-01T00:00:00Z","tx":{"@type":"/cosmos.tx.v1beta1.Tx","body":{"messages":[],"memo":"%s"},"auth_info":{},"signatures":[]}}`,
+			block.Block.Header.Height, bytes.HexBytes(tx.Hash()).String(), string(tx),
+		)), &txRes)
+		if err != nil {
+			return nil, err
+		}
+		txResponses = append(txResponses, &txRes)
+	}
+	return txResponses, nil
+}
+
+func (s *fakeChainSource) fetchedHeights() []int64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	heights := append([]int64{}, s.fetched...)
+	sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+	s.fetched = nil
+	return heights
+}
+
+func TestBackfill(t *testing.T) {
+	defer CleanupTestData(Conn)
+	source := &fakeChainSource{}
+
+	count, err := Backfill(Pool, source, 1, 5, false, nil)
+	require.NoError(t, err)
+	require.Equal(t, 5, count)
+	require.Equal(t, []int64{1, 2, 3, 4, 5}, source.fetchedHeights())
+	missing, err := db.GetMissingHeights(Conn, 1, 5)
+	require.NoError(t, err)
+	require.Empty(t, missing)
+	// backfill does not move the latest height
+	latestHeight, err := db.GetLatestHeight(Conn)
+	require.NoError(t, err)
+	require.Zero(t, latestHeight)
+
+	// block header of height 3 is lost, and the tx at height 4 is replaced by a placeholder
+	_, err = Conn.Exec(context.Background(), `DELETE FROM blocks WHERE height = 3`)
+	require.NoError(t, err)
+	_, err = Conn.Exec(context.Background(), `UPDATE txs SET tx = '{"height":"4","txhash":"ABCD"}' WHERE height = 4`)
+	require.NoError(t, err)
+	missing, err = db.GetMissingHeights(Conn, 1, 5)
+	require.NoError(t, err)
+	require.Equal(t, []int64{3, 4}, missing)
+
+	memos := []string{}
+	extractors := []db.NamedExtractor{{
+		Name: "backfill_test",
+		Extractor: func(ctx db.EventContext) error {
+			memos = append(memos, ctx.Memo)
+			return nil
+		},
+	}}
+	setExtractorHeight := func(height int64) {
+		_, err := Conn.Exec(context.Background(), `
+			INSERT INTO meta (id, height) VALUES ($1, $2)
+			ON CONFLICT (id) DO UPDATE SET height = EXCLUDED.height
+		`, db.ExtractorMetaKey("backfill_test"), height)
+		require.NoError(t, err)
+	}
+	setExtractorHeight(3)
+	count, err = Backfill(Pool, source, 1, 5, true, extractors)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+	require.Equal(t, []int64{3, 4}, source.fetchedHeights())
+	missing, err = db.GetMissingHeights(Conn, 1, 5)
+	require.NoError(t, err)
+	require.Empty(t, missing)
+	// height 4 is not extracted yet, so it is left to the extractor
+	require.Empty(t, memos)
+
+	setExtractorHeight(5)
+	_, err = Backfill(Pool, source, 4, 5, false, extractors)
+	require.NoError(t, err)
+	require.Equal(t, []string{"tx-4-0", "tx-5-0", "tx-5-1"}, memos)
+
+	var txCount int
+	err = Conn.QueryRow(context.Background(), `SELECT count(*) FROM txs WHERE height BETWEEN 1 AND 5`).Scan(&txCount)
+	require.NoError(t, err)
+	require.Equal(t, 6, txCount)
+}
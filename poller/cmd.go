@@ -0,0 +1,24 @@
+package poller
+
+import (
+	"github.com/spf13/cobra"
+)
+
+const (
+	CmdSource       = "source"
+	CmdRpcEndpoint  = "rpc-endpoint"
+	CmdGrpcEndpoint = "grpc-endpoint"
+
+	// SourcePolling polls the chain source for new blocks periodically
+	SourcePolling = "polling"
+	// SourceRpcWebsocket subscribes to new blocks through the Tendermint RPC websocket
+	SourceRpcWebsocket = "rpc-ws"
+
+	DefaultRpcEndpoint = "http://localhost:26657"
+)
+
+func ConfigCmd(cmd *cobra.Command) {
+	cmd.PersistentFlags().String(CmdSource, SourcePolling, "How the poller learns about new blocks, either `polling` or `rpc-ws`")
+	cmd.PersistentFlags().String(CmdRpcEndpoint, DefaultRpcEndpoint, "LikeCoin chain Tendermint RPC endpoint, used when source is `rpc-ws`")
+	cmd.PersistentFlags().String(CmdGrpcEndpoint, "", "LikeCoin chain gRPC endpoint (e.g. localhost:9090) for fetching blocks and txs instead of the lcd endpoint")
+}
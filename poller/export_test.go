@@ -0,0 +1,19 @@
+package poller
+
+import (
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+var RunWebsocketUntil = runWebsocket
+
+func SetSleepInitial(d time.Duration) (restore func()) {
+	original := sleepInitial
+	sleepInitial = d
+	return func() { sleepInitial = original }
+}
+
+func GrpcServerCodec() grpc.ServerOption {
+	return grpc.ForceServerCodec(grpcCodec())
+}
@@ -0,0 +1,129 @@
+package poller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/client/grpc/tmservice"
+	"github.com/cosmos/cosmos-sdk/codec"
+	"github.com/cosmos/cosmos-sdk/types"
+	txTypes "github.com/cosmos/cosmos-sdk/types/tx"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	tmTypes "github.com/tendermint/tendermint/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding"
+)
+
+const grpcCallTimeout = 10 * time.Second
+
+// GrpcChainSource is the ChainSource querying from the gRPC endpoint of the node.
+// Blocks are queried by `cosmos.base.tendermint.v1beta1.Service/GetBlockByHeight`. Since
+// `cosmos.tx.v1beta1.Service/GetBlockWithTxs` only returns the txs without their results, the tx responses are
+// queried by `GetTxsEvent` on `tx.height`, with fallback to `GetTx` one by one.
+type GrpcChainSource struct {
+	Conn     *grpc.ClientConn
+	tmClient tmservice.ServiceClient
+	txClient txTypes.ServiceClient
+}
+
+// grpcCodec is the gRPC codec for the Cosmos SDK services, which resolves the `Any` types (e.g. the txs in tx
+// responses) with the interface registry of LikeCoin chain
+func grpcCodec() encoding.Codec {
+	return codec.NewProtoCodec(encodingConfig.InterfaceRegistry).GRPCCodec()
+}
+
+func NewGrpcChainSource(conn *grpc.ClientConn) *GrpcChainSource {
+	return &GrpcChainSource{
+		Conn:     conn,
+		tmClient: tmservice.NewServiceClient(conn),
+		txClient: txTypes.NewServiceClient(conn),
+	}
+}
+
+// DialGrpcChainSource connects to the gRPC endpoint (e.g. localhost:9090) without TLS
+func DialGrpcChainSource(grpcEndpoint string, opts ...grpc.DialOption) (*GrpcChainSource, error) {
+	opts = append([]grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(grpcCodec())),
+	}, opts...)
+	conn, err := grpc.Dial(grpcEndpoint, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return NewGrpcChainSource(conn), nil
+}
+
+func toBlockResult(blockId *tmproto.BlockID, block *tmproto.Block) (*BlockResult, error) {
+	if blockId == nil || block == nil {
+		return nil, fmt.Errorf("empty block in response")
+	}
+	blockResult := BlockResult{}
+	blockResult.BlockId.Hash = blockId.Hash
+	blockResult.Block.Header.Height = block.Header.Height
+	blockResult.Block.Header.Time = block.Header.Time.UTC().Format(time.RFC3339Nano)
+	blockResult.Block.Header.AppHash = block.Header.AppHash
+	blockResult.Block.Header.ProposerAddress = block.Header.ProposerAddress
+	blockResult.Block.Data.Txs = make(tmTypes.Txs, 0, len(block.Data.Txs))
+	for _, tx := range block.Data.Txs {
+		blockResult.Block.Data.Txs = append(blockResult.Block.Data.Txs, tmTypes.Tx(tx))
+	}
+	return &blockResult, nil
+}
+
+func (source *GrpcChainSource) GetBlock(height int64) (*BlockResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), grpcCallTimeout)
+	defer cancel()
+	if height <= 0 {
+		res, err := source.tmClient.GetLatestBlock(ctx, &tmservice.GetLatestBlockRequest{})
+		if err != nil {
+			return nil, err
+		}
+		return toBlockResult(res.BlockId, res.Block)
+	}
+	res, err := source.tmClient.GetBlockByHeight(ctx, &tmservice.GetBlockByHeightRequest{Height: height})
+	if err != nil {
+		return nil, err
+	}
+	return toBlockResult(res.BlockId, res.Block)
+}
+
+func (source *GrpcChainSource) GetTxResponse(txHash string) (*types.TxResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), grpcCallTimeout)
+	defer cancel()
+	res, err := source.txClient.GetTx(ctx, &txTypes.GetTxRequest{Hash: txHash})
+	if err != nil {
+		return nil, err
+	}
+	if res.TxResponse == nil {
+		return nil, fmt.Errorf("empty tx response, txhash = %s", txHash)
+	}
+	return res.TxResponse, nil
+}
+
+func (source *GrpcChainSource) GetTxResponsesByHeight(height int64, txCount int) ([]*types.TxResponse, error) {
+	txResponses := make([]*types.TxResponse, 0, txCount)
+	for page := uint64(1); len(txResponses) < txCount; page++ {
+		ctx, cancel := context.WithTimeout(context.Background(), grpcCallTimeout)
+		res, err := source.txClient.GetTxsEvent(ctx, &txTypes.GetTxsEventRequest{
+			Events:  []string{fmt.Sprintf("tx.height=%d", height)},
+			OrderBy: txTypes.OrderBy_ORDER_BY_ASC,
+			Page:    page,
+			Limit:   txsByHeightPageLimit,
+		})
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("cannot get txs event, error = %w, height = %d, page = %d", err, height, page)
+		}
+		txResponses = append(txResponses, res.TxResponses...)
+		if len(res.TxResponses) < txsByHeightPageLimit {
+			break
+		}
+	}
+	return txResponses, nil
+}
+
+func (source *GrpcChainSource) GetBlockTxResponses(block *BlockResult) ([]*types.TxResponse, error) {
+	return getBlockTxResponses(block, source.GetTxResponsesByHeight, source.GetTxResponse)
+}
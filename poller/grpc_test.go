@@ -0,0 +1,196 @@
+package poller_test
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/client/grpc/tmservice"
+	"github.com/cosmos/cosmos-sdk/types"
+	txTypes "github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/bytes"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	. "github.com/likecoin/likecoin-chain-tx-indexer/poller"
+)
+
+var testBlockTime = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type fakeTmService struct {
+	tmservice.UnimplementedServiceServer
+	latestHeight int64
+	txs          map[int64][][]byte
+}
+
+func (s *fakeTmService) block(height int64) *tmproto.Block {
+	return &tmproto.Block{
+		Header: tmproto.Header{Height: height, Time: testBlockTime},
+		Data:   tmproto.Data{Txs: s.txs[height]},
+	}
+}
+
+func (s *fakeTmService) blockId(height int64) *tmproto.BlockID {
+	return &tmproto.BlockID{Hash: []byte(fmt.Sprintf("block-hash-%d", height))}
+}
+
+func (s *fakeTmService) GetLatestBlock(ctx context.Context, req *tmservice.GetLatestBlockRequest) (*tmservice.GetLatestBlockResponse, error) {
+	return &tmservice.GetLatestBlockResponse{BlockId: s.blockId(s.latestHeight), Block: s.block(s.latestHeight)}, nil
+}
+
+func (s *fakeTmService) GetBlockByHeight(ctx context.Context, req *tmservice.GetBlockByHeightRequest) (*tmservice.GetBlockByHeightResponse, error) {
+	if req.Height > s.latestHeight {
+		return nil, status.Error(codes.InvalidArgument, "requested block height is bigger then the chain length")
+	}
+	return &tmservice.GetBlockByHeightResponse{BlockId: s.blockId(req.Height), Block: s.block(req.Height)}, nil
+}
+
+type fakeTxService struct {
+	txTypes.UnimplementedServiceServer
+	txs               map[int64][][]byte
+	eventsUnsupported bool
+	getTxCount        int
+}
+
+func (s *fakeTxService) GetTxsEvent(ctx context.Context, req *txTypes.GetTxsEventRequest) (*txTypes.GetTxsEventResponse, error) {
+	if s.eventsUnsupported || len(req.Events) != 1 || !strings.HasPrefix(req.Events[0], "tx.height=") {
+		return nil, status.Error(codes.Unimplemented, "unsupported events query")
+	}
+	height, err := strconv.ParseInt(strings.TrimPrefix(req.Events[0], "tx.height="), 10, 64)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	res := &txTypes.GetTxsEventResponse{}
+	for _, tx := range s.txs[height] {
+		res.TxResponses = append(res.TxResponses, testTxResponse(height, tx))
+	}
+	return res, nil
+}
+
+func (s *fakeTxService) GetTx(ctx context.Context, req *txTypes.GetTxRequest) (*txTypes.GetTxResponse, error) {
+	s.getTxCount++
+	for height, txs := range s.txs {
+		for _, tx := range txs {
+			if testTxResponse(height, tx).TxHash == req.Hash {
+				return &txTypes.GetTxResponse{TxResponse: testTxResponse(height, tx)}, nil
+			}
+		}
+	}
+	return nil, status.Error(codes.NotFound, "tx not found")
+}
+
+func testTxResponse(height int64, tx []byte) *types.TxResponse {
+	return &types.TxResponse{
+		Height: height,
+		TxHash: bytes.HexBytes(newTestBlock(height, string(tx)).Block.Data.Txs[0].Hash()).String(),
+		RawLog: "[]",
+	}
+}
+
+func newTestGrpcSource(t *testing.T, tmService *fakeTmService, txService *fakeTxService) *GrpcChainSource {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(GrpcServerCodec())
+	tmservice.RegisterServiceServer(server, tmService)
+	txTypes.RegisterServiceServer(server, txService)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	source, err := DialGrpcChainSource("bufnet", grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return listener.DialContext(ctx)
+	}))
+	require.NoError(t, err)
+	t.Cleanup(func() { source.Conn.Close() })
+	return source
+}
+
+func newTestGrpcServices(latestHeight int64) (*fakeTmService, *fakeTxService) {
+	txs := map[int64][][]byte{}
+	for height := int64(1); height <= latestHeight; height++ {
+		for i := int64(0); i < height%3; i++ {
+			txs[height] = append(txs[height], []byte(fmt.Sprintf("tx-%d-%d", height, i)))
+		}
+	}
+	return &fakeTmService{latestHeight: latestHeight, txs: txs}, &fakeTxService{txs: txs}
+}
+
+func TestGrpcChainSourceGetBlock(t *testing.T) {
+	tmService, txService := newTestGrpcServices(20)
+	source := newTestGrpcSource(t, tmService, txService)
+
+	latest, err := source.GetBlock(0)
+	require.NoError(t, err)
+	require.Equal(t, int64(20), latest.Block.Header.Height)
+
+	block, err := source.GetBlock(5)
+	require.NoError(t, err)
+	require.Equal(t, int64(5), block.Block.Header.Height)
+	require.Equal(t, testBlockTime.Format(time.RFC3339), block.Block.Header.Time)
+	require.Len(t, block.Block.Data.Txs, 2)
+	require.Equal(t, "tx-5-0", string(block.Block.Data.Txs[0]))
+	dbBlock, err := block.ToDBBlock()
+	require.NoError(t, err)
+	require.Equal(t, strings.ToUpper(hex.EncodeToString([]byte("block-hash-5"))), dbBlock.Hash)
+	require.Equal(t, testBlockTime, dbBlock.Time)
+	require.Equal(t, 2, dbBlock.NumTxs)
+
+	_, err = source.GetBlock(21)
+	require.Error(t, err)
+}
+
+func TestGrpcChainSourceGetBlockTxResponses(t *testing.T) {
+	tmService, txService := newTestGrpcServices(20)
+	source := newTestGrpcSource(t, tmService, txService)
+
+	block, err := source.GetBlock(5)
+	require.NoError(t, err)
+	txResponses, err := source.GetBlockTxResponses(block)
+	require.NoError(t, err)
+	require.Zero(t, txService.getTxCount)
+	require.Len(t, txResponses, 2)
+	for i, tx := range block.Block.Data.Txs {
+		require.Equal(t, bytes.HexBytes(tx.Hash()).String(), txResponses[i].TxHash)
+	}
+}
+
+func TestGrpcChainSourceGetBlockTxResponsesFallback(t *testing.T) {
+	tmService, txService := newTestGrpcServices(20)
+	txService.eventsUnsupported = true
+	source := newTestGrpcSource(t, tmService, txService)
+
+	block, err := source.GetBlock(5)
+	require.NoError(t, err)
+	txResponses, err := source.GetBlockTxResponses(block)
+	require.NoError(t, err)
+	require.Equal(t, 2, txService.getTxCount)
+	require.Len(t, txResponses, 2)
+	for i, tx := range block.Block.Data.Txs {
+		require.Equal(t, bytes.HexBytes(tx.Hash()).String(), txResponses[i].TxHash)
+	}
+}
+
+func TestFetchBlocksFromGrpc(t *testing.T) {
+	tmService, txService := newTestGrpcServices(30)
+	var source ChainSource = newTestGrpcSource(t, tmService, txService)
+
+	done := make(chan struct{})
+	defer close(done)
+	expectedHeight := int64(1)
+	for fetched := range FetchBlocks(source, 1, 30, 4, 8, done) {
+		require.NoError(t, fetched.Err)
+		require.Equal(t, expectedHeight, fetched.Height)
+		require.Len(t, fetched.TxResponses, int(expectedHeight%3))
+		expectedHeight++
+	}
+	require.Equal(t, int64(31), expectedHeight)
+}
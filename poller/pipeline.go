@@ -0,0 +1,141 @@
+package poller
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/cosmos/cosmos-sdk/types"
+)
+
+type FetchedBlock struct {
+	Height      int64
+	Block       *BlockResult
+	TxResponses []*types.TxResponse
+	Err         error
+}
+
+func fetchBlock(source ChainSource, height int64) FetchedBlock {
+	fetched := FetchedBlock{Height: height}
+	blockResult, err := source.GetBlock(height)
+	if err != nil {
+		fetched.Err = fmt.Errorf("cannot get block, error = %w, height = %d", err, height)
+		return fetched
+	}
+	txResponses, err := source.GetBlockTxResponses(blockResult)
+	if err != nil {
+		fetched.Err = err
+		return fetched
+	}
+	fetched.Block = blockResult
+	fetched.TxResponses = txResponses
+	return fetched
+}
+
+// FetchBlocks fetches the blocks and their tx responses between `from` and `to` (inclusive) with `workers`
+// concurrent workers, while keeping at most `window` heights in flight.
+// The fetched blocks are emitted strictly in height order, so the consumer can write them sequentially.
+// The channel is closed after emitting the block at `to`, or after emitting the first block with error, which
+// means all the blocks emitted before the error are contiguous.
+// Closing `done` stops the fetching early.
+func FetchBlocks(source ChainSource, from, to int64, workers int, window int64, done <-chan struct{}) <-chan FetchedBlock {
+	return fetchInOrder(from, to, workers, window, done, func(height int64) FetchedBlock {
+		return fetchBlock(source, height)
+	})
+}
+
+// fetchBlockHeaders is the same as FetchBlocks, but only fetches the blocks without the tx responses
+func fetchBlockHeaders(source ChainSource, from, to int64, workers int, window int64, done <-chan struct{}) <-chan FetchedBlock {
+	return fetchInOrder(from, to, workers, window, done, func(height int64) FetchedBlock {
+		fetched := FetchedBlock{Height: height}
+		fetched.Block, fetched.Err = source.GetBlock(height)
+		if fetched.Err != nil {
+			fetched.Err = fmt.Errorf("cannot get block, error = %w, height = %d", fetched.Err, height)
+		}
+		return fetched
+	})
+}
+
+func fetchInOrder(from, to int64, workers int, window int64, done <-chan struct{}, fetch func(height int64) FetchedBlock) <-chan FetchedBlock {
+	if workers < 1 {
+		workers = 1
+	}
+	if window < 1 {
+		window = 1
+	}
+	out := make(chan FetchedBlock)
+	if from > to {
+		close(out)
+		return out
+	}
+
+	heights := make(chan int64)
+	// at most `window` heights are dispatched but not yet emitted, so workers never block on sending results
+	results := make(chan FetchedBlock, window)
+	slots := make(chan struct{}, window)
+	stop := make(chan struct{})
+	stopOnce := sync.Once{}
+	stopDispatching := func() {
+		stopOnce.Do(func() { close(stop) })
+	}
+
+	// dispatcher
+	go func() {
+		defer close(heights)
+		for height := from; height <= to; height++ {
+			select {
+			case slots <- struct{}{}:
+			case <-stop:
+				return
+			}
+			select {
+			case heights <- height:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	// workers
+	for i := 0; i < workers; i++ {
+		go func() {
+			for height := range heights {
+				results <- fetch(height)
+			}
+		}()
+	}
+
+	// reorder stage
+	go func() {
+		defer close(out)
+		defer stopDispatching()
+		pending := map[int64]FetchedBlock{}
+		next := from
+		for next <= to {
+			var fetched FetchedBlock
+			select {
+			case fetched = <-results:
+			case <-done:
+				return
+			}
+			pending[fetched.Height] = fetched
+			for {
+				fetched, ok := pending[next]
+				if !ok {
+					break
+				}
+				delete(pending, next)
+				select {
+				case out <- fetched:
+				case <-done:
+					return
+				}
+				if fetched.Err != nil {
+					return
+				}
+				<-slots
+				next++
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,84 @@
+package poller_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	. "github.com/likecoin/likecoin-chain-tx-indexer/poller"
+)
+
+func newTestBlockServer(t *testing.T, failHeight int64) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		heightStr := strings.TrimPrefix(r.URL.Path, "/cosmos/base/tendermint/v1beta1/blocks/")
+		height, err := strconv.ParseInt(heightStr, 10, 64)
+		if err != nil {
+			t.Errorf("unexpected request: %s", r.URL.String())
+			w.WriteHeader(400)
+			return
+		}
+		if height == failHeight {
+			w.WriteHeader(500)
+			return
+		}
+		// lower heights respond slower, so the responses arrive out of order
+		time.Sleep(time.Duration(20-height%20) * time.Millisecond)
+		fmt.Fprintf(w, `{"block":{"header":{"height":"%d","time":"2022-01-01T00:00:00Z"},"data":{"txs":[]}}}`, height)
+	}))
+}
+
+func TestFetchBlocksInOrder(t *testing.T) {
+	server := newTestBlockServer(t, -1)
+	defer server.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	expectedHeight := int64(1)
+	for fetched := range FetchBlocks(newTestContext(server.URL), 1, 50, 8, 16, done) {
+		require.NoError(t, fetched.Err)
+		require.Equal(t, expectedHeight, fetched.Height)
+		require.Equal(t, expectedHeight, fetched.Block.Block.Header.Height)
+		require.Empty(t, fetched.TxResponses)
+		expectedHeight++
+	}
+	require.Equal(t, int64(51), expectedHeight)
+}
+
+func TestFetchBlocksStopAtError(t *testing.T) {
+	server := newTestBlockServer(t, 30)
+	defer server.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	expectedHeight := int64(1)
+	var lastErr error
+	for fetched := range FetchBlocks(newTestContext(server.URL), 1, 50, 8, 16, done) {
+		require.Equal(t, expectedHeight, fetched.Height)
+		if fetched.Err != nil {
+			lastErr = fetched.Err
+			continue
+		}
+		expectedHeight++
+	}
+	require.Error(t, lastErr)
+	require.Equal(t, int64(30), expectedHeight)
+}
+
+func TestFetchBlocksEmptyRange(t *testing.T) {
+	server := newTestBlockServer(t, -1)
+	defer server.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	count := 0
+	for range FetchBlocks(newTestContext(server.URL), 10, 9, 8, 16, done) {
+		count++
+	}
+	require.Zero(t, count)
+}
@@ -2,13 +2,14 @@ package poller
 
 import (
 	"fmt"
-	"io"
-	"net/http"
+	"strings"
 	"time"
 
+	"github.com/cosmos/cosmos-sdk/types"
 	txTypes "github.com/cosmos/cosmos-sdk/types/tx"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/likecoin/likecoin-chain-tx-indexer/db"
+	"github.com/likecoin/likecoin-chain-tx-indexer/lcd"
 	"github.com/likecoin/likecoin-chain-tx-indexer/logger"
 	"github.com/likecoin/likecoin-chain-tx-indexer/utils"
 	"github.com/likecoin/likecoin-chain/v4/app"
@@ -20,40 +21,42 @@ import (
 
 var batchSize = utils.EnvInt("BATCH_SIZE", 1000)
 var batchMaxHeightDiff = int64(utils.EnvInt("BATCH_MAX_HEIGHT_DIFF", 1000))
+var fetchWorkers = utils.EnvInt("FETCH_WORKERS", 8)
+var fetchWindow = int64(utils.EnvInt("FETCH_WINDOW", 64))
 
 // TODO: move into config
 var sleepInitial = time.Duration(utils.EnvInt("SLEEP_INITIAL", 5)) * time.Second
 var sleepMax = time.Duration(utils.EnvInt("SLEEP_MAX", 600)) * time.Second
 
+// the LCD (Tendermint tx_search) caps the page size of events query at 100
+const txsByHeightPageLimit = 100
+
 var encodingConfig = app.MakeEncodingConfig()
 
-func getResponse(client *http.Client, url string) ([]byte, error) {
-	resp, err := client.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("non-200 code returned: %d", resp.StatusCode)
-	}
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	return body, nil
+// ChainSource provides the blocks and the tx responses to be indexed
+type ChainSource interface {
+	// GetBlock returns the block at the height, or the latest block if height is 0
+	GetBlock(height int64) (*BlockResult, error)
+	// GetBlockTxResponses returns the tx responses of all txs in the block, in the same order as the txs in the block
+	GetBlockTxResponses(block *BlockResult) ([]*types.TxResponse, error)
 }
 
+// CosmosCallContext is the ChainSource querying from the LCD endpoint
 type CosmosCallContext struct {
-	Codec       *amino.Codec
-	Client      *http.Client
-	LcdEndpoint string
+	Codec *amino.Codec
+	Lcd   *lcd.Pool
 }
 
 type BlockResult struct {
+	BlockId struct {
+		Hash []byte `json:"hash"`
+	} `json:"block_id"`
 	Block struct {
 		Header struct {
-			Height int64  `json:"height"`
-			Time   string `json:"time"`
+			Height          int64  `json:"height"`
+			Time            string `json:"time"`
+			AppHash         []byte `json:"app_hash"`
+			ProposerAddress []byte `json:"proposer_address"`
 		} `json:"header"`
 		Data struct {
 			Txs tmTypes.Txs `json:"txs"`
@@ -61,13 +64,29 @@ type BlockResult struct {
 	} `json:"block"`
 }
 
+// ToDBBlock converts the block into the header data stored in the `blocks` table
+func (block *BlockResult) ToDBBlock() (db.Block, error) {
+	header := block.Block.Header
+	blockTime, err := time.Parse(time.RFC3339, header.Time)
+	if err != nil {
+		return db.Block{}, fmt.Errorf("cannot parse block time, error = %w, height = %d, time = %s", err, header.Height, header.Time)
+	}
+	return db.Block{
+		Height:   header.Height,
+		Hash:     bytes.HexBytes(block.BlockId.Hash).String(),
+		Time:     blockTime,
+		Proposer: bytes.HexBytes(header.ProposerAddress).String(),
+		NumTxs:   len(block.Block.Data.Txs),
+		AppHash:  bytes.HexBytes(header.AppHash).String(),
+	}, nil
+}
+
 func GetBlock(ctx *CosmosCallContext, height int64) (*BlockResult, error) {
 	heightStr := "latest"
 	if height > 0 {
 		heightStr = fmt.Sprintf("%d", height)
 	}
-	url := fmt.Sprintf("%s/cosmos/base/tendermint/v1beta1/blocks/%s", ctx.LcdEndpoint, heightStr)
-	body, err := getResponse(ctx.Client, url)
+	body, err := ctx.Lcd.Get(fmt.Sprintf("/cosmos/base/tendermint/v1beta1/blocks/%s", heightStr))
 	if err != nil {
 		return nil, err
 	}
@@ -79,6 +98,120 @@ func GetBlock(ctx *CosmosCallContext, height int64) (*BlockResult, error) {
 	return &resultBlock, nil
 }
 
+func GetTxResponse(ctx *CosmosCallContext, txHash string) (*types.TxResponse, error) {
+	txResJSON, err := ctx.Lcd.Get(fmt.Sprintf("/cosmos/tx/v1beta1/txs/%s", txHash))
+	if err != nil {
+		return nil, err
+	}
+	txRes := txTypes.GetTxResponse{}
+	err = encodingConfig.Marshaler.UnmarshalJSON(txResJSON, &txRes)
+	if err != nil {
+		return nil, fmt.Errorf("cannot unmarshal tx response from JSON, error = %w, tx_response = %s", err, string(txResJSON))
+	}
+	if txRes.TxResponse == nil {
+		return nil, fmt.Errorf("empty tx response, tx_response = %s", string(txResJSON))
+	}
+	return txRes.TxResponse, nil
+}
+
+// GetTxResponsesByHeight queries all the tx responses of a block through the events query on `tx.height`,
+// so a block only costs a few requests (one per 100 txs) instead of one request per tx
+func GetTxResponsesByHeight(ctx *CosmosCallContext, height int64, txCount int) ([]*types.TxResponse, error) {
+	txResponses := make([]*types.TxResponse, 0, txCount)
+	for page := 1; len(txResponses) < txCount; page++ {
+		body, err := ctx.Lcd.Get(fmt.Sprintf(
+			"/cosmos/tx/v1beta1/txs?events=tx.height%%3D%d&order_by=ORDER_BY_ASC&page=%d&limit=%d",
+			height, page, txsByHeightPageLimit,
+		))
+		if err != nil {
+			return nil, err
+		}
+		res := txTypes.GetTxsEventResponse{}
+		err = encodingConfig.Marshaler.UnmarshalJSON(body, &res)
+		if err != nil {
+			return nil, fmt.Errorf("cannot unmarshal txs event response from JSON, error = %w, height = %d, page = %d", err, height, page)
+		}
+		txResponses = append(txResponses, res.TxResponses...)
+		if len(res.TxResponses) < txsByHeightPageLimit {
+			break
+		}
+	}
+	return txResponses, nil
+}
+
+// orderTxResponses arranges the tx responses in the order of the txs in the block.
+// It fails if any tx in the block is missing from the tx responses.
+func orderTxResponses(txs tmTypes.Txs, txResponses []*types.TxResponse) ([]*types.TxResponse, error) {
+	txResMap := make(map[string]*types.TxResponse, len(txResponses))
+	for _, txRes := range txResponses {
+		txResMap[strings.ToUpper(txRes.TxHash)] = txRes
+	}
+	ordered := make([]*types.TxResponse, 0, len(txs))
+	for txIndex, tx := range txs {
+		txHash := bytes.HexBytes(tx.Hash()).String()
+		txRes, ok := txResMap[txHash]
+		if !ok {
+			return nil, fmt.Errorf("tx response not found, txhash = %s, index = %d", txHash, txIndex)
+		}
+		ordered = append(ordered, txRes)
+	}
+	return ordered, nil
+}
+
+// GetBlockTxResponses returns the tx responses of all txs in the block, in the same order as the txs in the block.
+// It tries to fetch the whole block in one go first, and falls back to query tx responses one by one by tx hash
+// for nodes which do not support querying txs by height (e.g. tx indexing with limited events)
+func GetBlockTxResponses(ctx *CosmosCallContext, block *BlockResult) ([]*types.TxResponse, error) {
+	return getBlockTxResponses(
+		block,
+		func(height int64, txCount int) ([]*types.TxResponse, error) {
+			return GetTxResponsesByHeight(ctx, height, txCount)
+		},
+		func(txHash string) (*types.TxResponse, error) {
+			return GetTxResponse(ctx, txHash)
+		},
+	)
+}
+
+func getBlockTxResponses(
+	block *BlockResult,
+	getByHeight func(height int64, txCount int) ([]*types.TxResponse, error),
+	getByHash func(txHash string) (*types.TxResponse, error),
+) ([]*types.TxResponse, error) {
+	height := block.Block.Header.Height
+	txs := block.Block.Data.Txs
+	if len(txs) == 0 {
+		return []*types.TxResponse{}, nil
+	}
+	txResponses, err := getByHeight(height, len(txs))
+	if err == nil {
+		txResponses, err = orderTxResponses(txs, txResponses)
+		if err == nil {
+			return txResponses, nil
+		}
+	}
+	logger.L.Warnw("Cannot get tx responses by height, falling back to query by tx hash", "height", height, "error", err)
+	txResponses = make([]*types.TxResponse, 0, len(txs))
+	for txIndex, tx := range txs {
+		txHash := bytes.HexBytes(tx.Hash())
+		logger.L.Infow("Getting transaction", "txhash", txHash, "height", height, "index", txIndex)
+		txRes, err := getByHash(txHash.String())
+		if err != nil {
+			return nil, fmt.Errorf("cannot get tx response, error = %w, txhash = %s, height = %d, index = %d", err, txHash.String(), height, txIndex)
+		}
+		txResponses = append(txResponses, txRes)
+	}
+	return txResponses, nil
+}
+
+func (ctx *CosmosCallContext) GetBlock(height int64) (*BlockResult, error) {
+	return GetBlock(ctx, height)
+}
+
+func (ctx *CosmosCallContext) GetBlockTxResponses(block *BlockResult) ([]*types.TxResponse, error) {
+	return GetBlockTxResponses(ctx, block)
+}
+
 func getHeight(pool *pgxpool.Pool) (int64, error) {
 	conn, err := db.AcquireFromPool(pool)
 	if err != nil {
@@ -96,58 +229,81 @@ func getHeight(pool *pgxpool.Pool) (int64, error) {
 	return lastHeight, nil
 }
 
-func poll(pool *pgxpool.Pool, ctx *CosmosCallContext, lastHeight int64) (int64, error) {
+// poll indexes the blocks after lastHeight up to the latest block, and returns the height up to which all blocks
+// are indexed.
+// On error, the returned height is still valid, since all blocks before the failing one are indexed.
+func poll(pool *pgxpool.Pool, source ChainSource, lastHeight int64) (int64, error) {
+	latestBlockResult, err := source.GetBlock(0)
+	if err != nil {
+		return lastHeight, fmt.Errorf("cannot get latest block: %w", err)
+	}
+	return pollUntil(pool, source, lastHeight, latestBlockResult.Block.Header.Height)
+}
+
+// pollUntil is the same as poll, but indexes the blocks up to targetHeight instead of the latest block
+func pollUntil(pool *pgxpool.Pool, source ChainSource, lastHeight int64, targetHeight int64) (int64, error) {
+	if targetHeight <= lastHeight {
+		return lastHeight, nil
+	}
 	conn, err := db.AcquireFromPool(pool)
 	if err != nil {
-		return 0, fmt.Errorf("cannot acquire connection from database connection pool: %w", err)
+		return lastHeight, fmt.Errorf("cannot acquire connection from database connection pool: %w", err)
 	}
 	defer conn.Release()
 	batch := db.NewBatch(conn, batchSize)
-	latestBlockResult, err := GetBlock(ctx, 0)
-	if err != nil {
-		// TODO: retry
-		return 0, fmt.Errorf("cannot get latest block from lcd: %w", err)
-	}
-	maxHeight := latestBlockResult.Block.Header.Height
+	maxHeight := targetHeight
 	if maxHeight-lastHeight > batchMaxHeightDiff {
 		maxHeight = lastHeight + batchMaxHeightDiff
 	}
 	logger.L.Debugw("Querying blocks", "lastHeight", lastHeight, "maxHeight", maxHeight)
-	for height := lastHeight + 1; height <= maxHeight; height++ {
-		blockResult, err := GetBlock(ctx, height)
-		if err != nil {
-			return 0, fmt.Errorf("cannot get block from lcd, error = %w, height = %d", err, height)
+	done := make(chan struct{})
+	defer close(done)
+	processedHeight := lastHeight
+	processedBlockTime := ""
+	var pollErr error
+	for fetched := range FetchBlocks(source, lastHeight+1, maxHeight, fetchWorkers, fetchWindow, done) {
+		if fetched.Err != nil {
+			pollErr = fetched.Err
+			break
 		}
-		for txIndex, tx := range blockResult.Block.Data.Txs {
-			txHash := bytes.HexBytes(tx.Hash())
-			logger.L.Infow("Getting transaction", "txhash", txHash, "height", height, "index", txIndex)
-			url := fmt.Sprintf("%s/cosmos/tx/v1beta1/txs/%s", ctx.LcdEndpoint, txHash.String())
-			txResJSON, err := getResponse(ctx.Client, url)
+		height := fetched.Height
+		for txIndex, txRes := range fetched.TxResponses {
+			err = batch.InsertTx(*txRes, height, txIndex)
 			if err != nil {
-				return 0, fmt.Errorf("cannot get tx response from lcd, error = %w, txhash = %s, height = %d, index = %d", err, txHash.String(), height, txIndex)
-			}
-			txRes := txTypes.GetTxResponse{}
-			err = encodingConfig.Marshaler.UnmarshalJSON(txResJSON, &txRes)
-			if err != nil {
-				return 0, fmt.Errorf("cannot unmarshal tx response to JSON, error = %w, txhash = %s, tx_response = %v", err, txHash, txResJSON)
-			}
-			err = batch.InsertTx(*txRes.TxResponse, height, txIndex)
-			if err != nil {
-				return 0, fmt.Errorf("cannot insert transaction, error = %w, txhash = %s, height = %d, index = %d, tx_json = %s", err, txHash.String(), height, txIndex, string(txResJSON))
+				return lastHeight, fmt.Errorf("cannot insert transaction, error = %w, txhash = %s, height = %d, index = %d", err, txRes.TxHash, height, txIndex)
 			}
 		}
+		dbBlock, err := fetched.Block.ToDBBlock()
+		if err != nil {
+			return lastHeight, err
+		}
+		batch.InsertBlock(dbBlock)
+		processedHeight = height
+		processedBlockTime = fetched.Block.Block.Header.Time
+	}
+	if processedHeight == lastHeight {
+		return lastHeight, pollErr
 	}
-	batch.UpdateLatestBlockHeight(maxHeight)
+	// only advance to the contiguous height, so the failed heights will be polled again
+	batch.UpdateLatestBlockHeight(processedHeight)
 	// error is ignored since fail to update block time is not critical
-	_ = batch.UpdateLatestBlockTime(latestBlockResult.Block.Header.Time)
+	_ = batch.UpdateLatestBlockTime(processedBlockTime)
 	err = batch.Flush()
 	if err != nil {
-		return 0, fmt.Errorf("cannot flush transaction batch, error = %w, batch = %v", err, batch)
+		return lastHeight, fmt.Errorf("cannot flush transaction batch, error = %w, batch = %v", err, batch)
 	}
-	return maxHeight, nil
+	return processedHeight, pollErr
+}
+
+func notifyTriggers(height int64, triggers []chan<- int64) {
+	go func() {
+		for _, trigger := range triggers {
+			trigger <- height
+		}
+	}()
 }
 
-func Run(pool *pgxpool.Pool, ctx *CosmosCallContext, triggers ...chan<- int64) {
+func Run(pool *pgxpool.Pool, source ChainSource, triggers ...chan<- int64) {
 	lastHeight, err := getHeight(pool)
 	logger.L.Infow("Init Height", "lastHeight", lastHeight)
 	if err != nil {
@@ -155,16 +311,14 @@ func Run(pool *pgxpool.Pool, ctx *CosmosCallContext, triggers ...chan<- int64) {
 	}
 	toSleep := sleepInitial
 	for {
-		returnedHeight, err := poll(pool, ctx, lastHeight)
+		returnedHeight, err := poll(pool, source, lastHeight)
+		if returnedHeight > lastHeight {
+			lastHeight = returnedHeight
+			notifyTriggers(returnedHeight, triggers)
+		}
 		if err == nil {
 			// reset sleep time to normal value
 			toSleep = sleepInitial
-			lastHeight = returnedHeight
-			go func() {
-				for _, trigger := range triggers {
-					trigger <- returnedHeight
-				}
-			}()
 		} else {
 			logger.L.Errorw("cannot poll block", "error", err)
 			// exponential back-off with max cap
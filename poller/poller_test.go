@@ -0,0 +1,148 @@
+package poller_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/bytes"
+	tmTypes "github.com/tendermint/tendermint/types"
+
+	"github.com/likecoin/likecoin-chain/v4/app"
+
+	"github.com/likecoin/likecoin-chain-tx-indexer/lcd"
+	. "github.com/likecoin/likecoin-chain-tx-indexer/poller"
+	. "github.com/likecoin/likecoin-chain-tx-indexer/test"
+)
+
+func txResponseJSON(height int64, tx tmTypes.Tx) string {
+	return fmt.Sprintf(`{"height":"%d","txhash":"%s","raw_log":"[]"}`, height, bytes.HexBytes(tx.Hash()).String())
+}
+
+func newTestBlock(height int64, txs ...string) *BlockResult {
+	block := &BlockResult{}
+	block.Block.Header.Height = height
+	for _, tx := range txs {
+		block.Block.Data.Txs = append(block.Block.Data.Txs, tmTypes.Tx(tx))
+	}
+	return block
+}
+
+func newTestContext(lcdEndpoint string) *CosmosCallContext {
+	lcdPool, err := lcd.NewPool([]string{lcdEndpoint}, &http.Client{})
+	if err != nil {
+		panic(err)
+	}
+	lcdPool.RetryDelay = time.Millisecond
+	return &CosmosCallContext{
+		Codec: app.MakeEncodingConfig().Amino.Amino,
+		Lcd:   lcdPool,
+	}
+}
+
+func TestGetBlockTxResponsesByHeight(t *testing.T) {
+	block := newTestBlock(1234, "tx-a", "tx-b", "tx-c")
+	txs := block.Block.Data.Txs
+	byHashCalled := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/cosmos/tx/v1beta1/txs" {
+			byHashCalled = true
+			w.WriteHeader(404)
+			return
+		}
+		if r.URL.Query().Get("events") != "tx.height=1234" {
+			w.WriteHeader(400)
+			return
+		}
+		// respond in a different order to ensure the txs are re-arranged in block order
+		fmt.Fprintf(w, `{"tx_responses":[%s,%s,%s],"total":"3"}`,
+			txResponseJSON(1234, txs[2]), txResponseJSON(1234, txs[0]), txResponseJSON(1234, txs[1]),
+		)
+	}))
+	defer server.Close()
+
+	txResponses, err := GetBlockTxResponses(newTestContext(server.URL), block)
+	require.NoError(t, err)
+	require.False(t, byHashCalled)
+	require.Len(t, txResponses, 3)
+	for i, tx := range txs {
+		require.Equal(t, bytes.HexBytes(tx.Hash()).String(), txResponses[i].TxHash)
+	}
+}
+
+func TestGetBlockTxResponsesFallback(t *testing.T) {
+	block := newTestBlock(1234, "tx-a", "tx-b")
+	txs := block.Block.Data.Txs
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/cosmos/tx/v1beta1/txs" {
+			w.WriteHeader(501)
+			return
+		}
+		txHash := strings.TrimPrefix(r.URL.Path, "/cosmos/tx/v1beta1/txs/")
+		for _, tx := range txs {
+			if bytes.HexBytes(tx.Hash()).String() == txHash {
+				fmt.Fprintf(w, `{"tx_response":%s}`, txResponseJSON(1234, tx))
+				return
+			}
+		}
+		w.WriteHeader(404)
+	}))
+	defer server.Close()
+
+	txResponses, err := GetBlockTxResponses(newTestContext(server.URL), block)
+	require.NoError(t, err)
+	require.Len(t, txResponses, 2)
+	for i, tx := range txs {
+		require.Equal(t, bytes.HexBytes(tx.Hash()).String(), txResponses[i].TxHash)
+	}
+}
+
+func TestGetBlockTxResponsesPartialFallback(t *testing.T) {
+	block := newTestBlock(1234, "tx-a", "tx-b")
+	txs := block.Block.Data.Txs
+	byHashCount := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/cosmos/tx/v1beta1/txs" {
+			// missing the second tx, should fall back to query by hash
+			fmt.Fprintf(w, `{"tx_responses":[%s],"total":"1"}`, txResponseJSON(1234, txs[0]))
+			return
+		}
+		byHashCount++
+		txHash := strings.TrimPrefix(r.URL.Path, "/cosmos/tx/v1beta1/txs/")
+		for _, tx := range txs {
+			if bytes.HexBytes(tx.Hash()).String() == txHash {
+				fmt.Fprintf(w, `{"tx_response":%s}`, txResponseJSON(1234, tx))
+				return
+			}
+		}
+		w.WriteHeader(404)
+	}))
+	defer server.Close()
+
+	txResponses, err := GetBlockTxResponses(newTestContext(server.URL), block)
+	require.NoError(t, err)
+	require.Equal(t, 2, byHashCount)
+	require.Len(t, txResponses, 2)
+	for i, tx := range txs {
+		require.Equal(t, bytes.HexBytes(tx.Hash()).String(), txResponses[i].TxHash)
+	}
+}
+
+func TestGetBlockTxResponsesEmptyBlock(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request: %s", r.URL.String())
+	}))
+	defer server.Close()
+
+	txResponses, err := GetBlockTxResponses(newTestContext(server.URL), newTestBlock(1234))
+	require.NoError(t, err)
+	require.Empty(t, txResponses)
+}
+
+func TestMain(m *testing.M) {
+	SetupDbAndRunTest(m, nil)
+}
@@ -0,0 +1,152 @@
+package poller
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/likecoin/likecoin-chain-tx-indexer/db"
+	"github.com/likecoin/likecoin-chain-tx-indexer/logger"
+	"github.com/tendermint/tendermint/libs/bytes"
+)
+
+const (
+	// DiscrepancyTxCount means the number of stored txs differs from the number of txs in the block
+	DiscrepancyTxCount = "tx_count_mismatch"
+	// DiscrepancyMissingTx means no tx is stored at a tx index of the block
+	DiscrepancyMissingTx = "missing_tx"
+	// DiscrepancyUnexpectedTx means a tx is stored at a tx index beyond the txs in the block
+	DiscrepancyUnexpectedTx = "unexpected_tx"
+	// DiscrepancyDuplicateTxIndex means more than one tx is stored at the same tx index
+	DiscrepancyDuplicateTxIndex = "duplicate_tx_index"
+	// DiscrepancyOutOfOrderTxIndex means the stored tx belongs to the block, but at another tx index
+	DiscrepancyOutOfOrderTxIndex = "out_of_order_tx_index"
+	// DiscrepancyTxHash means the stored tx does not belong to the block
+	DiscrepancyTxHash = "tx_hash_mismatch"
+	// DiscrepancyPlaceholder means the stored tx has no tx body, e.g. written by import for txs missing from tx_index
+	DiscrepancyPlaceholder = "placeholder_tx"
+)
+
+type Discrepancy struct {
+	Height   int64  `json:"height"`
+	Type     string `json:"type"`
+	TxIndex  *int   `json:"tx_index,omitempty"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+}
+
+// VerifyReport is the result of verifying the stored txs against the chain.
+// Heights lists the heights with any discrepancy, which can be passed to `indexer backfill --report`.
+type VerifyReport struct {
+	From          int64         `json:"from"`
+	To            int64         `json:"to"`
+	Discrepancies []Discrepancy `json:"discrepancies"`
+	Heights       []int64       `json:"heights"`
+}
+
+// Verify compares the txs stored from `from` to `to` (inclusive) with the txs of the blocks on the chain
+func Verify(conn *pgxpool.Conn, source ChainSource, from, to int64) (VerifyReport, error) {
+	report := VerifyReport{
+		From:          from,
+		To:            to,
+		Discrepancies: []Discrepancy{},
+		Heights:       []int64{},
+	}
+	for chunkFrom := from; chunkFrom <= to; chunkFrom += batchMaxHeightDiff {
+		chunkTo := chunkFrom + batchMaxHeightDiff - 1
+		if chunkTo > to {
+			chunkTo = to
+		}
+		indexedTxs, err := db.GetIndexedTxs(conn, chunkFrom, chunkTo)
+		if err != nil {
+			return report, err
+		}
+		txsByHeight := map[int64][]db.IndexedTx{}
+		for _, tx := range indexedTxs {
+			txsByHeight[tx.Height] = append(txsByHeight[tx.Height], tx)
+		}
+		err = verifyRange(&report, source, chunkFrom, chunkTo, txsByHeight)
+		if err != nil {
+			return report, err
+		}
+		logger.L.Infow("Verified blocks", "from", chunkFrom, "to", chunkTo, "discrepancies", len(report.Discrepancies))
+	}
+	return report, nil
+}
+
+func verifyRange(report *VerifyReport, source ChainSource, from, to int64, txsByHeight map[int64][]db.IndexedTx) error {
+	done := make(chan struct{})
+	defer close(done)
+	for fetched := range fetchBlockHeaders(source, from, to, fetchWorkers, fetchWindow, done) {
+		if fetched.Err != nil {
+			return fetched.Err
+		}
+		discrepancies := verifyBlock(fetched.Block, txsByHeight[fetched.Height])
+		if len(discrepancies) > 0 {
+			report.Discrepancies = append(report.Discrepancies, discrepancies...)
+			report.Heights = append(report.Heights, fetched.Height)
+		}
+	}
+	return nil
+}
+
+func verifyBlock(block *BlockResult, indexedTxs []db.IndexedTx) []Discrepancy {
+	height := block.Block.Header.Height
+	discrepancies := []Discrepancy{}
+	add := func(discrepancyType string, txIndex *int, expected, actual string) {
+		discrepancies = append(discrepancies, Discrepancy{
+			Height:   height,
+			Type:     discrepancyType,
+			TxIndex:  txIndex,
+			Expected: expected,
+			Actual:   actual,
+		})
+	}
+
+	txs := block.Block.Data.Txs
+	if len(indexedTxs) != len(txs) {
+		add(DiscrepancyTxCount, nil, fmt.Sprintf("%d", len(txs)), fmt.Sprintf("%d", len(indexedTxs)))
+	}
+	expectedIndexes := map[string]int{}
+	for txIndex, tx := range txs {
+		expectedIndexes[bytes.HexBytes(tx.Hash()).String()] = txIndex
+	}
+	indexedByIndex := map[int][]db.IndexedTx{}
+	for _, tx := range indexedTxs {
+		indexedByIndex[tx.TxIndex] = append(indexedByIndex[tx.TxIndex], tx)
+	}
+	for _, tx := range indexedTxs {
+		txIndex := tx.TxIndex
+		if txIndex < 0 || txIndex >= len(txs) {
+			add(DiscrepancyUnexpectedTx, &txIndex, "", tx.TxHash)
+		}
+	}
+	for txIndex, tx := range txs {
+		txIndex := txIndex
+		expectedHash := bytes.HexBytes(tx.Hash()).String()
+		stored := indexedByIndex[txIndex]
+		if len(stored) == 0 {
+			add(DiscrepancyMissingTx, &txIndex, expectedHash, "")
+			continue
+		}
+		if len(stored) > 1 {
+			add(DiscrepancyDuplicateTxIndex, &txIndex, "1", fmt.Sprintf("%d", len(stored)))
+		}
+		for _, indexedTx := range stored {
+			if indexedTx.Placeholder {
+				add(DiscrepancyPlaceholder, &txIndex, expectedHash, indexedTx.TxHash)
+				continue
+			}
+			actualHash := strings.ToUpper(indexedTx.TxHash)
+			if actualHash == expectedHash {
+				continue
+			}
+			if _, ok := expectedIndexes[actualHash]; ok {
+				add(DiscrepancyOutOfOrderTxIndex, &txIndex, expectedHash, actualHash)
+			} else {
+				add(DiscrepancyTxHash, &txIndex, expectedHash, actualHash)
+			}
+		}
+	}
+	return discrepancies
+}
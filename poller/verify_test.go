@@ -0,0 +1,59 @@
+package poller_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	. "github.com/likecoin/likecoin-chain-tx-indexer/poller"
+	. "github.com/likecoin/likecoin-chain-tx-indexer/test"
+)
+
+func TestVerify(t *testing.T) {
+	defer CleanupTestData(Conn)
+	source := &fakeChainSource{}
+	_, err := Backfill(Pool, source, 1, 6, false, nil)
+	require.NoError(t, err)
+
+	report, err := Verify(Conn, source, 1, 6)
+	require.NoError(t, err)
+	require.Empty(t, report.Discrepancies)
+	require.Empty(t, report.Heights)
+
+	for _, sql := range []string{
+		// extra tx at height 1
+		`INSERT INTO txs (height, tx_index, tx, events) SELECT height, 3, tx, events FROM txs WHERE height = 1`,
+		// missing tx at height 2
+		`DELETE FROM txs WHERE height = 2 AND tx_index = 1`,
+		// placeholder at height 4
+		`UPDATE txs SET tx = jsonb_build_object('height', '4', 'txhash', tx -> 'txhash') WHERE height = 4`,
+		// the second tx at height 5 is stored as the first one
+		`UPDATE txs SET tx = (SELECT tx FROM txs WHERE height = 5 AND tx_index = 0) WHERE height = 5 AND tx_index = 1`,
+	} {
+		_, err = Conn.Exec(context.Background(), sql)
+		require.NoError(t, err)
+	}
+
+	report, err = Verify(Conn, source, 1, 6)
+	require.NoError(t, err)
+	require.Equal(t, []int64{1, 2, 4, 5}, report.Heights)
+	types := map[int64][]string{}
+	for _, d := range report.Discrepancies {
+		types[d.Height] = append(types[d.Height], d.Type)
+	}
+	require.Equal(t, map[int64][]string{
+		1: {DiscrepancyTxCount, DiscrepancyUnexpectedTx},
+		2: {DiscrepancyTxCount, DiscrepancyMissingTx},
+		4: {DiscrepancyPlaceholder},
+		5: {DiscrepancyOutOfOrderTxIndex},
+	}, types)
+
+	count, err := BackfillHeights(Pool, source, report.Heights, nil)
+	require.NoError(t, err)
+	require.Equal(t, 4, count)
+	report, err = Verify(Conn, source, 1, 6)
+	require.NoError(t, err)
+	// the extra tx at height 1 is not removed by backfill
+	require.Equal(t, []int64{1}, report.Heights)
+}
@@ -0,0 +1,237 @@
+package poller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"github.com/likecoin/likecoin-chain-tx-indexer/logger"
+)
+
+// Tendermint RPC pings every ~27s, so no message within this period means the connection is dead
+const wsReadTimeout = 60 * time.Second
+const wsWriteTimeout = 10 * time.Second
+
+var wsSubscribeQueries = []string{
+	"tm.event='NewBlock'",
+	"tm.event='Tx'",
+}
+
+type wsRequest struct {
+	JSONRPC string            `json:"jsonrpc"`
+	ID      int               `json:"id"`
+	Method  string            `json:"method"`
+	Params  map[string]string `json:"params"`
+}
+
+type wsResponse struct {
+	ID    int `json:"id"`
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Data    string `json:"data"`
+	} `json:"error"`
+	Result struct {
+		Query string `json:"query"`
+		Data  struct {
+			Type  string `json:"type"`
+			Value struct {
+				Block *struct {
+					Header struct {
+						Height string `json:"height"`
+					} `json:"header"`
+				} `json:"block"`
+				TxResult *struct {
+					Height string `json:"height"`
+				} `json:"TxResult"`
+			} `json:"value"`
+		} `json:"data"`
+	} `json:"result"`
+}
+
+// eventHeight returns the block height carried by a NewBlock or Tx event, or 0 if the message is not an event
+// (e.g. the response of the subscribe request)
+func (res *wsResponse) eventHeight() (int64, error) {
+	heightStr := ""
+	value := res.Result.Data.Value
+	switch {
+	case value.Block != nil:
+		heightStr = value.Block.Header.Height
+	case value.TxResult != nil:
+		heightStr = value.TxResult.Height
+	default:
+		return 0, nil
+	}
+	return strconv.ParseInt(heightStr, 10, 64)
+}
+
+// websocketURL converts the RPC endpoint (e.g. http://localhost:26657) into its websocket endpoint
+// (e.g. ws://localhost:26657/websocket)
+func websocketURL(rpcEndpoint string) (string, error) {
+	u, err := url.Parse(rpcEndpoint)
+	if err != nil {
+		return "", err
+	}
+	switch u.Scheme {
+	case "http", "tcp":
+		u.Scheme = "ws"
+	case "https":
+		u.Scheme = "wss"
+	case "ws", "wss":
+	default:
+		return "", fmt.Errorf("unsupported RPC endpoint scheme: %s", u.Scheme)
+	}
+	if !strings.HasSuffix(u.Path, "/websocket") {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/websocket"
+	}
+	return u.String(), nil
+}
+
+func subscribe(wsURL string) (*websocket.Conn, error) {
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for i, query := range wsSubscribeQueries {
+		req := wsRequest{
+			JSONRPC: "2.0",
+			ID:      i,
+			Method:  "subscribe",
+			Params:  map[string]string{"query": query},
+		}
+		_ = conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+		err = conn.WriteJSON(req)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("cannot subscribe, error = %w, query = %s", err, query)
+		}
+	}
+	conn.SetPingHandler(func(data string) error {
+		_ = conn.SetReadDeadline(time.Now().Add(wsReadTimeout))
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(wsWriteTimeout))
+	})
+	return conn, nil
+}
+
+// catchUp polls until all the blocks up to the latest block are indexed
+func catchUp(pool *pgxpool.Pool, source ChainSource, lastHeight int64, triggers []chan<- int64) (int64, error) {
+	for {
+		startHeight := lastHeight
+		returnedHeight, err := poll(pool, source, lastHeight)
+		if returnedHeight > lastHeight {
+			lastHeight = returnedHeight
+			notifyTriggers(returnedHeight, triggers)
+		}
+		// poll indexes at most batchMaxHeightDiff blocks at once
+		if err != nil || returnedHeight-startHeight < batchMaxHeightDiff {
+			return lastHeight, err
+		}
+	}
+}
+
+// consumeEvents indexes the blocks notified by the subscription until the connection is broken or done is closed
+func consumeEvents(
+	pool *pgxpool.Pool, source ChainSource, conn *websocket.Conn, lastHeight int64, done <-chan struct{},
+	triggers []chan<- int64,
+) (int64, error) {
+	for {
+		select {
+		case <-done:
+			return lastHeight, nil
+		default:
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(wsReadTimeout))
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return lastHeight, fmt.Errorf("cannot read from websocket: %w", err)
+		}
+		res := wsResponse{}
+		err = json.Unmarshal(message, &res)
+		if err != nil {
+			return lastHeight, fmt.Errorf("cannot unmarshal websocket message, error = %w, message = %s", err, string(message))
+		}
+		if res.Error != nil {
+			return lastHeight, fmt.Errorf("websocket subscription error, code = %d, message = %s, data = %s", res.Error.Code, res.Error.Message, res.Error.Data)
+		}
+		height, err := res.eventHeight()
+		if err != nil {
+			logger.L.Warnw("Cannot parse height from websocket event", "error", err, "message", string(message))
+			continue
+		}
+		if height <= lastHeight {
+			continue
+		}
+		logger.L.Debugw("Received new block event", "height", height, "query", res.Result.Query)
+		returnedHeight, err := pollUntil(pool, source, lastHeight, height)
+		if returnedHeight > lastHeight {
+			lastHeight = returnedHeight
+			notifyTriggers(returnedHeight, triggers)
+		}
+		if err != nil {
+			// the missing blocks will be indexed on the next event
+			logger.L.Errorw("cannot poll block", "error", err, "height", height)
+		}
+	}
+}
+
+func runWebsocket(pool *pgxpool.Pool, source ChainSource, rpcEndpoint string, done <-chan struct{}, triggers []chan<- int64) {
+	wsURL, err := websocketURL(rpcEndpoint)
+	if err != nil {
+		logger.L.Panicw("Invalid RPC endpoint", "rpc_endpoint", rpcEndpoint, "error", err)
+	}
+	lastHeight, err := getHeight(pool)
+	logger.L.Infow("Init Height", "lastHeight", lastHeight)
+	if err != nil {
+		logger.L.Panicw("Cannot get height from database", "error", err)
+	}
+	toSleep := sleepInitial
+	for {
+		conn, err := subscribe(wsURL)
+		if err == nil {
+			logger.L.Infow("Subscribed to RPC websocket", "url", wsURL)
+			// close the connection when done, so the blocking read returns
+			closed := make(chan struct{})
+			go func() {
+				select {
+				case <-done:
+					conn.Close()
+				case <-closed:
+				}
+			}()
+			// fill the gap since the last connection, events during catching up are buffered by the connection
+			lastHeight, err = catchUp(pool, source, lastHeight, triggers)
+			if err == nil {
+				toSleep = sleepInitial
+				lastHeight, err = consumeEvents(pool, source, conn, lastHeight, done, triggers)
+			}
+			close(closed)
+			conn.Close()
+		}
+		select {
+		case <-done:
+			return
+		default:
+		}
+		logger.L.Errorw("Websocket subscription interrupted, reconnecting", "error", err, "sleep", toSleep)
+		time.Sleep(toSleep)
+		// exponential back-off with max cap
+		toSleep = toSleep * 2
+		if toSleep > sleepMax {
+			toSleep = sleepMax
+		}
+	}
+}
+
+// RunWebsocket indexes blocks as soon as they are committed, by subscribing to NewBlock and Tx events through the
+// Tendermint RPC websocket.
+// The blocks are still fetched from the chain source, and the blocks missed while disconnected are filled by polling
+// after reconnecting.
+func RunWebsocket(pool *pgxpool.Pool, source ChainSource, rpcEndpoint string, triggers ...chan<- int64) {
+	runWebsocket(pool, source, rpcEndpoint, nil, triggers)
+}
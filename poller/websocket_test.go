@@ -0,0 +1,136 @@
+package poller_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+
+	"github.com/likecoin/likecoin-chain-tx-indexer/db"
+	. "github.com/likecoin/likecoin-chain-tx-indexer/poller"
+	. "github.com/likecoin/likecoin-chain-tx-indexer/test"
+)
+
+func newTestChainServer(latestHeight *int64, fetched func(height int64)) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		heightStr := strings.TrimPrefix(r.URL.Path, "/cosmos/base/tendermint/v1beta1/blocks/")
+		height := atomic.LoadInt64(latestHeight)
+		if heightStr != "latest" {
+			requested, err := strconv.ParseInt(heightStr, 10, 64)
+			if err != nil || requested > height {
+				w.WriteHeader(400)
+				return
+			}
+			height = requested
+			fetched(height)
+		}
+		fmt.Fprintf(w, `{"block":{"header":{"height":"%d","time":"2022-01-01T00:00:00Z"},"data":{"txs":[]}}}`, height)
+	}))
+}
+
+func newBlockEvent(height int64) string {
+	return fmt.Sprintf(`{"jsonrpc":"2.0","id":0,"result":{"query":"tm.event='NewBlock'","data":{"type":"tendermint/event/NewBlock","value":{"block":{"header":{"height":"%d"}}}}}}`, height)
+}
+
+func newTxEvent(height int64) string {
+	return fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"result":{"query":"tm.event='Tx'","data":{"type":"tendermint/event/Tx","value":{"TxResult":{"height":"%d","index":0}}}}}`, height)
+}
+
+func TestRunWebsocket(t *testing.T) {
+	defer CleanupTestData(Conn)
+	defer SetSleepInitial(10 * time.Millisecond)()
+
+	latestHeight := int64(2)
+	block4Fetched := make(chan struct{})
+	block4FetchedOnce := sync.Once{}
+	lcdServer := newTestChainServer(&latestHeight, func(height int64) {
+		if height == 4 {
+			block4FetchedOnce.Do(func() { close(block4Fetched) })
+		}
+	})
+	defer lcdServer.Close()
+
+	connCount := int32(0)
+	upgrader := websocket.Upgrader{}
+	rpcServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/websocket" {
+			w.WriteHeader(404)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("cannot upgrade websocket: %v", err)
+			return
+		}
+		defer conn.Close()
+		for i := 0; i < 2; i++ {
+			_, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if !strings.Contains(string(message), `"subscribe"`) {
+				t.Errorf("unexpected request: %s", string(message))
+			}
+			_ = conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"result":{}}`, i)))
+		}
+		if atomic.AddInt32(&connCount, 1) > 1 {
+			// keep the second connection open without events, so the remaining blocks can only be indexed by
+			// filling the gap after reconnect
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}
+		atomic.StoreInt64(&latestHeight, 4)
+		for _, event := range []string{newBlockEvent(3), newTxEvent(3), newBlockEvent(4), newTxEvent(4)} {
+			_ = conn.WriteMessage(websocket.TextMessage, []byte(event))
+		}
+		select {
+		case <-block4Fetched:
+		case <-time.After(10 * time.Second):
+			t.Errorf("block 4 is not fetched after new block event")
+		}
+		// blocks committed while disconnected
+		atomic.StoreInt64(&latestHeight, 6)
+	}))
+	defer rpcServer.Close()
+
+	trigger := make(chan int64, 100)
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		RunWebsocketUntil(Pool, newTestContext(lcdServer.URL), rpcServer.URL, done, []chan<- int64{trigger})
+	}()
+
+	timeout := time.After(10 * time.Second)
+	for triggered := int64(0); triggered < 6; {
+		select {
+		case height := <-trigger:
+			if height > triggered {
+				triggered = height
+			}
+		case <-timeout:
+			require.FailNow(t, "blocks are not indexed in time")
+		}
+	}
+	close(done)
+	<-finished
+
+	require.GreaterOrEqual(t, atomic.LoadInt32(&connCount), int32(2))
+	height, err := db.GetLatestHeight(Conn)
+	require.NoError(t, err)
+	require.Equal(t, int64(6), height)
+	block, err := db.GetBlockByHeight(Conn, 6)
+	require.NoError(t, err)
+	require.Equal(t, int64(6), block.Height)
+}
@@ -0,0 +1,119 @@
+package rest
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/likecoin/likecoin-chain-tx-indexer/db"
+	"github.com/likecoin/likecoin-chain-tx-indexer/poller"
+)
+
+// maximum number of heights verified in one request, since the blocks are fetched from the chain synchronously
+const maxVerifyHeights = 10000
+
+type VerifyRequest struct {
+	From int64 `form:"from"`
+	To   int64 `form:"to"`
+}
+
+type ExtractionFailuresRequest struct {
+	Limit int `form:"limit,default=100" binding:"gte=1,lte=1000"`
+}
+
+// AddAdminRoutes registers the admin endpoints, which require `Authorization: Bearer <adminToken>`.
+// They are not registered if adminToken is empty.
+func AddAdminRoutes(router *gin.Engine, adminToken string, source poller.ChainSource, extractors []db.NamedExtractor) {
+	if adminToken == "" {
+		return
+	}
+	admin := router.Group(ADMIN_ENDPOINT, withAdminToken(adminToken), withChainSource(source), withExtractors(extractors))
+	{
+		admin.GET("/verify", handleVerify)
+		admin.GET("/extraction-failures", handleExtractionFailures)
+		admin.POST("/extraction-failures/retry", handleRetryExtractionFailures)
+	}
+}
+
+func withAdminToken(adminToken string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+			c.AbortWithStatusJSON(401, gin.H{"error": "invalid admin token"})
+			return
+		}
+		c.Next()
+	}
+}
+
+func withChainSource(source poller.ChainSource) gin.HandlerFunc {
+	return with("chain-source", source)
+}
+
+func getChainSource(c *gin.Context) poller.ChainSource {
+	return c.MustGet("chain-source").(poller.ChainSource)
+}
+
+func withExtractors(extractors []db.NamedExtractor) gin.HandlerFunc {
+	return with("extractors", extractors)
+}
+
+func getExtractors(c *gin.Context) []db.NamedExtractor {
+	return c.MustGet("extractors").([]db.NamedExtractor)
+}
+
+func handleVerify(c *gin.Context) {
+	var q VerifyRequest
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if q.From <= 0 || q.To < q.From {
+		c.AbortWithStatusJSON(400, gin.H{"error": "invalid height range"})
+		return
+	}
+	if q.To-q.From+1 > maxVerifyHeights {
+		c.AbortWithStatusJSON(400, gin.H{"error": fmt.Sprintf("cannot verify more than %d heights in one request", maxVerifyHeights)})
+		return
+	}
+	report, err := poller.Verify(getConn(c), getChainSource(c), q.From, q.To)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, report)
+}
+
+func handleExtractionFailures(c *gin.Context) {
+	var q ExtractionFailuresRequest
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	conn := getConn(c)
+	failures, err := db.GetExtractionFailures(conn, q.Limit)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	count, err := db.CountExtractionFailures(conn)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, db.QueryExtractionFailuresResponse{
+		Failures: failures,
+		Count:    count,
+	})
+}
+
+func handleRetryExtractionFailures(c *gin.Context) {
+	res, err := db.RetryExtractionFailures(getConn(c), getExtractors(c))
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, res)
+}
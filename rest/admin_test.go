@@ -0,0 +1,124 @@
+package rest_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/likecoin/likecoin-chain-tx-indexer/db"
+	"github.com/likecoin/likecoin-chain-tx-indexer/poller"
+	. "github.com/likecoin/likecoin-chain-tx-indexer/rest"
+	. "github.com/likecoin/likecoin-chain-tx-indexer/test"
+)
+
+// emptyChainSource serves blocks without txs
+type emptyChainSource struct{}
+
+func (emptyChainSource) GetBlock(height int64) (*poller.BlockResult, error) {
+	block := &poller.BlockResult{}
+	block.Block.Header.Height = height
+	return block, nil
+}
+
+func (emptyChainSource) GetBlockTxResponses(block *poller.BlockResult) ([]*types.TxResponse, error) {
+	return []*types.TxResponse{}, nil
+}
+
+func TestAdminVerify(t *testing.T) {
+	defer CleanupTestData(Conn)
+	InsertTestData(DBTestData{
+		Txs: []string{`{"height":"2","txhash":"AAAA"}`},
+	})
+	router := GetRouter(Pool, nil)
+	AddAdminRoutes(router, "secret", emptyChainSource{}, nil)
+
+	req := httptest.NewRequest("GET", ADMIN_ENDPOINT+"/verify?from=1&to=3", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, 401, w.Code)
+
+	req = httptest.NewRequest("GET", ADMIN_ENDPOINT+"/verify?from=1&to=3", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, 401, w.Code)
+
+	req = httptest.NewRequest("GET", ADMIN_ENDPOINT+"/verify?from=3&to=1", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, 400, w.Code)
+
+	req = httptest.NewRequest("GET", ADMIN_ENDPOINT+"/verify?from=1&to=3", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, 200, w.Code, w.Body.String())
+	var report poller.VerifyReport
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	require.Equal(t, []int64{2}, report.Heights)
+	require.Len(t, report.Discrepancies, 2)
+	require.Equal(t, poller.DiscrepancyTxCount, report.Discrepancies[0].Type)
+	require.Equal(t, poller.DiscrepancyUnexpectedTx, report.Discrepancies[1].Type)
+}
+
+func TestAdminDisabled(t *testing.T) {
+	router := GetRouter(Pool, nil)
+	AddAdminRoutes(router, "", emptyChainSource{}, nil)
+	req := httptest.NewRequest("GET", ADMIN_ENDPOINT+"/verify?from=1&to=3", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, 404, w.Code)
+}
+
+func TestAdminExtractionFailures(t *testing.T) {
+	defer CleanupTestData(Conn)
+	InsertTestData(DBTestData{
+		Txs:               []string{`{"height":"1","txhash":"AAAA","tx":{"body":{"messages":[],"memo":""}},"logs":[],"timestamp":"2022-01-01T00:00:00Z"}`},
+		LatestBlockHeight: 1,
+	})
+	broken := true
+	extractors := []db.NamedExtractor{{
+		Name: "test_admin",
+		Extractor: func(ctx db.EventContext) error {
+			if broken {
+				return fmt.Errorf("broken")
+			}
+			return nil
+		},
+	}}
+	_, err := db.ExtractNamed(Conn, extractors)
+	require.NoError(t, err)
+
+	router := GetRouter(Pool, nil)
+	AddAdminRoutes(router, "secret", emptyChainSource{}, extractors)
+
+	req := httptest.NewRequest("GET", ADMIN_ENDPOINT+"/extraction-failures", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, 200, w.Code, w.Body.String())
+	var res db.QueryExtractionFailuresResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	require.Equal(t, int64(1), res.Count)
+	require.Len(t, res.Failures, 1)
+	require.Equal(t, "AAAA", res.Failures[0].TxHash)
+
+	broken = false
+	req = httptest.NewRequest("POST", ADMIN_ENDPOINT+"/extraction-failures/retry", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, 200, w.Code, w.Body.String())
+	var retryRes db.RetryExtractionFailuresResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &retryRes))
+	require.Equal(t, 1, retryRes.Resolved)
+
+	count, err := db.CountExtractionFailures(Conn)
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
@@ -0,0 +1,73 @@
+package rest
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v4"
+
+	"github.com/likecoin/likecoin-chain-tx-indexer/db"
+)
+
+func respondBlock(c *gin.Context, block db.Block, err error) {
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.AbortWithStatusJSON(404, gin.H{"error": "block not found"})
+			return
+		}
+		c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, block)
+}
+
+func handleBlockByHeight(c *gin.Context) {
+	height, err := strconv.ParseInt(c.Param("height"), 10, 64)
+	if err != nil || height <= 0 {
+		c.AbortWithStatusJSON(400, gin.H{"error": "invalid height"})
+		return
+	}
+	block, err := db.GetBlockByHeight(getConn(c), height)
+	respondBlock(c, block, err)
+}
+
+// handleBlockByTime returns the latest block at or before the time, which is either in RFC3339 format or unix
+// timestamp in seconds
+func handleBlockByTime(c *gin.Context) {
+	timeStr := c.Param("time")
+	t, err := time.Parse(time.RFC3339, timeStr)
+	if err != nil {
+		unix, unixErr := strconv.ParseInt(timeStr, 10, 64)
+		if unixErr != nil {
+			c.AbortWithStatusJSON(400, gin.H{"error": "invalid time, expect RFC3339 format or unix timestamp"})
+			return
+		}
+		t = time.Unix(unix, 0)
+	}
+	block, err := db.GetBlockByTime(getConn(c), t)
+	respondBlock(c, block, err)
+}
+
+func handleBlocks(c *gin.Context) {
+	var q db.QueryBlocksRequest
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	p, err := getPagination(c)
+	if err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := db.GetBlocks(getConn(c), q, p)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, res)
+}
@@ -0,0 +1,109 @@
+package rest_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/likecoin/likecoin-chain-tx-indexer/db"
+	. "github.com/likecoin/likecoin-chain-tx-indexer/rest"
+	. "github.com/likecoin/likecoin-chain-tx-indexer/test"
+)
+
+func TestBlock(t *testing.T) {
+	defer CleanupTestData(Conn)
+	baseTime := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	blocks := []db.Block{}
+	for height := int64(1); height <= 5; height++ {
+		blocks = append(blocks, db.Block{
+			Height:   height,
+			Hash:     fmt.Sprintf("HASH%d", height),
+			Time:     baseTime.Add(time.Duration(height) * 6 * time.Second),
+			Proposer: "PROPOSER",
+			AppHash:  "APPHASH",
+		})
+	}
+	InsertTestData(DBTestData{Blocks: blocks})
+
+	table := []struct {
+		name   string
+		path   string
+		status int
+		height int64
+	}{
+		{
+			name:   "by height",
+			path:   "/height/3",
+			status: 200,
+			height: 3,
+		},
+		{
+			name:   "by height not found",
+			path:   "/height/6",
+			status: 404,
+		},
+		{
+			name:   "invalid height",
+			path:   "/height/abc",
+			status: 400,
+		},
+		{
+			name:   "by time",
+			path:   "/time/" + blocks[2].Time.Add(time.Second).Format(time.RFC3339),
+			status: 200,
+			height: 3,
+		},
+		{
+			name:   "by unix time",
+			path:   fmt.Sprintf("/time/%d", blocks[3].Time.Unix()),
+			status: 200,
+			height: 4,
+		},
+		{
+			name:   "by time before first block",
+			path:   "/time/" + baseTime.Format(time.RFC3339),
+			status: 404,
+		},
+		{
+			name:   "invalid time",
+			path:   "/time/yesterday",
+			status: 400,
+		},
+	}
+	for _, v := range table {
+		t.Run(v.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", BLOCK_ENDPOINT+v.path, nil)
+			res, body := request(req)
+			require.Equal(t, v.status, res.StatusCode, body)
+			if v.status != 200 {
+				return
+			}
+			var block db.Block
+			err := json.Unmarshal([]byte(body), &block)
+			require.NoError(t, err)
+			require.Equal(t, blocks[v.height-1], block)
+		})
+	}
+
+	req := httptest.NewRequest("GET", BLOCK_ENDPOINT+"?from_height=2&to_height=4&pagination.reverse=true", nil)
+	res, body := request(req)
+	require.Equal(t, 200, res.StatusCode, body)
+	var blocksRes db.QueryBlocksResponse
+	err := json.Unmarshal([]byte(body), &blocksRes)
+	require.NoError(t, err)
+	require.Len(t, blocksRes.Blocks, 3)
+	require.Equal(t, int64(4), blocksRes.Blocks[0].Height)
+	require.Equal(t, int64(2), blocksRes.Blocks[2].Height)
+
+	req = httptest.NewRequest("GET", BLOCK_ENDPOINT+"?from_time="+blocks[3].Time.Format(time.RFC3339), nil)
+	res, body = request(req)
+	require.Equal(t, 200, res.StatusCode, body)
+	err = json.Unmarshal([]byte(body), &blocksRes)
+	require.NoError(t, err)
+	require.Len(t, blocksRes.Blocks, 2)
+	require.Equal(t, int64(4), blocksRes.Blocks[0].Height)
+}
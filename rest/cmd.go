@@ -5,20 +5,16 @@ import (
 )
 
 const (
-	CmdLcdEndpoint  = "lcd-endpoint"
-	CmdListenAddr   = "listen-addr"
-	CmdApiAddresses = "api-address"
+	CmdLcdEndpoint = "lcd-endpoint"
+	CmdListenAddr  = "listen-addr"
+	CmdAdminToken  = "admin-token"
 
 	DefaultLcdEndpoint = "http://localhost:1317"
 	DefaultListenAddr  = "localhost:8997"
 )
 
-var (
-	DefaultApiAddresses = []string{"like17m4vwrnhjmd20uu7tst7nv0kap6ee7js69jfrs"}
-)
-
 func ConfigCmd(cmd *cobra.Command) {
-	cmd.PersistentFlags().String(CmdLcdEndpoint, DefaultLcdEndpoint, "LikeCoin chain lite client RPC endpoint")
+	cmd.PersistentFlags().StringSlice(CmdLcdEndpoint, []string{DefaultLcdEndpoint}, "LikeCoin chain lite client RPC endpoints, requests fail over between them")
 	cmd.PersistentFlags().String(CmdListenAddr, DefaultListenAddr, "HTTP API serving address")
-	cmd.PersistentFlags().StringSlice(CmdApiAddresses, DefaultApiAddresses, "Default API sender addresses for NFT ranking and stats")
+	cmd.PersistentFlags().String(CmdAdminToken, "", "Bearer token for the admin endpoints, which are disabled if empty")
 }
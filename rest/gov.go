@@ -0,0 +1,117 @@
+package rest
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/likecoin/likecoin-chain-tx-indexer/db"
+)
+
+func getProposalId(c *gin.Context) (uint64, bool) {
+	proposalId, err := strconv.ParseUint(c.Param("proposal_id"), 10, 64)
+	if err != nil || proposalId == 0 {
+		c.AbortWithStatusJSON(400, gin.H{"error": "invalid proposal id"})
+		return 0, false
+	}
+	return proposalId, true
+}
+
+func handleGovProposals(c *gin.Context) {
+	var q db.QueryGovProposalsRequest
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": "invalid inputs: " + err.Error()})
+		return
+	}
+
+	p, err := getPagination(c)
+	if err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := db.GetGovProposals(getConn(c), q, p)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, res)
+}
+
+func handleGovDeposits(c *gin.Context) {
+	proposalId, ok := getProposalId(c)
+	if !ok {
+		return
+	}
+
+	p, err := getPagination(c)
+	if err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := db.GetGovDeposits(getConn(c), proposalId, p)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, res)
+}
+
+func respondGovVotes(c *gin.Context, q db.QueryGovVotesRequest) {
+	p, err := getPagination(c)
+	if err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := db.GetGovVotes(getConn(c), q, p)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, res)
+}
+
+// handleGovProposalVotes returns the vote timeline of the proposal
+func handleGovProposalVotes(c *gin.Context) {
+	proposalId, ok := getProposalId(c)
+	if !ok {
+		return
+	}
+	var q db.QueryGovVotesRequest
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": "invalid inputs: " + err.Error()})
+		return
+	}
+	q.ProposalId = proposalId
+	respondGovVotes(c, q)
+}
+
+// handleGovVotes returns the voting history of the voter
+func handleGovVotes(c *gin.Context) {
+	var q db.QueryGovVotesRequest
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": "invalid inputs: " + err.Error()})
+		return
+	}
+	if q.Voter == "" {
+		c.AbortWithStatusJSON(400, gin.H{"error": "must provide voter"})
+		return
+	}
+	respondGovVotes(c, q)
+}
+
+func handleGovTurnout(c *gin.Context) {
+	proposalId, ok := getProposalId(c)
+	if !ok {
+		return
+	}
+
+	res, err := db.GetGovTurnout(getConn(c), proposalId)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, res)
+}
@@ -0,0 +1,45 @@
+package rest
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/likecoin/likecoin-chain-tx-indexer/db"
+	"github.com/likecoin/likecoin-chain-tx-indexer/lcd"
+)
+
+type HealthResponse struct {
+	Healthy            bool                 `json:"healthy"`
+	LatestHeight       int64                `json:"latest_height"`
+	ExtractionFailures int64                `json:"extraction_failures"`
+	LcdEndpoints       []lcd.EndpointStatus `json:"lcd_endpoints"`
+}
+
+func handleHealth(c *gin.Context) {
+	conn := getConn(c)
+	latestHeight, err := db.GetLatestHeight(conn)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	extractionFailures, err := db.CountExtractionFailures(conn)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	res := HealthResponse{
+		LatestHeight:       latestHeight,
+		ExtractionFailures: extractionFailures,
+		LcdEndpoints:       getLcdPool(c).Status(),
+	}
+	for _, status := range res.LcdEndpoints {
+		if status.Available {
+			res.Healthy = true
+			break
+		}
+	}
+	statusCode := 200
+	if !res.Healthy {
+		statusCode = 503
+	}
+	c.JSON(statusCode, res)
+}
@@ -0,0 +1,82 @@
+package rest
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/likecoin/likecoin-chain-tx-indexer/db"
+)
+
+func bindIbcTransfersRequest(c *gin.Context) (db.QueryIbcTransfersRequest, bool) {
+	var q db.QueryIbcTransfersRequest
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": "invalid inputs: " + err.Error()})
+		return q, false
+	}
+	switch q.Direction {
+	case "", db.IBC_TRANSFER_DIRECTION_SEND, db.IBC_TRANSFER_DIRECTION_RECEIVE:
+	default:
+		c.AbortWithStatusJSON(400, gin.H{"error": "direction should be send or receive"})
+		return q, false
+	}
+	return q, true
+}
+
+func respondIbcTransfers(c *gin.Context, q db.QueryIbcTransfersRequest) {
+	p, err := getPagination(c)
+	if err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := db.GetIbcTransfers(getConn(c), q, p)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, res)
+}
+
+func handleIbcTransfers(c *gin.Context) {
+	q, ok := bindIbcTransfersRequest(c)
+	if !ok {
+		return
+	}
+	for _, status := range q.Status {
+		switch status {
+		case db.IBC_TRANSFER_STATUS_PENDING, db.IBC_TRANSFER_STATUS_ACKNOWLEDGED, db.IBC_TRANSFER_STATUS_RECEIVED,
+			db.IBC_TRANSFER_STATUS_FAILED, db.IBC_TRANSFER_STATUS_TIMED_OUT:
+		default:
+			c.AbortWithStatusJSON(400, gin.H{"error": "status should only include pending, acknowledged, received, failed or timed_out"})
+			return
+		}
+	}
+	respondIbcTransfers(c, q)
+}
+
+// handleIbcTransfersInFlight returns the sent transfers of the address waiting for the acknowledgement or timeout
+func handleIbcTransfersInFlight(c *gin.Context) {
+	q, ok := bindIbcTransfersRequest(c)
+	if !ok {
+		return
+	}
+	if q.Address == "" {
+		c.AbortWithStatusJSON(400, gin.H{"error": "must provide address"})
+		return
+	}
+	q.Status = []string{db.IBC_TRANSFER_STATUS_PENDING}
+	respondIbcTransfers(c, q)
+}
+
+// handleIbcTransfersFailed returns the transfers of the address which failed or timed out
+func handleIbcTransfersFailed(c *gin.Context) {
+	q, ok := bindIbcTransfersRequest(c)
+	if !ok {
+		return
+	}
+	if q.Address == "" {
+		c.AbortWithStatusJSON(400, gin.H{"error": "must provide address"})
+		return
+	}
+	q.Status = []string{db.IBC_TRANSFER_STATUS_FAILED, db.IBC_TRANSFER_STATUS_TIMED_OUT}
+	respondIbcTransfers(c, q)
+}
@@ -1,6 +1,8 @@
 package rest
 
 import (
+	"strings"
+
 	"github.com/gin-gonic/gin"
 	"github.com/likecoin/likecoin-chain-tx-indexer/db"
 	iscntypes "github.com/likecoin/likecoin-chain/v4/x/iscn/types"
@@ -67,3 +69,45 @@ func handleIscnSearch(c *gin.Context, form db.IscnQuery) {
 
 	c.JSON(200, res)
 }
+
+// handleIscnHistory serves the history of the ISCN at `/{prefix}/history` under ISCN_ENDPOINT, where the ISCN ID prefix
+// in the path is URL encoded
+func handleIscnHistory(c *gin.Context) {
+	path := strings.TrimPrefix(c.Param("path"), "/")
+	if !strings.HasSuffix(path, "/history") {
+		c.AbortWithStatusJSON(404, gin.H{"error": "not found"})
+		return
+	}
+	iscnId, err := iscntypes.ParseIscnId(strings.TrimSuffix(path, "/history"))
+	if err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": "invalid inputs: " + err.Error()})
+		return
+	}
+	var q db.QueryIscnHistoryRequest
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": "invalid inputs: " + err.Error()})
+		return
+	}
+	for _, action := range q.Action {
+		switch action {
+		case db.ISCN_ACTION_CREATE, db.ISCN_ACTION_UPDATE, db.ISCN_ACTION_TRANSFER:
+		default:
+			c.AbortWithStatusJSON(400, gin.H{"error": "action should only include create, update or transfer"})
+			return
+		}
+	}
+	q.IscnIdPrefix = iscnId.Prefix.String()
+
+	p, err := getPagination(c)
+	if err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := db.GetIscnHistory(getConn(c), q, p)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, res)
+}
@@ -4,11 +4,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/likecoin/likecoin-chain-tx-indexer/db"
+	"github.com/likecoin/likecoin-chain-tx-indexer/extractor"
 	. "github.com/likecoin/likecoin-chain-tx-indexer/rest"
 	. "github.com/likecoin/likecoin-chain-tx-indexer/test"
 )
@@ -150,3 +152,79 @@ func TestISCNCombine(t *testing.T) {
 		})
 	}
 }
+
+func TestIscnHistory(t *testing.T) {
+	defer CleanupTestData(Conn)
+	iscnIdPrefix := "iscn://testing/abcdef"
+	txs := []string{
+		fmt.Sprintf(
+			`{"height":"1234","txhash":"AAAAAA","tx":{"body":{"messages":[{"@type":"/likechain.iscn.MsgCreateIscnRecord","from":"%[1]s","record":{"contentMetadata":{}}}],"memo":""}},"logs":[{"msg_index":0,"log":"","events":[{"type":"iscn_record","attributes":[{"key":"iscn_id","value":"%[2]s/1"},{"key":"iscn_id_prefix","value":"%[2]s"},{"key":"owner","value":"%[1]s"},{"key":"ipld","value":"ipldxxxxxxxxxx"}]}]}],"timestamp":"2022-01-01T00:00:00Z"}`,
+			ADDR_01_LIKE, iscnIdPrefix,
+		),
+		fmt.Sprintf(
+			`{"height":"1235","txhash":"BBBBBB","tx":{"body":{"messages":[{"@type":"/likechain.iscn.MsgChangeIscnRecordOwnership","from":"%[1]s","iscn_id":"%[2]s/1","new_owner":"%[3]s"}],"memo":""}},"logs":[{"msg_index":0,"log":"","events":[{"type":"iscn_record","attributes":[{"key":"iscn_id","value":"%[2]s/1"},{"key":"iscn_id_prefix","value":"%[2]s"},{"key":"owner","value":"%[3]s"}]}]}],"timestamp":"2022-01-02T00:00:00Z"}`,
+			ADDR_01_LIKE, iscnIdPrefix, ADDR_02_LIKE,
+		),
+	}
+	InsertTestData(DBTestData{Txs: txs})
+	_, err := db.ExtractNamed(Conn, extractor.Extractors)
+	require.NoError(t, err)
+
+	table := []struct {
+		name   string
+		prefix string
+		query  string
+		status int
+		length int
+	}{
+		{
+			name:   "prefix",
+			prefix: iscnIdPrefix,
+			length: 2,
+		},
+		{
+			name:   "iscn_id",
+			prefix: iscnIdPrefix + "/1",
+			length: 2,
+		},
+		{
+			name:   "action",
+			prefix: iscnIdPrefix,
+			query:  "action=transfer",
+			length: 1,
+		},
+		{
+			name:   "invalid action",
+			prefix: iscnIdPrefix,
+			query:  "action=burn",
+			status: 400,
+		},
+		{
+			name:   "invalid prefix",
+			prefix: "abcdef",
+			status: 400,
+		},
+	}
+	for _, v := range table {
+		t.Run(v.name, func(t *testing.T) {
+			req := httptest.NewRequest(
+				"GET",
+				fmt.Sprintf("%s/%s/history?%s",
+					ISCN_ENDPOINT, url.PathEscape(v.prefix), v.query),
+				nil,
+			)
+			res, body := request(req)
+			if v.status == 0 {
+				v.status = 200
+			}
+			require.Equal(t, v.status, res.StatusCode, body)
+			if res.StatusCode != 200 {
+				return
+			}
+			var history db.QueryIscnHistoryResponse
+			require.NoError(t, json.Unmarshal([]byte(body), &history))
+			require.Len(t, history.Events, v.length)
+			require.Equal(t, iscnIdPrefix, history.Events[0].IscnIdPrefix)
+		})
+	}
+}
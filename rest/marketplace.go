@@ -1,6 +1,8 @@
 package rest
 
 import (
+	"fmt"
+
 	"github.com/gin-gonic/gin"
 	"github.com/likecoin/likecoin-chain-tx-indexer/db"
 )
@@ -33,3 +35,104 @@ func handleNftMarketplaceItem(c *gin.Context) {
 
 	c.JSON(200, res)
 }
+
+func handleNftMarketplaceHistory(c *gin.Context) {
+	var q db.QueryNftMarketplaceHistoryRequest
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": "invalid inputs: " + err.Error()})
+		return
+	}
+	if q.Type != "" && q.Type != "listing" && q.Type != "offer" {
+		c.AbortWithStatusJSON(400, gin.H{"error": `invalid type (expect "listing" or "offer")`})
+		return
+	}
+	for _, action := range q.Action {
+		switch action {
+		case db.MARKETPLACE_ACTION_CREATE, db.MARKETPLACE_ACTION_UPDATE, db.MARKETPLACE_ACTION_DELETE, db.MARKETPLACE_ACTION_FILL:
+		default:
+			c.AbortWithStatusJSON(400, gin.H{"error": "action should only include create, update, delete or fill"})
+			return
+		}
+	}
+
+	p, err := getPagination(c)
+	if err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := db.GetNftMarketplaceHistory(getConn(c), q, p)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, res)
+}
+
+func handleNftMarketplaceSellers(c *gin.Context) {
+	var q db.QueryNftMarketplaceSellerStatsRequest
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": "invalid inputs: " + err.Error()})
+		return
+	}
+
+	p, err := getPagination(c)
+	if err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := db.GetNftMarketplaceSellerStats(getConn(c), q, p)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, res)
+}
+
+const maxMarketplaceClassStatsClassIds = 100
+const maxMarketplaceDepthLevels = 100
+
+func handleNftMarketplaceClassStats(c *gin.Context) {
+	var q db.QueryNftMarketplaceClassStatsRequest
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": "invalid inputs: " + err.Error()})
+		return
+	}
+	if len(q.ClassIds) > maxMarketplaceClassStatsClassIds {
+		c.AbortWithStatusJSON(400, gin.H{"error": fmt.Sprintf("at most %d class_id allowed", maxMarketplaceClassStatsClassIds)})
+		return
+	}
+	if q.DepthLevels > maxMarketplaceDepthLevels {
+		c.AbortWithStatusJSON(400, gin.H{"error": fmt.Sprintf("depth_levels should not exceed %d", maxMarketplaceDepthLevels)})
+		return
+	}
+
+	res, err := db.GetNftMarketplaceClassStats(getConn(c), q)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, res)
+}
+
+func handleNftMarketplaceFloorPriceHistory(c *gin.Context) {
+	var q db.QueryNftMarketplaceFloorPriceHistoryRequest
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": "invalid inputs: " + err.Error()})
+		return
+	}
+
+	p, err := getPagination(c)
+	if err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := db.GetNftMarketplaceFloorPriceHistory(getConn(c), q, p)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, res)
+}
@@ -151,6 +151,18 @@ func TestMarketplace(t *testing.T) {
 			length: 3,
 			items:  []db.NftMarketplaceItem{marketplaceItems[0], marketplaceItems[1], marketplaceItems[2]},
 		},
+		{
+			name:   "include inactive listings",
+			query:  "type=listing&active_only=false",
+			length: 4,
+			items:  []db.NftMarketplaceItem{marketplaceItems[0], marketplaceItems[1], marketplaceItems[2], marketplaceItems[3]},
+		},
+		{
+			name:   "active listings",
+			query:  "type=listing&active_only=true",
+			length: 3,
+			items:  []db.NftMarketplaceItem{marketplaceItems[0], marketplaceItems[1], marketplaceItems[2]},
+		},
 		{
 			name:   "query all offers",
 			query:  "type=offer",
@@ -269,3 +281,30 @@ func TestMarketplace(t *testing.T) {
 		})
 	}
 }
+
+func TestMarketplaceQueryInputs(t *testing.T) {
+	table := []struct {
+		name       string
+		path       string
+		shouldFail bool
+	}{
+		{name: "history", path: "/marketplace/history?class_id=nftlike1aaaaa1"},
+		{name: "history by type and action", path: "/marketplace/history?class_id=nftlike1aaaaa1&type=offer&action=create&action=fill"},
+		{name: "history without class ID", path: "/marketplace/history", shouldFail: true},
+		{name: "history with invalid type", path: "/marketplace/history?class_id=nftlike1aaaaa1&type=listings", shouldFail: true},
+		{name: "history with invalid action", path: "/marketplace/history?class_id=nftlike1aaaaa1&action=buy", shouldFail: true},
+		{name: "sellers", path: "/marketplace/sellers?class_id=nftlike1aaaaa1&seller=" + ADDR_01_LIKE},
+		{name: "class stats", path: "/marketplace/classes?class_id=nftlike1aaaaa1&class_id=nftlike1bbbbbb&bucket_size=100"},
+		{name: "class stats without class ID", path: "/marketplace/classes", shouldFail: true},
+		{name: "class stats with too many depth levels", path: "/marketplace/classes?class_id=nftlike1aaaaa1&bucket_size=100&depth_levels=1000", shouldFail: true},
+		{name: "floor price history", path: "/marketplace/floor-price-history?class_id=nftlike1aaaaa1&after=1700000000"},
+		{name: "floor price history without class ID", path: "/marketplace/floor-price-history", shouldFail: true},
+	}
+	for _, v := range table {
+		t.Run(v.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", NFT_ENDPOINT+v.path, nil)
+			httpRes, body := request(req)
+			require.Equal(t, v.shouldFail, httpRes.StatusCode != 200, body)
+		})
+	}
+}
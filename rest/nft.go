@@ -1,10 +1,34 @@
 package rest
 
 import (
+	"errors"
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"github.com/likecoin/likecoin-chain-tx-indexer/db"
 )
 
+func validateOwnershipAt(c *gin.Context, atHeight int64, atTime *time.Time) bool {
+	if atHeight < 0 {
+		c.AbortWithStatusJSON(400, gin.H{"error": "at_height should not be negative"})
+		return false
+	}
+	if atHeight != 0 && atTime != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": "at_height and at_time cannot be both set"})
+		return false
+	}
+	return true
+}
+
+// abortOwnershipQuery responds 400 for the historical ownership not extracted yet, and 500 for the other errors
+func abortOwnershipQuery(c *gin.Context, err error) {
+	if errors.Is(err, db.ErrOwnershipNotExtracted) {
+		c.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+}
+
 func handleNftClass(c *gin.Context) {
 	var q db.QueryClassRequest
 
@@ -28,6 +52,35 @@ func handleNftClass(c *gin.Context) {
 	c.JSON(200, res)
 }
 
+func handleNftClassConfigHistory(c *gin.Context) {
+	var q db.QueryNftClassConfigHistoryRequest
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": "invalid inputs: " + err.Error()})
+		return
+	}
+	for _, configType := range q.ConfigType {
+		switch configType {
+		case db.NFT_CLASS_CONFIG_TYPE_CLASS, db.NFT_CLASS_CONFIG_TYPE_ROYALTY:
+		default:
+			c.AbortWithStatusJSON(400, gin.H{"error": "config_type should only include class or royalty"})
+			return
+		}
+	}
+
+	p, err := getPagination(c)
+	if err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := db.GetNftClassConfigHistory(getConn(c), q, p)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, res)
+}
+
 func handleNft(c *gin.Context) {
 	var q db.QueryNftRequest
 
@@ -35,6 +88,9 @@ func handleNft(c *gin.Context) {
 		c.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
 		return
 	}
+	if !validateOwnershipAt(c, q.AtHeight, q.AtTime) {
+		return
+	}
 
 	p, err := getPagination(c)
 	if err != nil {
@@ -45,7 +101,7 @@ func handleNft(c *gin.Context) {
 	conn := getConn(c)
 	res, err := db.GetNfts(conn, q, p)
 	if err != nil {
-		c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+		abortOwnershipQuery(c, err)
 		return
 	}
 
@@ -60,10 +116,14 @@ func handleNftOwner(c *gin.Context) {
 		return
 	}
 
+	if !validateOwnershipAt(c, q.AtHeight, q.AtTime) {
+		return
+	}
+
 	conn := getConn(c)
 	res, err := db.GetOwners(conn, q)
 	if err != nil {
-		c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+		abortOwnershipQuery(c, err)
 		return
 	}
 
@@ -88,8 +148,9 @@ func handleNftEvents(c *gin.Context) {
 		len(form.Sender) == 0 &&
 		len(form.Receiver) == 0 &&
 		len(form.Creator) == 0 &&
-		len(form.Involver) == 0 {
-		c.AbortWithStatusJSON(400, gin.H{"error": "must provide either class_id, iscn_id_prefix, sender, receiver, creator or involver"})
+		len(form.Involver) == 0 &&
+		len(form.Executor) == 0 {
+		c.AbortWithStatusJSON(400, gin.H{"error": "must provide either class_id, iscn_id_prefix, sender, receiver, creator, involver or executor"})
 		return
 	}
 	conn := getConn(c)
@@ -281,10 +342,13 @@ func handleClassesOwnersRequest(c *gin.Context) {
 		c.AbortWithStatusJSON(400, gin.H{"error": "invalid inputs: " + err.Error()})
 		return
 	}
+	if !validateOwnershipAt(c, form.AtHeight, form.AtTime) {
+		return
+	}
 	conn := getConn(c)
 	res, err := db.GetClassesOwners(conn, form)
 	if err != nil {
-		c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+		abortOwnershipQuery(c, err)
 		return
 	}
 
@@ -170,3 +170,33 @@ func TestQueryClassesOwners(t *testing.T) {
 	require.NoError(t, err)
 	require.Contains(t, res.Err, "Field validation for 'ClassIds' failed on the 'required' tag")
 }
+
+func TestOwnershipAtInputs(t *testing.T) {
+	defer CleanupTestData(Conn)
+	InsertTestData(DBTestData{
+		Txs:              []string{`{"txhash":"TX100","height":"100","timestamp":"2023-11-14T22:13:20Z"}`},
+		ExtractorHeights: map[string]int64{"nft": 100, "marketplace": 100},
+	})
+	table := []struct {
+		name   string
+		path   string
+		status int
+	}{
+		{name: "owners at height", path: "/owner?class_id=nftlike1aaaaa1&at_height=100", status: 200},
+		{name: "owners at time", path: "/owner?class_id=nftlike1aaaaa1&at_time=2023-11-14T22:00:00Z", status: 200},
+		{name: "owners at both height and time", path: "/owner?class_id=nftlike1aaaaa1&at_height=100&at_time=2023-11-14T22:00:00Z", status: 400},
+		{name: "owners at negative height", path: "/owner?class_id=nftlike1aaaaa1&at_height=-1", status: 400},
+		{name: "owners at height not extracted", path: "/owner?class_id=nftlike1aaaaa1&at_height=101", status: 400},
+		{name: "nfts at height", path: "/nft?owner=" + ADDR_01_LIKE + "&at_height=100", status: 200},
+		{name: "nfts at invalid time", path: "/nft?owner=" + ADDR_01_LIKE + "&at_time=yesterday", status: 400},
+		{name: "classes owners at height", path: "/classes-owners?class_ids=nftlike1aaaaa1&at_height=100", status: 200},
+		{name: "classes owners at height not extracted", path: "/classes-owners?class_ids=nftlike1aaaaa1&at_height=101", status: 400},
+	}
+	for _, v := range table {
+		t.Run(v.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", rest.NFT_ENDPOINT+v.path, nil)
+			httpRes, body := request(req)
+			require.Equal(t, v.status, httpRes.StatusCode, body)
+		})
+	}
+}
@@ -1,13 +1,12 @@
 package rest
 
 import (
-	"net/http/httputil"
-	"net/url"
-
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/likecoin/likecoin-chain-tx-indexer/db"
-	"github.com/likecoin/likecoin-chain-tx-indexer/logger"
+	"github.com/likecoin/likecoin-chain-tx-indexer/extractor"
+	"github.com/likecoin/likecoin-chain-tx-indexer/lcd"
+	"github.com/likecoin/likecoin-chain-tx-indexer/poller"
 )
 
 const STARGATE_ENDPOINT = "/cosmos/tx/v1beta1/txs"
@@ -16,18 +15,26 @@ const LATEST_HEIGHT_ENDPOINT = "/indexer/height/latest"
 const NFT_ENDPOINT = "/likechain/likenft/v1"
 const ANALYSIS_ENDPOINT = "/statistics"
 const INFO_ENDPOINT = "/indexer/info"
+const HEALTH_ENDPOINT = "/indexer/health"
+const BLOCK_ENDPOINT = "/indexer/blocks"
+const ADMIN_ENDPOINT = "/indexer/admin"
+const TOKEN_ENDPOINT = "/indexer/token"
+const STAKING_ENDPOINT = "/indexer/staking"
+const GOV_ENDPOINT = "/indexer/gov"
+const IBC_ENDPOINT = "/indexer/ibc"
+const AUTHZ_ENDPOINT = "/indexer/authz"
 
-func Run(pool *pgxpool.Pool, listenAddr string, lcdEndpoint string, defaultApiAddresses []string) {
-	lcdURL, err := url.Parse(lcdEndpoint)
-	if err != nil {
-		logger.L.Panicw("Cannot parse lcd URL", "lcd_endpoint", lcdEndpoint, "error", err)
-	}
-	proxy := httputil.NewSingleHostReverseProxy(lcdURL)
+func Run(pool *pgxpool.Pool, listenAddr string, lcdPool *lcd.Pool, defaultApiAddresses []string, adminToken string) {
 	proxyHandler := func(c *gin.Context) {
-		proxy.ServeHTTP(c.Writer, c.Request)
+		lcdPool.ServeHTTP(c.Writer, c.Request)
 	}
 
 	router := GetRouter(pool, defaultApiAddresses)
+	router.GET(HEALTH_ENDPOINT, withLcdPool(lcdPool), handleHealth)
+	AddAdminRoutes(router, adminToken, &poller.CosmosCallContext{
+		Codec: encodingConfig.Amino.Amino,
+		Lcd:   lcdPool,
+	}, extractor.Extractors)
 	router.NoRoute(proxyHandler)
 	_ = router.Run(listenAddr)
 }
@@ -38,6 +45,7 @@ func GetRouter(pool *pgxpool.Pool, defaultApiAddresses []string) *gin.Engine {
 	nft := router.Group(NFT_ENDPOINT)
 	{
 		nft.GET("/class", handleNftClass)
+		nft.GET("/class/config-history", handleNftClassConfigHistory)
 		nft.GET("/nft", handleNft)
 		nft.GET("/owner", handleNftOwner)
 		nft.GET("/event", handleNftEvents)
@@ -47,6 +55,10 @@ func GetRouter(pool *pgxpool.Pool, defaultApiAddresses []string) *gin.Engine {
 		nft.GET("/income", handleNftIncome)
 		nft.GET("/user-stat", handleNftUserStat)
 		nft.GET("/marketplace", handleNftMarketplaceItem)
+		nft.GET("/marketplace/history", handleNftMarketplaceHistory)
+		nft.GET("/marketplace/sellers", handleNftMarketplaceSellers)
+		nft.GET("/marketplace/classes", handleNftMarketplaceClassStats)
+		nft.GET("/marketplace/floor-price-history", handleNftMarketplaceFloorPriceHistory)
 		nft.GET("/collector-top-ranked-creators", handleNftCollectorTopRankedCreatorsRequest)
 		nft.GET("/classes-owners", handleClassesOwnersRequest)
 	}
@@ -61,7 +73,40 @@ func GetRouter(pool *pgxpool.Pool, defaultApiAddresses []string) *gin.Engine {
 		analysis.GET("/nft/owner-count", handleNftOwnerCount)
 		analysis.GET("/nft/owners", handleNftOwnerList)
 	}
+	block := router.Group(BLOCK_ENDPOINT)
+	{
+		block.GET("", handleBlocks)
+		block.GET("/height/:height", handleBlockByHeight)
+		block.GET("/time/:time", handleBlockByTime)
+	}
+	token := router.Group(TOKEN_ENDPOINT)
+	{
+		token.GET("/transfers", handleTokenTransfers)
+		token.GET("/balance", handleTokenBalance)
+	}
+	staking := router.Group(STAKING_ENDPOINT)
+	{
+		staking.GET("/events", handleStakingEvents)
+		staking.GET("/delegations", handleDelegations)
+	}
+	gov := router.Group(GOV_ENDPOINT)
+	{
+		gov.GET("/proposals", handleGovProposals)
+		gov.GET("/proposals/:proposal_id/deposits", handleGovDeposits)
+		gov.GET("/proposals/:proposal_id/votes", handleGovProposalVotes)
+		gov.GET("/proposals/:proposal_id/turnout", handleGovTurnout)
+		gov.GET("/votes", handleGovVotes)
+	}
+	ibc := router.Group(IBC_ENDPOINT)
+	{
+		ibc.GET("/transfers", handleIbcTransfers)
+		ibc.GET("/transfers/in-flight", handleIbcTransfersInFlight)
+		ibc.GET("/transfers/failed", handleIbcTransfersFailed)
+	}
+	router.GET(AUTHZ_ENDPOINT+"/grants", handleAuthzGrants)
 	router.GET(ISCN_ENDPOINT, handleIscn)
+	// ISCN ID prefixes contain slashes, which are already unescaped in the path matched by the routes
+	router.GET(ISCN_ENDPOINT+"/*path", handleIscnHistory)
 	router.GET(STARGATE_ENDPOINT, handleStargateTxsSearch)
 	router.GET(LATEST_HEIGHT_ENDPOINT, handleLatestHeight)
 	router.GET(INFO_ENDPOINT, handleInfo)
@@ -83,6 +128,14 @@ func getDefaultApiAddresses(c *gin.Context) []string {
 	return c.MustGet("default-api-addresses").([]string)
 }
 
+func withLcdPool(lcdPool *lcd.Pool) gin.HandlerFunc {
+	return with("lcd-pool", lcdPool)
+}
+
+func getLcdPool(c *gin.Context) *lcd.Pool {
+	return c.MustGet("lcd-pool").(*lcd.Pool)
+}
+
 func withConn(pool *pgxpool.Pool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		conn, err := db.AcquireFromPool(pool)
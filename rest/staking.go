@@ -0,0 +1,63 @@
+package rest
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/likecoin/likecoin-chain-tx-indexer/db"
+)
+
+func handleStakingEvents(c *gin.Context) {
+	var q db.QueryStakingEventsRequest
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": "invalid inputs: " + err.Error()})
+		return
+	}
+	if q.Delegator == "" && q.Validator == "" {
+		c.AbortWithStatusJSON(400, gin.H{"error": "must provide either delegator or validator"})
+		return
+	}
+	for _, action := range q.Action {
+		switch action {
+		case db.STAKING_ACTION_DELEGATE, db.STAKING_ACTION_UNDELEGATE, db.STAKING_ACTION_REDELEGATE, db.STAKING_ACTION_WITHDRAW_REWARDS:
+		default:
+			c.AbortWithStatusJSON(400, gin.H{"error": "action should only include delegate, undelegate, redelegate or withdraw_rewards"})
+			return
+		}
+	}
+
+	p, err := getPagination(c)
+	if err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := db.GetStakingEvents(getConn(c), q, p)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, res)
+}
+
+func handleDelegations(c *gin.Context) {
+	var q db.QueryDelegationsRequest
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": "invalid inputs: " + err.Error()})
+		return
+	}
+	if q.Delegator == "" && q.Validator == "" {
+		c.AbortWithStatusJSON(400, gin.H{"error": "must provide either delegator or validator"})
+		return
+	}
+	if q.Interval != "day" && q.Interval != "week" && q.Interval != "month" {
+		c.AbortWithStatusJSON(400, gin.H{"error": "interval should be day, week or month"})
+		return
+	}
+
+	res, err := db.GetDelegations(getConn(c), q)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, res)
+}
@@ -95,7 +95,13 @@ func handleNftRecentCreatorCount(c *gin.Context) {
 }
 
 func handleNftOwnerCount(c *gin.Context) {
-	res, err := db.GetNftOwnerCount(getConn(c))
+	var q db.QueryNftOwnerCountRequest
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := db.GetNftOwnerCount(getConn(c), q)
 	if err != nil {
 		c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
 		return
@@ -105,13 +111,18 @@ func handleNftOwnerCount(c *gin.Context) {
 }
 
 func handleNftOwnerList(c *gin.Context) {
-	var q db.PageRequest
+	var p db.PageRequest
+	if err := c.ShouldBindQuery(&p); err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	var q db.QueryNftOwnerListRequest
 	if err := c.ShouldBindQuery(&q); err != nil {
 		c.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
 		return
 	}
 
-	res, err := db.GetNftOwnerList(getConn(c), q)
+	res, err := db.GetNftOwnerList(getConn(c), q, p)
 	if err != nil {
 		c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
 		return
@@ -0,0 +1,55 @@
+package rest
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/likecoin/likecoin-chain-tx-indexer/db"
+)
+
+func handleTokenTransfers(c *gin.Context) {
+	var q db.QueryTokenTransfersRequest
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": "invalid inputs: " + err.Error()})
+		return
+	}
+	if q.Address == "" && q.Sender == "" && q.Recipient == "" {
+		c.AbortWithStatusJSON(400, gin.H{"error": "must provide either address, sender or recipient"})
+		return
+	}
+	if q.Type != "" && q.Type != db.TOKEN_TRANSFER_TYPE_TRANSFER && q.Type != db.TOKEN_TRANSFER_TYPE_FEE {
+		c.AbortWithStatusJSON(400, gin.H{"error": "type should either be transfer or fee"})
+		return
+	}
+
+	p, err := getPagination(c)
+	if err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := db.GetTokenTransfers(getConn(c), q, p)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, res)
+}
+
+func handleTokenBalance(c *gin.Context) {
+	var q db.QueryTokenBalanceRequest
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": "invalid inputs: " + err.Error()})
+		return
+	}
+	if q.Height < 0 {
+		c.AbortWithStatusJSON(400, gin.H{"error": "invalid height"})
+		return
+	}
+
+	res, err := db.GetTokenBalance(getConn(c), q)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, res)
+}
@@ -2,13 +2,30 @@ DELETE FROM txs;
 DELETE FROM iscn_latest_version;
 DELETE FROM iscn_stakeholders;
 DELETE FROM iscn;
+DELETE FROM iscn_event;
 DELETE FROM nft_event;
 DELETE FROM nft;
 DELETE FROM nft_class;
+DELETE FROM nft_class_mint_period;
+DELETE FROM nft_class_royalty_stakeholder;
+DELETE FROM nft_class_config_history;
+DELETE FROM resolved_metadata;
 DELETE FROM nft_marketplace;
+DELETE FROM nft_marketplace_history;
+DELETE FROM nft_marketplace_floor_price;
 DELETE FROM nft_income;
+DELETE FROM blocks;
+DELETE FROM extraction_failures;
+DELETE FROM token_transfer;
+DELETE FROM token_balance_change;
+DELETE FROM staking_event;
+DELETE FROM gov_proposal;
+DELETE FROM gov_deposit;
+DELETE FROM gov_vote;
+DELETE FROM ibc_transfer;
+DELETE FROM authz_grant;
 UPDATE meta SET height = 0
-  WHERE id = 'extractor_v1'
+  WHERE id LIKE 'extractor_%'
       OR id = 'latest_block_height'
       OR id = 'latest_block_time_epoch_ns'
 ;
@@ -8,3 +8,4 @@ DROP TABLE nft;
 DROP TABLE nft_class;
 DROP TABLE nft_marketplace;
 DROP TABLE nft_income;
+DROP TABLE blocks;
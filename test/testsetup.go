@@ -161,7 +161,9 @@ type DBTestData struct {
 	NftEvents           []db.NftEvent
 	NftMarketplaceItems []db.NftMarketplaceItem
 	Txs                 []string
+	Blocks              []db.Block
 	ExtractorHeight     int64
+	ExtractorHeights    map[string]int64
 	LatestBlockHeight   int64
 	LatestBlockTime     *time.Time
 }
@@ -194,15 +196,16 @@ func InsertTestData(testData DBTestData) {
 		sql := `
 		INSERT INTO nft (
 			nft_id, class_id, owner, uri, uri_hash,
-			metadata, latest_price, price_updated_at
+			metadata, latest_price, price_updated_at, burned_at
 		)
 		VALUES
-		($1, $2, $3, $4, $5, $6, $7, $8)
+		($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		ON CONFLICT DO NOTHING`
 		b.Batch.Queue(sql,
 			n.NftId, n.ClassId, n.Owner, n.Uri, n.UriHash,
-			n.Metadata, n.LatestPrice, time.Unix(0, 0).UTC(),
+			n.Metadata, n.LatestPrice, time.Unix(0, 0).UTC(), n.BurnedAt,
 		)
+		b.EnqueueResolveMetadata(n.Uri, n.UriHash)
 	}
 	for _, e := range testData.NftEvents {
 		e.Timestamp = e.Timestamp.UTC()
@@ -231,6 +234,9 @@ func InsertTestData(testData DBTestData) {
 		item.Expiration = item.Expiration.UTC()
 		b.InsertNFTMarketplaceItem(item)
 	}
+	for _, block := range testData.Blocks {
+		b.InsertBlock(block)
+	}
 	for i, tx := range testData.Txs {
 		height := 1
 		type Log struct {
@@ -266,6 +272,9 @@ func InsertTestData(testData DBTestData) {
 	if testData.ExtractorHeight != 0 {
 		b.UpdateMetaHeight(db.META_EXTRACTOR, testData.ExtractorHeight)
 	}
+	for name, height := range testData.ExtractorHeights {
+		b.Batch.Queue("INSERT INTO meta (id, height) VALUES ($1, $2) ON CONFLICT (id) DO UPDATE SET height = EXCLUDED.height", db.ExtractorMetaKey(name), height)
+	}
 	err := b.Flush()
 	if err != nil {
 		logger.L.Panicw("failed to insert test data", "err", err)